@@ -2,13 +2,12 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
 
 	"github.com/AllenDang/cimgui-go/imgui"
 	"github.com/anasrar/chihuahua/pkg/dat"
 	rlig "github.com/anasrar/chihuahua/pkg/raylib_imgui"
+	"github.com/anasrar/chihuahua/pkg/utils"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
@@ -23,18 +22,23 @@ func clearLog() {
 }
 
 func drop(filePath string) error {
-	buf, err := os.ReadFile(filePath)
-	if err != nil {
+	var m dat.Metadata
+	if err := dat.MetadataFromPath(&m, filePath); err != nil {
+		datMetadata = nil
 		return err
 	}
 
-	var m dat.Metadata
-	if err := json.Unmarshal(buf, &m); err != nil {
+	changes, err := m.Validate(utils.ParentDirectory(filePath))
+	if err != nil {
+		datMetadata = nil
 		return err
 	}
-	datMetadata = &m
 
+	datMetadata = &m
 	writeLog(fmt.Sprintf("Entry Total: %d", m.EntryTotal))
+	for _, change := range changes {
+		writeLog(change)
+	}
 	writeLog("Ready")
 
 	return nil
@@ -104,31 +108,33 @@ func gui() {
 					ctx, cancel = context.WithCancel(context.Background())
 
 					go func() {
+						// NOTE: entries are processed by multiple workers, so current is not in order
+						done := 0
 						if err := pack(
 							ctx,
 							metadataPath,
+							"",
+							workers,
+							// NOTE: changes is already shown on drop
+							func(message string) {},
 							func(total, current uint32, name string) {
 								writeLog(fmt.Sprintf("%d/%d(%s): start", current, total, name))
 							},
 							func(total, current uint32, name string) {
 								writeLog(fmt.Sprintf("%d/%d(%s): done", current, total, name))
 
-								progress = float32(current) / float32(total)
-								if total == current {
-									writeLog("Done")
-
-									progress = 0
-									canPack = true
-									canCancel = false
-								}
+								done += 1
+								progress = float32(done) / float32(total)
 							},
 						); err != nil {
 							writeLog(err.Error())
-
-							progress = 0
-							canPack = true
-							canCancel = false
+						} else {
+							writeLog("Done")
 						}
+
+						progress = 0
+						canPack = true
+						canCancel = false
 					}()
 
 					progress = 0
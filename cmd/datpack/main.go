@@ -11,6 +11,8 @@ import (
 
 func init() {
 	flag.StringVar(&metadataPath, "metadatapath", "", "Path to METADATA.json file")
+	flag.StringVar(&verifyPath, "verifypath", "", "Path to source DAT file to verify packed DAT (optional)")
+	flag.IntVar(&workers, "workers", 0, "Number of entries packed at the same time, 0 use CPU count")
 }
 
 func main() {
@@ -23,6 +25,11 @@ func main() {
 		if err := pack(
 			ctx,
 			metadataPath,
+			verifyPath,
+			workers,
+			func(message string) {
+				log.Println(message)
+			},
 			func(total, current uint32, name string) {
 				log.Printf("% 8d/%d(%s): start\n", current, total, name)
 			},
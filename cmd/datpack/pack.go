@@ -2,8 +2,6 @@ package main
 
 import (
 	"context"
-	"encoding/json"
-	"os"
 	"path/filepath"
 
 	"github.com/anasrar/chihuahua/pkg/dat"
@@ -13,22 +11,32 @@ import (
 func pack(
 	ctx context.Context,
 	metadataPath string,
+	verifyPath string,
+	workers int,
+	onChange func(message string),
 	onStart,
 	onDone func(total uint32, current uint32, name string),
 ) error {
-	metadataBuf, err := os.ReadFile(metadataPath)
-	if err != nil {
+	var m dat.Metadata
+	if err := dat.MetadataFromPath(&m, metadataPath); err != nil {
 		return err
 	}
 
-	var m dat.Metadata
-	if err := json.Unmarshal(metadataBuf, &m); err != nil {
+	parentDir := utils.ParentDirectory(metadataPath)
+
+	changes, err := m.Validate(parentDir)
+	if err != nil {
 		return err
 	}
 
-	d := dat.New()
+	for _, change := range changes {
+		onChange(change)
+	}
 
-	parentDir := utils.ParentDirectory(metadataPath)
+	d := dat.New()
+	d.HeaderSize = m.HeaderSize
+	d.Alignment = m.Alignment
+	d.Order = m.Order
 
 	for _, entry := range m.Entries {
 		if entry.IsNull {
@@ -45,14 +53,22 @@ func pack(
 		}
 	}
 
-	if err := d.Pack(
+	output := filepath.Join(parentDir, "OUTPUT.dat")
+	if err := d.PackWithWorkers(
 		ctx,
-		filepath.Join(parentDir, "OUTPUT.dat"),
+		output,
+		workers,
 		onStart,
 		onDone,
 	); err != nil {
 		return err
 	}
 
+	if verifyPath != "" {
+		if err := dat.Verify(output, verifyPath); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
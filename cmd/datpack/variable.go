@@ -10,6 +10,8 @@ import (
 var GitCommitHash = "Dev Mode"
 
 var metadataPath = ""
+var verifyPath = ""
+var workers = 0
 var datMetadata *dat.Metadata = nil
 
 var (
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/anasrar/chihuahua/pkg/akg"
+	"github.com/anasrar/chihuahua/pkg/dat"
+	"github.com/anasrar/chihuahua/pkg/utils"
+)
+
+// NOTE: parsed AKG next to unpacked AKG entry for reading only, datpack use unpacked file and ignore the JSON
+func writeAkgJson(dir string, md *dat.Metadata) error {
+	for _, entry := range md.Entries {
+		if entry.IsNull || utils.FilterUnprintableString(entry.Type) != "AKG" {
+			continue
+		}
+
+		source := filepath.Join(dir, entry.Source)
+
+		a := akg.New()
+		if err := akg.FromPath(a, source); err != nil {
+			return err
+		}
+
+		buf, err := json.MarshalIndent(a, "", "\t")
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(source+".json", buf, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
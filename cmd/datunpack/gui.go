@@ -91,31 +91,31 @@ func gui() {
 					ctx, cancel = context.WithCancel(context.Background())
 
 					go func() {
+						// NOTE: entries are processed by multiple workers, so current is not in order
+						done := 0
 						if err := unpack(
 							ctx,
 							datPath,
+							workers,
+							akgJson,
 							func(total, current uint32, name string) {
 								writeLog(fmt.Sprintf("%d/%d(%s): start", current, total, name))
 							},
 							func(total, current uint32, name string) {
 								writeLog(fmt.Sprintf("%d/%d(%s): done", current, total, name))
 
-								progress = float32(current) / float32(total)
-								if total == current {
-									writeLog("Done")
-
-									progress = 0
-									canUnpack = true
-									canCancel = false
-								}
+								done += 1
+								progress = float32(done) / float32(total)
 							},
 						); err != nil {
 							writeLog(err.Error())
-
-							progress = 0
-							canUnpack = true
-							canCancel = false
+						} else {
+							writeLog("Done")
 						}
+
+						progress = 0
+						canUnpack = true
+						canCancel = false
 					}()
 
 					progress = 0
@@ -144,6 +144,9 @@ func gui() {
 					}
 				}
 
+				imgui.SameLineV(0, 12)
+				imgui.Checkbox("AKG JSON", &akgJson)
+
 				if datData != nil {
 					imgui.SameLineV(0, 12)
 					imgui.Text(fmt.Sprintf("Entries: %d", datData.EntryTotal))
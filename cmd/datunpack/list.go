@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/anasrar/chihuahua/pkg/iso9660"
+)
+
+// NOTE: print every DAT inside ISO as path that can be used as datpath
+func list(isoPath string, onFile func(p string, size uint32)) error {
+	file, err := os.Open(isoPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	iso := iso9660.New()
+	if err := iso9660.FromStream(iso, file); err != nil {
+		return err
+	}
+
+	return iso.Walk(file, func(p string, record *iso9660.Record) error {
+		if !record.IsDir && strings.HasSuffix(strings.ToLower(p), ".dat") {
+			onFile(fmt.Sprintf("%s:%s", isoPath, p), record.Size)
+		}
+
+		return nil
+	})
+}
@@ -6,23 +6,35 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 )
 
 func init() {
-	flag.StringVar(&datPath, "datpath", "", "Path to dat file")
+	flag.StringVar(&datPath, "datpath", "", "Path to dat file, file inside ISO use game.iso:/DAT/xxx.dat, ISO path list every DAT")
+	flag.IntVar(&workers, "workers", 0, "Number of entries unpacked at the same time, 0 use CPU count")
+	flag.BoolVar(&akgJson, "akgjson", false, "Write parsed AKG entry as JSON next to unpacked file (AKG_000.akg.json)")
 }
 
 func main() {
 	flag.Parse()
 
-	if datPath != "" {
+	if strings.EqualFold(filepath.Ext(datPath), ".iso") {
+		if err := list(datPath, func(p string, size uint32) {
+			log.Printf("%s (%d bytes)\n", p, size)
+		}); err != nil {
+			log.Fatalln(err)
+		}
+	} else if datPath != "" {
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 		defer cancel()
 
 		if err := unpack(
 			ctx,
 			datPath,
+			workers,
+			akgJson,
 			func(total, current uint32, name string) {
 				log.Printf("% 8d/%d(%s): start\n", current, total, name)
 			},
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -15,6 +14,8 @@ import (
 func unpack(
 	ctx context.Context,
 	datPath string,
+	workers int,
+	akgJson bool,
 	onStart,
 	onDone func(total uint32, current uint32, name string),
 ) error {
@@ -30,7 +31,11 @@ func unpack(
 	)
 
 	md := dat.Metadata{
+		Version:    dat.MetadataVersion,
 		EntryTotal: d.EntryTotal,
+		HeaderSize: d.HeaderSize,
+		Alignment:  d.Alignment,
+		Order:      d.Order,
 		Entries:    []*dat.MetadataEntry{},
 	}
 
@@ -57,6 +62,8 @@ func unpack(
 					IsNull: false,
 					Source: source,
 					Type:   entry.Type,
+					Size:   entry.Size,
+					Offset: entry.Offset - d.Offset,
 				},
 			)
 		}
@@ -71,24 +78,27 @@ func unpack(
 		return err
 	}
 
-	if err := d.Unpack(ctx, outputFilesDirPath, onStart, onDone); err != nil {
+	if err := d.UnpackWithWorkers(ctx, outputFilesDirPath, workers, onStart, onDone); err != nil {
 		return err
 	}
 
-	buf, err := json.MarshalIndent(md, "", "\t")
-	if err != nil {
-		return err
-	}
+	for _, entry := range md.Entries {
+		if entry.IsNull {
+			continue
+		}
 
-	metadataFile, err := os.OpenFile(outputMetadataPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
-	if err != nil {
-		return err
+		checksum, err := utils.ChecksumFile(filepath.Join(utils.ParentDirectory(outputMetadataPath), entry.Source))
+		if err != nil {
+			return err
+		}
+		entry.Checksum = checksum
 	}
-	defer metadataFile.Close()
 
-	if _, err := metadataFile.Write(buf); err != nil {
-		return err
+	if akgJson {
+		if err := writeAkgJson(utils.ParentDirectory(outputMetadataPath), &md); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	return dat.MetadataToPath(&md, outputMetadataPath)
 }
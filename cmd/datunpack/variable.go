@@ -10,6 +10,8 @@ import (
 var GitCommitHash = "Dev Mode"
 
 var datPath = ""
+var workers = 0
+var akgJson = false
 var datData *dat.Dat = nil
 
 type OffsetUnit int
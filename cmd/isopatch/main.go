@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/anasrar/chihuahua/pkg/iso9660"
+	"github.com/anasrar/chihuahua/pkg/utils"
+)
+
+func init() {
+	flag.StringVar(&isoPath, "iso", "", "Path to original ISO file")
+	flag.StringVar(&replacePath, "replace", "", "Path to directory with the same layout as ISO (ex: replace/DAT/pl00.dat)")
+	flag.StringVar(&outputPath, "output", "", "Path to output file, PATCHED_<name> next to ISO when empty")
+	flag.StringVar(&format, "format", format, "Output format (ISO, XDELTA, or PPF)")
+}
+
+func main() {
+	flag.Parse()
+
+	if isoPath == "" || replacePath == "" {
+		flag.Usage()
+		return
+	}
+
+	f := iso9660.PatchFormatFromString(format)
+	if f == iso9660.PatchFormatUnknown {
+		log.Fatalf("Unknown format %s\n", format)
+	}
+
+	if outputPath == "" {
+		outputPath = filepath.Join(
+			utils.ParentDirectory(isoPath),
+			fmt.Sprintf("PATCHED_%s%s", utils.BasenameWithoutExt(isoPath), f.Ext()),
+		)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if err := patch(
+		ctx,
+		isoPath,
+		replacePath,
+		outputPath,
+		f,
+		func(total, current uint32, name string) {
+			log.Printf("% 8d/%d(%s): start\n", current, total, name)
+		},
+		func(total, current uint32, name string) {
+			log.Printf("% 8d/%d(%s): done\n", current, total, name)
+		},
+	); err != nil {
+		log.Fatalln(err)
+	}
+
+	log.Printf("%s written\n", outputPath)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anasrar/chihuahua/pkg/iso9660"
+)
+
+func patch(
+	ctx context.Context,
+	isoPath string,
+	replacePath string,
+	outputPath string,
+	format iso9660.PatchFormat,
+	onStart,
+	onDone func(total uint32, current uint32, name string),
+) error {
+	replacements, err := iso9660.ReplacementsFromDir(replacePath)
+	if err != nil {
+		return err
+	}
+
+	if len(replacements) == 0 {
+		return fmt.Errorf("No file in %s", replacePath)
+	}
+
+	return iso9660.RebuildToPath(ctx, isoPath, replacements, outputPath, format, onStart, onDone)
+}
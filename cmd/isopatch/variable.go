@@ -0,0 +1,6 @@
+package main
+
+var isoPath = ""
+var replacePath = ""
+var outputPath = ""
+var format = "ISO"
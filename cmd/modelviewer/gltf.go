@@ -1,13 +1,12 @@
 package main
 
 import (
-	"bytes"
 	"fmt"
-	"image/png"
 	"os"
 	"path/filepath"
 
 	"github.com/anasrar/chihuahua/pkg/scr"
+	"github.com/anasrar/chihuahua/pkg/texture"
 	"github.com/anasrar/chihuahua/pkg/tim3"
 	"github.com/anasrar/chihuahua/pkg/tm3"
 	"github.com/anasrar/chihuahua/pkg/utils"
@@ -19,9 +18,24 @@ func ConvertModelToGlft(
 	datPath string,
 	tm3Entry *Entry,
 	mdEntry *Entry,
-	textureShift int,
+	bindings map[uint16]int,
+	option texture.GltfOption,
 ) error {
+	output := filepath.Join(
+		utils.ParentDirectory(datPath),
+		fmt.Sprintf("UNPACK_%s", utils.Basename(datPath)),
+		"FILES", "MD",
+		fmt.Sprintf("GLTF_%s.md", mdEntry.Name),
+	)
+
+	if err := os.MkdirAll(output, os.ModePerm); err != nil {
+		return err
+	}
+
+	option.Dir = output
+
 	doc := gltf.NewDocument()
+	textured := map[int]int{}
 	materials := map[uint16]int{}
 	zero := float64(0)
 	one := float64(1)
@@ -37,19 +51,18 @@ func ConvertModelToGlft(
 			return err
 		}
 
-		var buf bytes.Buffer
 		picture := tim.Pictures[0]
-		if err := png.Encode(&buf, tim3.PictureToImage(picture)); err != nil {
+		nrgba, err := tim3.PictureToImage(picture)
+		if err != nil {
 			return err
 		}
 
-		index, _ := modeler.WriteImage(doc, fmt.Sprintf("%s_%03d", entry.Name, i), "image/png", &buf)
-		doc.Textures = append(doc.Textures, &gltf.Texture{
-			Source: gltf.Index(index),
-		})
+		index, err := texture.WriteGltfTexture(doc, fmt.Sprintf("%s_%03d", utils.FilterUnprintableString(entry.Name), i), nrgba, &option)
+		if err != nil {
+			return err
+		}
 
-		k := len(doc.Materials)
-		materials[uint16(i)] = k
+		textured[i] = len(doc.Materials)
 		doc.Materials = append(doc.Materials,
 			&gltf.Material{
 				PBRMetallicRoughness: &gltf.PBRMetallicRoughness{
@@ -64,19 +77,14 @@ func ConvertModelToGlft(
 		)
 	}
 
-	s := scr.New()
-	if err := scr.FromPathWithOffset(s, mdEntry.Source, mdEntry.Offset); err != nil {
-		return err
+	for material, index := range bindings {
+		if k, ok := textured[index]; ok {
+			materials[material] = k
+		}
 	}
 
-	output := filepath.Join(
-		utils.ParentDirectory(datPath),
-		fmt.Sprintf("UNPACK_%s", utils.Basename(datPath)),
-		"FILES", "MD",
-		fmt.Sprintf("GLTF_%s.md", mdEntry.Name),
-	)
-
-	if err := os.MkdirAll(output, os.ModePerm); err != nil {
+	s := scr.New()
+	if err := scr.FromPathWithOffset(s, mdEntry.Source, mdEntry.Offset); err != nil {
 		return err
 	}
 
@@ -156,10 +164,10 @@ func ConvertModelToGlft(
 		primitives := []*gltf.Primitive{}
 
 		for _, vb := range node.Mdb.VertexBuffers {
-			materialIndex, ok := materials[vb.Material+uint16(textureShift)]
+			materialIndex, ok := materials[vb.Material]
 			if !ok {
 				k := len(doc.Materials)
-				materials[vb.Material+uint16(textureShift)] = k
+				materials[vb.Material] = k
 				materialIndex = k
 				doc.Materials = append(doc.Materials,
 					&gltf.Material{
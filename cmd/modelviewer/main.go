@@ -2,13 +2,16 @@ package main
 
 import (
 	"bytes"
+	"flag"
 	"fmt"
 	"image/png"
 	"log"
+	"slices"
 
 	"github.com/AllenDang/cimgui-go/imgui"
 	"github.com/anasrar/chihuahua/pkg/bone"
 	"github.com/anasrar/chihuahua/pkg/dat"
+	"github.com/anasrar/chihuahua/pkg/iso9660"
 	"github.com/anasrar/chihuahua/pkg/mot"
 	rlig "github.com/anasrar/chihuahua/pkg/raylib_imgui"
 	"github.com/anasrar/chihuahua/pkg/scr"
@@ -43,7 +46,12 @@ func loadModel(index int) error {
 			}
 
 			buf := bytes.NewBuffer([]byte{})
-			if err := png.Encode(buf, tim3.PictureToImage(tim.Pictures[0])); err != nil {
+			nrgba, err := tim3.PictureToImage(tim.Pictures[0])
+			if err != nil {
+				return err
+			}
+
+			if err := png.Encode(buf, nrgba); err != nil {
 				return err
 			}
 
@@ -56,7 +64,6 @@ func loadModel(index int) error {
 			textureIndices = append(textureIndices, i)
 		}
 
-		textureShift = 0
 	}
 
 	{
@@ -173,18 +180,14 @@ func loadModel(index int) error {
 				model.Bones = &bones[0]
 				model.BindPose = &pose[0]
 
-				if texture, found := textures[int(vb.Material)]; found {
-					rl.SetMaterialTexture(model.Materials, rl.MapDiffuse, texture.Texture)
-				} else {
-					rl.SetMaterialTexture(model.Materials, rl.MapDiffuse, textureDefault)
-				}
+				rl.SetMaterialTexture(model.Materials, rl.MapDiffuse, textureDefault)
 
 				models = append(
 					models,
 					NewModel(
 						name,
 						&model,
-						int(vb.Material),
+						vb.Material,
 						rl.NewVector3(node.Translation[0], node.Translation[1], node.Translation[2]),
 						rl.NewVector3(node.Rotation[0], node.Rotation[1], node.Rotation[2]),
 						rl.NewVector3(node.Scale[0], node.Scale[1], node.Scale[2]),
@@ -205,6 +208,28 @@ func loadModel(index int) error {
 				node,
 			)
 		}
+
+		file, err := iso9660.Open(tm3Entry.Source)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		base := 0
+		if container != nil {
+			if base, err = scr.TextureBase(container, file, slices.Index(container.Entries, tm3Entry.Entry)); err != nil {
+				return err
+			}
+		}
+
+		resolved, err := s.ResolveMaterials(textureTotal, base)
+		bindings = resolved
+		bindTextures()
+
+		// NOTE: model is still shown, uncovered material is drawn without texture
+		if err != nil {
+			rlig.ShowError(err)
+		}
 	}
 
 	modelIndex = index
@@ -247,6 +272,7 @@ func drop(filePath string) error {
 		return fmt.Errorf("MD not found")
 	}
 
+	container = dat0
 	tm3Entries = tmpTm3Entries
 	mdEntries = tmpMdEntries
 	motEntries = tmpMotEntries
@@ -309,7 +335,13 @@ func loadMotion(index int) error {
 	return nil
 }
 
+func init() {
+	flag.StringVar(&openPath, "path", "", "Path to XXX.dat file to open on start, file inside ISO use game.iso:/DAT/xxx.dat")
+}
+
 func main() {
+	flag.Parse()
+
 	rl.InitWindow(int32(width), int32(height), "Model Viewer")
 	defer rl.CloseWindow()
 	rl.SetTargetFPS(30)
@@ -330,6 +362,12 @@ func main() {
 	boneRender = rl.LoadRenderTexture(int32(width), int32(height))
 	defer rl.UnloadRenderTexture(boneRender)
 
+	if openPath != "" {
+		if err := drop(openPath); err != nil {
+			rlig.ShowError(err)
+		}
+	}
+
 	for !rl.WindowShouldClose() {
 		rlig.Update()
 
@@ -346,7 +384,7 @@ func main() {
 			defer rl.UnloadDroppedFiles()
 
 			if err := drop(filePath); err != nil {
-				log.Println(err)
+				rlig.ShowError(err)
 			}
 		}
 
@@ -431,7 +469,7 @@ func main() {
 			imgui.BeginDisabledV(i == modelIndex)
 			if imgui.Button("View") {
 				if err := loadModel(i); err != nil {
-					log.Println(err)
+					rlig.ShowError(err)
 				}
 			}
 			imgui.EndDisabled()
@@ -444,36 +482,16 @@ func main() {
 		imgui.SetNextWindowPosV(imgui.NewVec2(12, 224), imgui.CondFirstUseEver, imgui.NewVec2(0, 0))
 		imgui.SetNextWindowSizeV(imgui.NewVec2(200, 300), imgui.CondFirstUseEver)
 		imgui.BeginV("Inspector", nil, imgui.WindowFlagsNone)
-		imgui.BeginDisabledV(textureTotal == 0)
-		if imgui.Button("Shift -1") {
-			textureShift = (textureShift - 1) % textureTotal
-			for _, model := range models {
-				if texture, found := textures[model.Texture+textureShift]; found {
-					rl.SetMaterialTexture(model.Model.Materials, rl.MapDiffuse, texture.Texture)
-				} else {
-					rl.SetMaterialTexture(model.Model.Materials, rl.MapDiffuse, textureDefault)
-				}
-			}
-		}
-		imgui.SameLineV(0, 4)
-		if imgui.Button("Shift +1") {
-			textureShift = (textureShift + 1) % textureTotal
-			for _, model := range models {
-				if texture, found := textures[model.Texture+textureShift]; found {
-					rl.SetMaterialTexture(model.Model.Materials, rl.MapDiffuse, texture.Texture)
-				} else {
-					rl.SetMaterialTexture(model.Model.Materials, rl.MapDiffuse, textureDefault)
-				}
-			}
-		}
-		imgui.EndDisabled()
 		imgui.Checkbox("Show Bones", &showBones)
 		imgui.BeginDisabledV(len(models) == 0)
+		rlig.GltfOption(gltfOption)
+		imgui.NewLine()
 		if imgui.Button("Convert To GLTF") {
+			option := *gltfOption
 			go func() {
 				log.Println("Convert Model to GLTF")
-				if err := ConvertModelToGlft(datPath, tm3Entries[modelIndex], mdEntries[modelIndex], textureShift); err != nil {
-					log.Println(err)
+				if err := ConvertModelToGlft(datPath, tm3Entries[modelIndex], mdEntries[modelIndex], bindings, option); err != nil {
+					rlig.ShowError(err)
 				} else {
 					log.Println("Convert done")
 				}
@@ -502,7 +520,7 @@ func main() {
 			imgui.BeginDisabledV(i == motionIndex)
 			if imgui.Button("Play") {
 				if err := loadMotion(i); err != nil {
-					log.Println(err)
+					rlig.ShowError(err)
 				}
 			}
 			imgui.EndDisabled()
@@ -561,6 +579,7 @@ func main() {
 			rl.DrawTextureRec(boneRender.Texture, rl.NewRectangle(0, 0, width, -height), rl.Vector2Zero(), rl.White)
 		}
 
+		rlig.ErrorPopup()
 		rlig.Render()
 		rl.EndDrawing()
 	}
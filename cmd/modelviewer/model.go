@@ -5,7 +5,7 @@ import rl "github.com/gen2brain/raylib-go/raylib"
 type Model struct {
 	Name        string
 	Model       *rl.Model
-	Texture     int
+	Material    uint16
 	Render      bool
 	Translation rl.Vector3
 	Rotation    rl.Vector3
@@ -15,7 +15,7 @@ type Model struct {
 func NewModel(
 	name string,
 	model *rl.Model,
-	texture int,
+	material uint16,
 	translation rl.Vector3,
 	rotation rl.Vector3,
 	scale rl.Vector3,
@@ -23,10 +23,24 @@ func NewModel(
 	return &Model{
 		Name:        name,
 		Model:       model,
-		Texture:     texture,
+		Material:    material,
 		Render:      true,
 		Translation: translation,
 		Rotation:    rotation,
 		Scale:       scale,
 	}
 }
+
+// NOTE: bind texture from material binding, default texture when material is not bound
+func bindTextures() {
+	for _, model := range models {
+		texture := textureDefault
+		if index, found := bindings[model.Material]; found {
+			if t, found := textures[index]; found {
+				texture = t.Texture
+			}
+		}
+
+		rl.SetMaterialTexture(model.Model.Materials, rl.MapDiffuse, texture)
+	}
+}
@@ -2,10 +2,14 @@ package main
 
 import (
 	"github.com/anasrar/chihuahua/pkg/bone"
+	"github.com/anasrar/chihuahua/pkg/dat"
+	"github.com/anasrar/chihuahua/pkg/texture"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
 var datPath = ""
+var openPath = ""
+var container *dat.Dat
 
 var (
 	width  float32 = 1000
@@ -25,7 +29,8 @@ var textureDefault rl.Texture2D
 var textures = map[int]*Texture{}
 var textureIndices = []int{}
 var textureTotal = 0
-var textureShift = 0
+var bindings = map[uint16]int{} // NOTE: material to TM3 entry index
+var gltfOption = texture.NewGltfOption("")
 
 var mdEntries = []*Entry{}
 var models = []*Model{}
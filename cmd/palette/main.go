@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/anasrar/chihuahua/pkg/palette"
+)
+
+func init() {
+	flag.StringVar(&timPath, "timpath", "", "Path to TIM2, TIM3, or T32 file")
+	flag.StringVar(&palettePath, "palettepath", "", "Path to palette file (ACT, GPL, or PAL) to import, export when empty")
+	flag.StringVar(&format, "format", "ACT", "Palette format output (ACT, GPL, or PAL)")
+	flag.IntVar(&pictureIndex, "picture", 0, "Picture index (TIM2 and TIM3)")
+}
+
+func main() {
+	flag.Parse()
+
+	if timPath == "" {
+		flag.Usage()
+		return
+	}
+
+	if palettePath == "" {
+		f := palette.FormatFromString(format)
+		if f == palette.FormatUnknown {
+			log.Fatalln("Allowed format is ACT, GPL, and PAL")
+		}
+
+		output, err := export(timPath, pictureIndex, f)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		log.Printf("Exported %s\n", output)
+	} else {
+		if err := replace(timPath, pictureIndex, palettePath); err != nil {
+			log.Fatalln(err)
+		}
+		log.Printf("Imported %s\n", palettePath)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/palette"
+	"github.com/anasrar/chihuahua/pkg/t32"
+	"github.com/anasrar/chihuahua/pkg/tim2"
+	"github.com/anasrar/chihuahua/pkg/tim3"
+	"github.com/anasrar/chihuahua/pkg/utils"
+)
+
+type Kind uint8
+
+const (
+	KindUnknown Kind = iota
+	KindTim2
+	KindTim3
+	KindT32
+)
+
+func kindFromPath(filePath string) (Kind, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return KindUnknown, err
+	}
+	defer file.Close()
+
+	signature := uint32(0)
+	if _, err := buffer.ReadUint32LE(file, &signature); err != nil {
+		return KindUnknown, err
+	}
+
+	switch signature {
+	case tim2.Signature:
+		return KindTim2, nil
+	case tim3.Signature:
+		return KindTim3, nil
+	}
+
+	// NOTE: T32 has no signature
+	if strings.ToLower(filepath.Ext(filePath)) == ".t32" {
+		return KindT32, nil
+	}
+
+	return KindUnknown, fmt.Errorf("Format not supported")
+}
+
+func clut(filePath string, kind Kind, pictureIndex int) ([]*color.RGBA, error) {
+	switch kind {
+	case KindTim2:
+		tim := tim2.New()
+		if err := tim2.FromPath(tim, filePath); err != nil {
+			return nil, err
+		}
+
+		if pictureIndex < 0 || pictureIndex >= len(tim.Pictures) {
+			return nil, fmt.Errorf("Picture index %d out of range, total %d", pictureIndex, len(tim.Pictures))
+		}
+
+		return tim.Pictures[pictureIndex].ClutData, nil
+	case KindTim3:
+		tim := tim3.New()
+		if err := tim3.FromPath(tim, filePath); err != nil {
+			return nil, err
+		}
+
+		if pictureIndex < 0 || pictureIndex >= len(tim.Pictures) {
+			return nil, fmt.Errorf("Picture index %d out of range, total %d", pictureIndex, len(tim.Pictures))
+		}
+
+		return tim.Pictures[pictureIndex].ClutData, nil
+	case KindT32:
+		t := t32.New()
+		if err := t32.FromPath(t, filePath); err != nil {
+			return nil, err
+		}
+
+		return t.ClutData, nil
+	default:
+		return nil, fmt.Errorf("Format not supported")
+	}
+}
+
+func export(filePath string, pictureIndex int, format palette.Format) (string, error) {
+	kind, err := kindFromPath(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	colors, err := clut(filePath, kind, pictureIndex)
+	if err != nil {
+		return "", err
+	}
+
+	name := utils.BasenameWithoutExt(filePath)
+	if kind != KindT32 {
+		name = fmt.Sprintf("%s_%03d", name, pictureIndex)
+	}
+
+	output := filepath.Join(
+		utils.ParentDirectory(filePath),
+		fmt.Sprintf("%s%s", name, format.Ext()),
+	)
+
+	if err := palette.ToPath(palette.FromColors(format, name, colors), output); err != nil {
+		return "", err
+	}
+
+	return output, nil
+}
+
+func replace(filePath string, pictureIndex int, palettePath string) error {
+	format := palette.FormatFromPath(palettePath)
+	if format == palette.FormatUnknown {
+		return fmt.Errorf("Palette extension should be .act, .gpl, or .pal")
+	}
+
+	p := palette.New(format)
+	if err := palette.FromPath(p, palettePath); err != nil {
+		return err
+	}
+
+	kind, err := kindFromPath(filePath)
+	if err != nil {
+		return err
+	}
+
+	colors, err := clut(filePath, kind, pictureIndex)
+	if err != nil {
+		return err
+	}
+
+	if len(p.Colors) > len(colors) {
+		return fmt.Errorf("Palette colors exceeds CLUT colors, expected %d, got %d", len(colors), len(p.Colors))
+	}
+
+	colors = p.ApplyTo(colors)
+
+	switch kind {
+	case KindTim2:
+		return tim2.WriteClut(filePath, pictureIndex, colors)
+	case KindTim3:
+		return tim3.WriteClut(filePath, pictureIndex, colors)
+	case KindT32:
+		return t32.WriteClut(filePath, colors)
+	default:
+		return fmt.Errorf("Format not supported")
+	}
+}
@@ -0,0 +1,6 @@
+package main
+
+var timPath = ""
+var palettePath = ""
+var format = "ACT"
+var pictureIndex = 0
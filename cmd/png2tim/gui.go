@@ -11,6 +11,7 @@ import (
 
 	"github.com/AllenDang/cimgui-go/imgui"
 	rlig "github.com/anasrar/chihuahua/pkg/raylib_imgui"
+	"github.com/anasrar/chihuahua/pkg/tim2"
 	"github.com/anasrar/chihuahua/pkg/utils"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
@@ -53,8 +54,7 @@ func drop(filePath string) error {
 
 	colors = []color.RGBA{}
 	for _, v := range imgPaletted.Palette {
-		c, _ := v.(color.RGBA)
-		colors = append(colors, color.RGBA{R: c.R, G: c.G, B: c.B, A: c.A})
+		colors = append(colors, *tim2.ColorToClut(v))
 	}
 
 	matrix = rl.MatrixTranslate(
@@ -0,0 +1,408 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/AllenDang/cimgui-go/imgui"
+	"github.com/AllenDang/cimgui-go/imguizmo"
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/dat"
+	"github.com/anasrar/chihuahua/pkg/ems"
+	"github.com/anasrar/chihuahua/pkg/oms"
+	rlig "github.com/anasrar/chihuahua/pkg/raylib_imgui"
+	"github.com/anasrar/chihuahua/pkg/utils"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const (
+	CameraNear float32 = 0.01
+	CameraFar  float32 = 1000
+)
+
+func noop(total uint32, current uint32, name string) {}
+
+// NOTE: selected translation, nil when nothing is selected
+func selectedTranslation() *[3]float32 {
+	if selectedObject != nil {
+		return &selectedObject.Translation
+	}
+
+	if selectedEnemy != nil {
+		return &selectedEnemy.Translation
+	}
+
+	return nil
+}
+
+func Select(object *Object, enemy *Enemy) {
+	selectedObject = object
+	selectedEnemy = enemy
+}
+
+// NOTE: file is OMS entry in room DAT that new object is added to, name is model name (max 8 char)
+func AddObject(file *OmsFile, name string, translation [3]float32) error {
+	if file == nil {
+		return fmt.Errorf("Room has no OMS entry")
+	}
+
+	if name == "" {
+		return fmt.Errorf("Object name is empty")
+	}
+
+	if len(name) > oms.NameLength {
+		return fmt.Errorf("Object name %s longer than %d char", name, oms.NameLength)
+	}
+
+	entry := oms.NewEntry(name, translation)
+	file.Oms.AddEntry(entry)
+
+	object := &Object{Entry: entry, Owner: file, RenderLabel: false}
+	omsEntries = append(omsEntries, object)
+	Select(object, nil)
+	modified = true
+
+	return nil
+}
+
+// NOTE: file is EMS entry in room DAT that new enemy is added to
+func AddEnemy(file *EmsFile, translation [3]float32) error {
+	if file == nil {
+		return fmt.Errorf("Room has no EMS entry")
+	}
+
+	entry := ems.NewEntry(translation)
+	file.Ems.AddEntry(entry)
+
+	enemy := &Enemy{Entry: entry, Owner: file}
+	emsEntries = append(emsEntries, enemy)
+	Select(nil, enemy)
+	modified = true
+
+	return nil
+}
+
+func DeleteSelected() {
+	if selectedObject != nil {
+		owner := selectedObject.Owner.Oms
+		owner.RemoveEntry(slices.Index(owner.Entries, selectedObject.Entry))
+		omsEntries = slices.DeleteFunc(omsEntries, func(object *Object) bool { return object == selectedObject })
+		modified = true
+	}
+
+	if selectedEnemy != nil {
+		owner := selectedEnemy.Owner.Ems
+		owner.RemoveEntry(slices.Index(owner.Entries, selectedEnemy.Entry))
+		emsEntries = slices.DeleteFunc(emsEntries, func(enemy *Enemy) bool { return enemy == selectedEnemy })
+		modified = true
+	}
+
+	Select(nil, nil)
+}
+
+// NOTE: rebuild room dat with modified OMS and EMS entry, then reload room because every entry offset may change
+func Save() error {
+	if datPath == "" {
+		return fmt.Errorf("Room not loaded")
+	}
+
+	if _, _, found := utils.SplitIsoPath(datPath); found {
+		return fmt.Errorf("Room inside ISO is read only")
+	}
+
+	file, err := os.Open(datPath)
+	if err != nil {
+		return err
+	}
+
+	room := dat.New()
+	if err := dat.FromStream(room, file); err != nil {
+		file.Close()
+		return err
+	}
+
+	err = room.Load(file)
+	file.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, f := range omsFiles {
+		memory := buffer.NewMemory(nil)
+		if err := oms.ToStream(f.Oms, memory); err != nil {
+			return err
+		}
+
+		if err := room.SetEntryData(f.Index, memory.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range emsFiles {
+		memory := buffer.NewMemory(nil)
+		if err := ems.ToStream(f.Ems, memory); err != nil {
+			return err
+		}
+
+		if err := room.SetEntryData(f.Index, memory.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	memory := buffer.NewMemory(nil)
+	if err := room.PackToStream(context.Background(), memory, noop, noop); err != nil {
+		return err
+	}
+
+	if err := writeRoom(memory.Bytes()); err != nil {
+		return err
+	}
+
+	return drop(datPath)
+}
+
+// NOTE: room is written to temporary file next to it then renamed, so failed save never leave half written room.
+// Room before the first save is kept as .bak
+func writeRoom(data []byte) error {
+	backup := datPath + ".bak"
+	if _, err := os.Stat(backup); errors.Is(err, os.ErrNotExist) {
+		original, err := os.ReadFile(datPath)
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(backup, original, 0644); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	tempFile, err := os.CreateTemp(filepath.Dir(datPath), filepath.Base(datPath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return err
+	}
+
+	if err := tempFile.Chmod(0644); err != nil {
+		tempFile.Close()
+		return err
+	}
+
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFile.Name(), datPath)
+}
+
+// NOTE: pick hovered object or enemy on left click, ignored when mouse is over imgui window or gizmo
+func Pick(hoverEnemy *Enemy) {
+	if !rl.IsMouseButtonPressed(rl.MouseLeftButton) {
+		return
+	}
+
+	if imgui.CurrentIO().WantCaptureMouse() || imguizmo.IsOver() || imguizmo.IsUsing() {
+		return
+	}
+
+	if showOms {
+		for _, object := range omsEntries {
+			if object.RenderLabel {
+				Select(object, nil)
+				return
+			}
+		}
+	}
+
+	if showEms && hoverEnemy != nil {
+		Select(nil, hoverEnemy)
+		return
+	}
+
+	Select(nil, nil)
+}
+
+func Gizmo() {
+	translation := selectedTranslation()
+	if translation == nil {
+		return
+	}
+
+	imguizmo.BeginFrame()
+	imguizmo.SetOrthographic(false)
+	imguizmo.SetDrawlistV(imgui.BackgroundDrawList())
+	imguizmo.SetRect(0, 0, width, height)
+
+	view := rl.MatrixToFloatV(rl.GetCameraMatrix(camera))
+	projection := rl.MatrixToFloatV(rl.MatrixPerspective(camera.Fovy, width/height, CameraNear, CameraFar))
+	matrix := rl.MatrixToFloatV(rl.MatrixTranslate(translation[0], translation[1], translation[2]))
+
+	if imguizmo.Manipulate(&view[0], &projection[0], imguizmo.TRANSLATE, imguizmo.WORLD, &matrix[0]) {
+		translation[0] = matrix[12]
+		translation[1] = matrix[13]
+		translation[2] = matrix[14]
+		modified = true
+	}
+}
+
+func drawSelection() {
+	translation := selectedTranslation()
+	if translation == nil {
+		return
+	}
+
+	rl.DrawCubeWires(rl.NewVector3(translation[0], translation[1]+0.2, translation[2]), 0.44, 0.44, 0.44, rl.Yellow)
+}
+
+func uint16Input(label string, v *uint16) {
+	n := int32(*v)
+	if imgui.InputInt(label, &n) {
+		*v = uint16(n)
+		modified = true
+	}
+}
+
+// NOTE: pick OMS or EMS entry that new object or enemy is added to, indices is DAT entry index of every file
+func fileCombo(id string, kind string, indices []int, current *int) {
+	imgui.PushIDStr(id)
+	preview := fmt.Sprintf("No %s", kind)
+	if *current < len(indices) {
+		preview = fmt.Sprintf("%s %d", kind, indices[*current])
+	}
+
+	if imgui.BeginComboV("", preview, imgui.ComboFlagsWidthFitPreview) {
+		for i, index := range indices {
+			selected := i == *current
+			if imgui.SelectableBoolV(fmt.Sprintf("%s %d", kind, index), selected, imgui.SelectableFlagsNone, imgui.NewVec2(0, 0)) {
+				*current = i
+			}
+
+			if selected {
+				imgui.SetItemDefaultFocus()
+			}
+		}
+
+		imgui.EndCombo()
+	}
+	imgui.PopID()
+}
+
+func Editor() {
+	imgui.SetNextWindowPosV(imgui.NewVec2(width-12, height-12), imgui.CondFirstUseEver, imgui.NewVec2(1, 1))
+	imgui.SetNextWindowSizeV(imgui.NewVec2(260, 260), imgui.CondFirstUseEver)
+	imgui.BeginV("Editor", nil, imgui.WindowFlagsNone)
+
+	target := [3]float32{camera.Target.X, camera.Target.Y, camera.Target.Z}
+
+	var omsFile *OmsFile
+	if addOmsFile < len(omsFiles) {
+		omsFile = omsFiles[addOmsFile]
+	}
+
+	var emsFile *EmsFile
+	if addEmsFile < len(emsFiles) {
+		emsFile = emsFiles[addEmsFile]
+	}
+
+	omsIndices := []int{}
+	for _, f := range omsFiles {
+		omsIndices = append(omsIndices, f.Index)
+	}
+
+	emsIndices := []int{}
+	for _, f := range emsFiles {
+		emsIndices = append(emsIndices, f.Index)
+	}
+
+	imgui.BeginDisabledV(omsFile == nil)
+	if imgui.Button("Add Object") {
+		if err := AddObject(omsFile, addObjectName, target); err != nil {
+			rlig.ShowError(err)
+		}
+	}
+	imgui.EndDisabled()
+
+	imgui.SameLine()
+
+	imgui.BeginDisabledV(emsFile == nil)
+	if imgui.Button("Add Enemy") {
+		if err := AddEnemy(emsFile, target); err != nil {
+			rlig.ShowError(err)
+		}
+	}
+	imgui.EndDisabled()
+
+	imgui.SameLine()
+
+	imgui.BeginDisabledV(selectedTranslation() == nil)
+	if imgui.Button("Delete") || (selectedTranslation() != nil && rl.IsKeyPressed(rl.KeyDelete)) {
+		DeleteSelected()
+	}
+	imgui.EndDisabled()
+
+	imgui.BeginDisabledV(omsFile == nil)
+	fileCombo("AddObjectFile", "OMS", omsIndices, &addOmsFile)
+	imgui.SameLine()
+	imgui.SetNextItemWidth(-1)
+	imgui.InputTextWithHint("##AddObjectName", "Object name", &addObjectName, imgui.InputTextFlagsNone, nil)
+	imgui.EndDisabled()
+
+	imgui.BeginDisabledV(emsFile == nil)
+	fileCombo("AddEnemyFile", "EMS", emsIndices, &addEmsFile)
+	imgui.EndDisabled()
+
+	imgui.Separator()
+
+	if selectedObject != nil {
+		imgui.SeparatorText(utils.FilterUnprintableString(selectedObject.Name))
+		if imgui.DragFloat3V("Translation", &selectedObject.Translation, 0.01, 0, 0, "%.3f", imgui.SliderFlagsNone) {
+			modified = true
+		}
+		if imgui.DragFloat3V("Rotation", &selectedObject.Rotation, 0.01, 0, 0, "%.3f", imgui.SliderFlagsNone) {
+			modified = true
+		}
+		if imgui.DragFloat3V("Scale", &selectedObject.Scale, 0.01, 0, 0, "%.3f", imgui.SliderFlagsNone) {
+			modified = true
+		}
+	}
+
+	if selectedEnemy != nil {
+		imgui.SeparatorText(fmt.Sprintf("em%02x", selectedEnemy.Id))
+		if imgui.DragFloat3V("Translation", &selectedEnemy.Translation, 0.01, 0, 0, "%.3f", imgui.SliderFlagsNone) {
+			modified = true
+		}
+		if imgui.DragFloat3V("Rotation", &selectedEnemy.Rotation, 0.01, 0, 0, "%.3f", imgui.SliderFlagsNone) {
+			modified = true
+		}
+		uint16Input("Id", &selectedEnemy.Id)
+		uint16Input("Flag", &selectedEnemy.Flag)
+		uint16Input("Trigger", &selectedEnemy.Trigger)
+		uint16Input("Wave", &selectedEnemy.Wave)
+	}
+
+	if selectedTranslation() == nil {
+		imgui.Text("Click object or enemy to select")
+	}
+
+	imgui.Separator()
+
+	imgui.BeginDisabledV(!modified)
+	if imgui.Button("Save") {
+		if err := Save(); err != nil {
+			rlig.ShowError(err)
+		}
+	}
+	imgui.EndDisabled()
+
+	imgui.End()
+}
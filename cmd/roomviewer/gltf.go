@@ -1,14 +1,13 @@
 package main
 
 import (
-	"bytes"
 	"fmt"
-	"image/png"
 	"os"
 	"path/filepath"
 
 	"github.com/anasrar/chihuahua/pkg/dat"
 	"github.com/anasrar/chihuahua/pkg/scr"
+	"github.com/anasrar/chihuahua/pkg/texture"
 	"github.com/anasrar/chihuahua/pkg/tim3"
 	"github.com/anasrar/chihuahua/pkg/tm3"
 	"github.com/anasrar/chihuahua/pkg/utils"
@@ -17,7 +16,8 @@ import (
 	"github.com/qmuntal/gltf/modeler"
 )
 
-func ConvertToGlft() error {
+// NOTE: option Dir is set to glTF directory
+func ConvertToGlft(option texture.GltfOption) error {
 	if scp == nil {
 		return fmt.Errorf("SCP not found")
 	}
@@ -31,6 +31,17 @@ func ConvertToGlft() error {
 		return err
 	}
 
+	output := filepath.Join(
+		utils.ParentDirectory(datPath),
+		fmt.Sprintf("GLTF_%s", utils.Basename(datPath)),
+	)
+
+	if err := os.MkdirAll(output, os.ModePerm); err != nil {
+		return err
+	}
+
+	option.Dir = output
+
 	doc := gltf.NewDocument()
 	materials := map[uint16]int{}
 	zero := float64(0)
@@ -52,16 +63,16 @@ func ConvertToGlft() error {
 					return err
 				}
 
-				var buf bytes.Buffer
 				picture := tim.Pictures[0]
-				if err := png.Encode(&buf, tim3.PictureToImage(picture)); err != nil {
+				nrgba, err := tim3.PictureToImage(picture)
+				if err != nil {
 					return err
 				}
 
-				index, _ := modeler.WriteImage(doc, fmt.Sprintf("%s_%03d", entry.Name, i), "image/png", &buf)
-				doc.Textures = append(doc.Textures, &gltf.Texture{
-					Source: gltf.Index(index),
-				})
+				index, err := texture.WriteGltfTexture(doc, fmt.Sprintf("%s_%03d", utils.FilterUnprintableString(entry.Name), i), nrgba, &option)
+				if err != nil {
+					return err
+				}
 
 				k := len(doc.Materials)
 				materials[uint16(i)] = k
@@ -157,14 +168,7 @@ func ConvertToGlft() error {
 		}
 	}
 
-	output := filepath.Join(
-		utils.ParentDirectory(datPath),
-		fmt.Sprintf("GLTF_%s", utils.Basename(datPath)),
-	)
-
-	if err := os.MkdirAll(output, os.ModePerm); err != nil {
-		return err
-	}
+	addMarkers(doc)
 
 	if err := gltf.Save(
 		doc,
@@ -175,3 +179,65 @@ func ConvertToGlft() error {
 
 	return nil
 }
+
+func markerNode(name string, translation, rotation, scale [3]float32, extras map[string]any) *gltf.Node {
+	orientation := rl.QuaternionFromEuler(rotation[0], rotation[1], rotation[2])
+
+	return &gltf.Node{
+		Name:        name,
+		Translation: [3]float64{float64(translation[0]), float64(translation[1]), float64(translation[2])},
+		Rotation:    [4]float64{float64(orientation.X), float64(orientation.Y), float64(orientation.Z), float64(orientation.W)},
+		Scale:       [3]float64{float64(scale[0]), float64(scale[1]), float64(scale[2])},
+		Extras:      extras,
+	}
+}
+
+// NOTE: OMS and EMS entry as empty node under "objects" and "spawns", every decoded field is kept in extras
+func addMarkers(doc *gltf.Document) {
+	objects := len(doc.Nodes)
+	doc.Nodes = append(doc.Nodes, &gltf.Node{Name: "objects"})
+	doc.Scenes[0].Nodes = append(doc.Scenes[0].Nodes, objects)
+
+	for i, obj := range omsEntries {
+		name := utils.FilterUnprintableString(obj.Name)
+		doc.Nodes[objects].Children = append(doc.Nodes[objects].Children, len(doc.Nodes))
+		doc.Nodes = append(doc.Nodes, markerNode(
+			fmt.Sprintf("%s_%03d", name, i),
+			obj.Translation,
+			obj.Rotation,
+			obj.Scale,
+			map[string]any{
+				"name":        name,
+				"fx":          utils.FilterUnprintableString(obj.Fx),
+				"translation": obj.Translation,
+				"rotation":    obj.Rotation,
+				"scale":       obj.Scale,
+				"flag":        obj.Flag,
+				"unknown":     obj.Unknown,
+			},
+		))
+	}
+
+	spawns := len(doc.Nodes)
+	doc.Nodes = append(doc.Nodes, &gltf.Node{Name: "spawns"})
+	doc.Scenes[0].Nodes = append(doc.Scenes[0].Nodes, spawns)
+
+	for i, enemy := range emsEntries {
+		doc.Nodes[spawns].Children = append(doc.Nodes[spawns].Children, len(doc.Nodes))
+		doc.Nodes = append(doc.Nodes, markerNode(
+			fmt.Sprintf("em%02x_%03d", enemy.Id, i),
+			enemy.Translation,
+			enemy.Rotation,
+			[3]float32{1, 1, 1},
+			map[string]any{
+				"id":          enemy.Id,
+				"flag":        enemy.Flag,
+				"translation": enemy.Translation,
+				"rotation":    enemy.Rotation,
+				"trigger":     enemy.Trigger,
+				"wave":        enemy.Wave,
+				"unknown":     enemy.Unknown,
+			},
+		))
+	}
+}
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
+	"math"
 
 	"github.com/AllenDang/cimgui-go/imgui"
+	"github.com/anasrar/chihuahua/pkg/akg"
 	"github.com/anasrar/chihuahua/pkg/dat"
 	"github.com/anasrar/chihuahua/pkg/ems"
 	"github.com/anasrar/chihuahua/pkg/oms"
@@ -24,19 +27,33 @@ func drop(filePath string) error {
 	}
 
 	var datScp *dat.Entry
-	datOms := []*dat.Entry{}
-	datEms := []*dat.Entry{}
+	datOms := []int{}
+	datEms := []int{}
+	datAkg := []*dat.Entry{}
 
-	for _, entry := range dat0.Entries {
+	for i, entry := range dat0.Entries {
 		t := utils.FilterUnprintableString(entry.Type)
 
 		switch t {
 		case "SCP":
 			datScp = entry
 		case "OMS":
-			datOms = append(datOms, entry)
+			datOms = append(datOms, i)
 		case "EMS":
-			datEms = append(datEms, entry)
+			datEms = append(datEms, i)
+		case "AKG":
+			datAkg = append(datAkg, entry)
+		case "AKT":
+			akt := dat.New()
+			if err := dat.FromPathWithOffsetSize(akt, filePath, entry.Offset, entry.Size); err != nil {
+				return err
+			}
+
+			for _, aktEntry := range akt.Entries {
+				if utils.FilterUnprintableString(aktEntry.Type) == "AKG" {
+					datAkg = append(datAkg, aktEntry)
+				}
+			}
 		}
 	}
 
@@ -72,32 +89,62 @@ func drop(filePath string) error {
 		}
 	}
 
+	omsFiles = []*OmsFile{}
 	omsEntries = []*Object{}
+	addOmsFile = 0
 
-	for _, entry := range datOms {
+	for _, index := range datOms {
 		om := oms.New()
-		if err := oms.FromPathWithOffset(om, filePath, entry.Offset); err != nil {
+		if err := oms.FromPathWithOffset(om, filePath, dat0.Entries[index].Offset); err != nil {
 			return err
 		}
 
+		file := &OmsFile{Index: index, Oms: om}
+		omsFiles = append(omsFiles, file)
+
 		for _, omEntry := range om.Entries {
 			omsEntries = append(omsEntries, &Object{
-				RenderLabel: false,
 				Entry:       omEntry,
+				Owner:       file,
+				RenderLabel: false,
 			})
 		}
-
 	}
 
-	emsEntries = []*ems.Entry{}
+	emsFiles = []*EmsFile{}
+	emsEntries = []*Enemy{}
+	addEmsFile = 0
 
-	for _, entry := range datEms {
+	for _, index := range datEms {
 		em := ems.New()
-		if err := ems.FromPathWithOffset(em, filePath, entry.Offset); err != nil {
+		if err := ems.FromPathWithOffset(em, filePath, dat0.Entries[index].Offset); err != nil {
 			return err
 		}
 
-		emsEntries = append(emsEntries, em.Entries...)
+		file := &EmsFile{Index: index, Ems: em}
+		emsFiles = append(emsFiles, file)
+
+		for _, emEntry := range em.Entries {
+			emsEntries = append(emsEntries, &Enemy{
+				Entry: emEntry,
+				Owner: file,
+			})
+		}
+	}
+
+	selectedObject = nil
+	selectedEnemy = nil
+	modified = false
+
+	akgEntries = []*akg.Akg{}
+
+	for _, entry := range datAkg {
+		ak := akg.New()
+		if err := akg.FromPathWithOffsetSize(ak, filePath, entry.Offset, entry.Size); err != nil {
+			return err
+		}
+
+		akgEntries = append(akgEntries, ak)
 	}
 
 	datPath = filePath
@@ -105,7 +152,13 @@ func drop(filePath string) error {
 	return nil
 }
 
+func init() {
+	flag.StringVar(&openPath, "path", "", "Path to rXXX.dat file to open on start, file inside ISO use game.iso:/DAT/xxx.dat")
+}
+
 func main() {
+	flag.Parse()
+
 	rl.InitWindow(int32(width), int32(height), "Room Viewer")
 	defer rl.CloseWindow()
 	rl.SetTargetFPS(30)
@@ -123,6 +176,12 @@ func main() {
 	rl.EnableColorBlend()
 	rl.EnableDepthMask()
 
+	if openPath != "" {
+		if err := drop(openPath); err != nil {
+			rlig.ShowError(err)
+		}
+	}
+
 	for !rl.WindowShouldClose() {
 		rlig.Update()
 
@@ -136,7 +195,7 @@ func main() {
 			defer rl.UnloadDroppedFiles()
 
 			if err := drop(filePath); err != nil {
-				log.Println(err)
+				rlig.ShowError(err)
 			}
 		}
 
@@ -212,11 +271,13 @@ func main() {
 		imgui.SetNextWindowPosV(imgui.NewVec2(12, height-12), imgui.CondAlways, imgui.NewVec2(0, 1))
 		imgui.BeginV("ToGltf", nil, imgui.WindowFlagsNoResize|imgui.WindowFlagsNoMove|imgui.WindowFlagsNoTitleBar)
 		imgui.BeginDisabledV(scp == nil)
+		rlig.GltfOption(gltfOption)
 		if imgui.Button("Convert To GLTF") {
+			option := *gltfOption
 			go func() {
 				log.Println("Convert to GLTF")
-				if err := ConvertToGlft(); err != nil {
-					log.Println(err)
+				if err := ConvertToGlft(option); err != nil {
+					rlig.ShowError(err)
 				} else {
 					log.Println("Convert done")
 				}
@@ -240,6 +301,7 @@ func main() {
 		imgui.BeginV("Inspector", nil, imgui.WindowFlagsNone)
 		imgui.Checkbox("Show OMS", &showOms)
 		imgui.Checkbox("Show EMS", &showEms)
+		imgui.Checkbox("Show AKG", &showAkg)
 		imgui.Separator()
 		imgui.BeginChildStrV("MdbRegion", imgui.NewVec2(0, 0), imgui.ChildFlagsNavFlattened, imgui.WindowFlagsHorizontalScrollbar)
 		for _, model := range models {
@@ -281,16 +343,45 @@ func main() {
 			}
 		}
 
+		var hoverEnemy *Enemy
 		if showEms {
+			ray := rl.GetMouseRay(rl.GetMousePosition(), camera)
 			for _, enemy := range emsEntries {
-				rl.DrawCube(rl.NewVector3(enemy.Translation[0], enemy.Translation[1]+0.2, enemy.Translation[2]), 0.4, 0.4, 0.4, rl.Red)
+				if rl.GetRayCollisionBox(
+					ray,
+					rl.NewBoundingBox(
+						rl.NewVector3(enemy.Translation[0]-0.2, enemy.Translation[1], enemy.Translation[2]-0.2),
+						rl.NewVector3(enemy.Translation[0]+0.2, enemy.Translation[1]+0.4, enemy.Translation[2]+0.2),
+					),
+				).Hit {
+					hoverEnemy = enemy
+				}
+
+				center := rl.NewVector3(enemy.Translation[0], enemy.Translation[1]+0.2, enemy.Translation[2])
+				rl.DrawCube(center, 0.4, 0.4, 0.4, rl.Red)
+
+				// NOTE: facing direction from yaw
+				yaw := float64(enemy.Rotation[1])
+				rl.DrawLine3D(center, rl.NewVector3(center.X+float32(math.Sin(yaw))*0.6, center.Y, center.Z+float32(math.Cos(yaw))*0.6), rl.Yellow)
+			}
+		}
+
+		if showAkg {
+			for _, ak := range akgEntries {
+				for _, position := range ak.Positions() {
+					rl.DrawCubeWires(rl.NewVector3(position[0], position[1]+0.1, position[2]), 0.2, 0.2, 0.2, rl.Green)
+				}
 			}
 		}
 
+		drawSelection()
+
 		rl.DrawGrid(4, 0.5)
 
 		rl.EndMode3D()
 
+		Pick(hoverEnemy)
+
 		if showOms {
 			for _, obj := range omsEntries {
 				if obj.RenderLabel {
@@ -298,11 +389,30 @@ func main() {
 					imgui.SetNextWindowPosV(imgui.NewVec2(position.X, position.Y), imgui.CondAlways, imgui.NewVec2(0, 1))
 					imgui.BeginV("Information", nil, imgui.WindowFlagsNoResize|imgui.WindowFlagsAlwaysAutoResize|imgui.WindowFlagsNoMove|imgui.WindowFlagsNoTitleBar|imgui.WindowFlagsNoFocusOnAppearing)
 					imgui.Text(obj.Name)
+					if fx := utils.FilterUnprintableString(obj.Fx); fx != "" {
+						imgui.Text(fmt.Sprintf("Fx: %s", fx))
+					}
+					imgui.Text(fmt.Sprintf("Flag: 0x%08X", obj.Flag))
 					imgui.End()
 				}
 			}
 		}
 
+		if showEms && hoverEnemy != nil {
+			position := imgui.MousePos()
+			imgui.SetNextWindowPosV(imgui.NewVec2(position.X, position.Y), imgui.CondAlways, imgui.NewVec2(0, 1))
+			imgui.BeginV("Enemy", nil, imgui.WindowFlagsNoResize|imgui.WindowFlagsAlwaysAutoResize|imgui.WindowFlagsNoMove|imgui.WindowFlagsNoTitleBar|imgui.WindowFlagsNoFocusOnAppearing)
+			imgui.Text(fmt.Sprintf("em%02x", hoverEnemy.Id))
+			imgui.Text(fmt.Sprintf("Flag: 0x%04X", hoverEnemy.Flag))
+			imgui.Text(fmt.Sprintf("Trigger: %d", hoverEnemy.Trigger))
+			imgui.Text(fmt.Sprintf("Wave: %d", hoverEnemy.Wave))
+			imgui.End()
+		}
+
+		Editor()
+		Gizmo()
+
+		rlig.ErrorPopup()
 		rlig.Render()
 		rl.EndDrawing()
 	}
@@ -1,8 +1,28 @@
 package main
 
-import "github.com/anasrar/chihuahua/pkg/oms"
+import (
+	"github.com/anasrar/chihuahua/pkg/ems"
+	"github.com/anasrar/chihuahua/pkg/oms"
+)
 
 type Object struct {
 	*oms.Entry
+	Owner       *OmsFile
 	RenderLabel bool
 }
+
+type Enemy struct {
+	*ems.Entry
+	Owner *EmsFile
+}
+
+// NOTE: index is entry index in room dat, used to replace entry data on save
+type OmsFile struct {
+	Index int
+	Oms   *oms.Oms
+}
+
+type EmsFile struct {
+	Index int
+	Ems   *ems.Ems
+}
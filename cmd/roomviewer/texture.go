@@ -42,7 +42,12 @@ func LoadTextures(datPath string, offset uint32, size uint32) error {
 		}
 
 		buf := bytes.NewBuffer([]byte{})
-		if err := png.Encode(buf, tim3.PictureToImage(tim.Pictures[0])); err != nil {
+		nrgba, err := tim3.PictureToImage(tim.Pictures[0])
+		if err != nil {
+			return err
+		}
+
+		if err := png.Encode(buf, nrgba); err != nil {
 			return err
 		}
 
@@ -1,12 +1,14 @@
 package main
 
 import (
+	"github.com/anasrar/chihuahua/pkg/akg"
 	"github.com/anasrar/chihuahua/pkg/dat"
-	"github.com/anasrar/chihuahua/pkg/ems"
+	"github.com/anasrar/chihuahua/pkg/texture"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
 var datPath = ""
+var openPath = ""
 var scp *dat.Entry
 
 var (
@@ -25,13 +27,26 @@ var camera = rl.NewCamera3D(
 var textureDefault rl.Texture2D
 var textures = map[int]*Texture{}
 var textureIndices = []int{}
+var gltfOption = texture.NewGltfOption("")
 
 var models = []*Model{}
 
 var showEms = true
-var emsEntries = []*ems.Entry{}
+var emsFiles = []*EmsFile{}
+var emsEntries = []*Enemy{}
+var addEmsFile = 0
 
 var showOms = true
+var omsFiles = []*OmsFile{}
 var omsEntries = []*Object{}
+var addOmsFile = 0
+var addObjectName = "object"
+
+var showAkg = true
+var akgEntries = []*akg.Akg{}
+
+var selectedObject *Object
+var selectedEnemy *Enemy
+var modified = false
 
 var background = [3]float32{0.071, 0.071, 0.071}
@@ -2,13 +2,14 @@ package main
 
 import (
 	"bytes"
+	"flag"
 	"fmt"
 	"image/png"
 	"log"
-	"os"
 
 	"github.com/AllenDang/cimgui-go/imgui"
 	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/iso9660"
 	rlig "github.com/anasrar/chihuahua/pkg/raylib_imgui"
 	"github.com/anasrar/chihuahua/pkg/scr"
 	"github.com/anasrar/chihuahua/pkg/tim3"
@@ -17,8 +18,23 @@ import (
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
+// NOTE: model is still shown when material is not covered, uncovered material is drawn without texture
+func resolveMaterials() {
+	if scrFile == nil {
+		return
+	}
+
+	resolved, err := scrFile.ResolveMaterials(textureTotal, int(textureBase))
+	bindings = resolved
+	bindTextures()
+
+	if err != nil {
+		rlig.ShowError(err)
+	}
+}
+
 func drop(filePath string) error {
-	file, err := os.Open(filePath)
+	file, err := iso9660.Open(filePath)
 	if err != nil {
 		return err
 	}
@@ -78,18 +94,14 @@ func drop(filePath string) error {
 
 				model := rl.LoadModelFromMesh(mesh)
 
-				if texture, found := textures[int(vb.Material)]; found {
-					rl.SetMaterialTexture(model.Materials, rl.MapDiffuse, texture.Texture)
-				} else {
-					rl.SetMaterialTexture(model.Materials, rl.MapDiffuse, textureDefault)
-				}
+				rl.SetMaterialTexture(model.Materials, rl.MapDiffuse, textureDefault)
 
 				models = append(
 					models,
 					NewModel(
 						name,
 						&model,
-						int(vb.Material),
+						vb.Material,
 						rl.NewVector3(node.Translation[0], node.Translation[1], node.Translation[2]),
 						rl.NewVector3(node.Rotation[0], node.Rotation[1], node.Rotation[2]),
 						rl.NewVector3(node.Scale[0], node.Scale[1], node.Scale[2]),
@@ -113,8 +125,10 @@ func drop(filePath string) error {
 			)
 		}
 
+		scrFile = &s
+		resolveMaterials()
+
 		scrPath = filePath
-		textureShift = 0
 	case tm3.Signature:
 		tm := tm3.New()
 		if err := tm3.FromPath(tm, filePath); err != nil {
@@ -136,7 +150,12 @@ func drop(filePath string) error {
 			}
 
 			buf := bytes.NewBuffer([]byte{})
-			if err := png.Encode(buf, tim3.PictureToImage(tim.Pictures[0])); err != nil {
+			nrgba, err := tim3.PictureToImage(tim.Pictures[0])
+			if err != nil {
+				return err
+			}
+
+			if err := png.Encode(buf, nrgba); err != nil {
 				return err
 			}
 
@@ -149,16 +168,9 @@ func drop(filePath string) error {
 			textureIndices = append(textureIndices, i)
 		}
 
-		for _, model := range models {
-			if texture, found := textures[model.Texture]; found {
-				rl.SetMaterialTexture(model.Model.Materials, rl.MapDiffuse, texture.Texture)
-			} else {
-				rl.SetMaterialTexture(model.Model.Materials, rl.MapDiffuse, textureDefault)
-			}
-		}
+		resolveMaterials()
 
 		tm3Path = filePath
-		textureShift = 0
 	default:
 		return fmt.Errorf("Format not supported")
 	}
@@ -166,7 +178,13 @@ func drop(filePath string) error {
 	return nil
 }
 
+func init() {
+	flag.StringVar(&openPath, "path", "", "Path to SCR, MD, or TM3 file to open on start, file inside ISO use game.iso:/DIR/FILE")
+}
+
 func main() {
+	flag.Parse()
+
 	rl.InitWindow(int32(width), int32(height), "SCR Viewer")
 	defer rl.CloseWindow()
 	rl.SetTargetFPS(30)
@@ -187,6 +205,12 @@ func main() {
 	boneRender = rl.LoadRenderTexture(int32(width), int32(height))
 	defer rl.UnloadRenderTexture(boneRender)
 
+	if openPath != "" {
+		if err := drop(openPath); err != nil {
+			rlig.ShowError(err)
+		}
+	}
+
 	for !rl.WindowShouldClose() {
 		rlig.Update()
 
@@ -203,7 +227,7 @@ func main() {
 			defer rl.UnloadDroppedFiles()
 
 			if err := drop(filePath); err != nil {
-				log.Println(err)
+				rlig.ShowError(err)
 			}
 		}
 
@@ -279,11 +303,14 @@ func main() {
 		imgui.SetNextWindowPosV(imgui.NewVec2(12, height-12), imgui.CondAlways, imgui.NewVec2(0, 1))
 		imgui.BeginV("ToGltf", nil, imgui.WindowFlagsNoResize|imgui.WindowFlagsNoMove|imgui.WindowFlagsNoTitleBar)
 		imgui.BeginDisabledV(scrPath == "")
+		rlig.GltfOption(gltfOption)
 		if imgui.Button("Convert To GLTF") {
+			option := *gltfOption
+			resolved := bindings
 			go func() {
 				log.Println("Convert to GLTF")
-				if err := scr.ConvertToGlft(scrPath, tm3Path, textureShift); err != nil {
-					log.Println(err)
+				if err := scr.ConvertToGlft(scrPath, tm3Path, resolved, &option); err != nil {
+					rlig.ShowError(err)
 				} else {
 					log.Println("Convert done")
 				}
@@ -295,31 +322,13 @@ func main() {
 		imgui.SetNextWindowPosV(imgui.NewVec2(12, 12), imgui.CondFirstUseEver, imgui.NewVec2(0, 0))
 		imgui.SetNextWindowSizeV(imgui.NewVec2(200, 300), imgui.CondFirstUseEver)
 		imgui.BeginV("Inspector", nil, imgui.WindowFlagsNone)
-		imgui.BeginDisabledV(textureTotal == 0)
-		if imgui.Button("Shift -1") {
-			textureShift = (textureShift - 1) % textureTotal
-			for _, model := range models {
-				if texture, found := textures[model.Texture+textureShift]; found {
-					rl.SetMaterialTexture(model.Model.Materials, rl.MapDiffuse, texture.Texture)
-				} else {
-					rl.SetMaterialTexture(model.Model.Materials, rl.MapDiffuse, textureDefault)
-				}
-			}
-		}
-		imgui.SameLineV(0, 4)
-		if imgui.Button("Shift +1") {
-			textureShift = (textureShift + 1) % textureTotal
-			for _, model := range models {
-				if texture, found := textures[model.Texture+textureShift]; found {
-					rl.SetMaterialTexture(model.Model.Materials, rl.MapDiffuse, texture.Texture)
-				} else {
-					rl.SetMaterialTexture(model.Model.Materials, rl.MapDiffuse, textureDefault)
-				}
-			}
-		}
-		imgui.EndDisabled()
 		imgui.Checkbox("Show Bones", &showBones)
 		imgui.Checkbox("Apply SCR Transform", &applyScrTransform)
+		// NOTE: TM3 entry index is material minus texture base, see scr.TextureBase
+		if imgui.InputInt("Texture Base", &textureBase) {
+			textureBase = max(textureBase, 0)
+			resolveMaterials()
+		}
 		imgui.Separator()
 		imgui.BeginChildStrV("MdbRegion", imgui.NewVec2(0, 0), imgui.ChildFlagsNavFlattened, imgui.WindowFlagsHorizontalScrollbar)
 		for _, model := range models {
@@ -373,6 +382,7 @@ func main() {
 			rl.DrawTextureRec(boneRender.Texture, rl.NewRectangle(0, 0, width, -height), rl.Vector2Zero(), rl.White)
 		}
 
+		rlig.ErrorPopup()
 		rlig.Render()
 		rl.EndDrawing()
 	}
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"github.com/anasrar/chihuahua/pkg/scr"
+	"github.com/anasrar/chihuahua/pkg/texture"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
 var scrPath = ""
 var tm3Path = ""
+var openPath = ""
+var scrFile *scr.Scr
 
 var (
 	width  float32 = 800
@@ -24,7 +28,9 @@ var textureDefault rl.Texture2D
 var textures = map[int]*Texture{}
 var textureIndices = []int{}
 var textureTotal = 0
-var textureShift = 0
+var bindings = map[uint16]int{} // NOTE: material to TM3 entry index
+var textureBase = int32(0)      // NOTE: texture total of TM3 before this TM3 in the same DAT
+var gltfOption = texture.NewGltfOption("")
 
 var applyScrTransform = false
 var models = []*Model{}
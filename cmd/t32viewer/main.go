@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"flag"
 	"fmt"
 	"image"
 	"image/png"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/AllenDang/cimgui-go/imgui"
 	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/iso9660"
 	rlig "github.com/anasrar/chihuahua/pkg/raylib_imgui"
 	"github.com/anasrar/chihuahua/pkg/t32"
 	"github.com/anasrar/chihuahua/pkg/utils"
@@ -18,7 +20,7 @@ import (
 )
 
 func drop(filePath string) error {
-	file, err := os.Open(filePath)
+	file, err := iso9660.Open(filePath)
 	if err != nil {
 		return err
 	}
@@ -39,7 +41,12 @@ func drop(filePath string) error {
 	}
 
 	buf := bytes.NewBuffer([]byte{})
-	if err := png.Encode(buf, t32.T32ToImage(t)); err != nil {
+	paletted, err := t32.T32ToPaletted(t)
+	if err != nil {
+		return err
+	}
+
+	if err := png.Encode(buf, paletted); err != nil {
 		return err
 	}
 
@@ -115,10 +122,14 @@ func convert2png(stride, strideTotal int32) error {
 			return err
 		}
 	} else {
-		t32Img := t32.T32ToImage(entry.Picture)
+		t32Img, err := t32.T32ToPaletted(entry.Picture)
+		if err != nil {
+			return err
+		}
+
 		pngWidth := 128 * stride
 		pngHeight := (strideTotal / stride) * 64
-		pngImg := image.NewNRGBA(image.Rect(0, 0, int(pngWidth), int(pngHeight)))
+		pngImg := image.NewPaletted(image.Rect(0, 0, int(pngWidth), int(pngHeight)), t32Img.Palette)
 
 		for i := range strideTotal {
 			scrY := 64 * i
@@ -129,8 +140,8 @@ func convert2png(stride, strideTotal int32) error {
 				// NOTE: copy 128 x 64 pixels
 				for y := range 64 {
 					for x := range 128 {
-						c := t32Img.At(x, int(scrY)+y)
-						pngImg.Set(int(dstX)+x, int(dstY)+y, c)
+						index := t32Img.ColorIndexAt(x, int(scrY)+y)
+						pngImg.SetColorIndex(int(dstX)+x, int(dstY)+y, index)
 					}
 				}
 			}
@@ -185,7 +196,13 @@ func png2t32(filePath string) error {
 	return t32.ImagePalettedToFile(entry.Source, imgPaletted, t32File)
 }
 
+func init() {
+	flag.StringVar(&openPath, "path", "", "Path to T32 file to open on start, file inside ISO use game.iso:/DIR/FILE")
+}
+
 func main() {
+	flag.Parse()
+
 	rl.InitWindow(int32(width), int32(height), "T32 Viewer")
 	defer rl.CloseWindow()
 	rl.SetTargetFPS(30)
@@ -193,6 +210,14 @@ func main() {
 	rlig.Load()
 	defer rlig.Unload()
 
+	if openPath != "" {
+		if err := drop(openPath); err != nil {
+			rlig.ShowError(err)
+		} else {
+			t32Path = openPath
+		}
+	}
+
 	for !rl.WindowShouldClose() {
 		rlig.Update()
 
@@ -208,13 +233,13 @@ func main() {
 			if canConvertPng2t32 {
 				log.Println("Convert PNG to T32")
 				if err := png2t32(filePath); err != nil {
-					log.Println(err)
+					rlig.ShowError(err)
 				} else {
 					log.Println("Converted")
 				}
 			} else {
 				if err := drop(filePath); err != nil {
-					log.Println(err)
+					rlig.ShowError(err)
 				} else {
 					t32Path = filePath
 				}
@@ -312,7 +337,7 @@ func main() {
 			go func() {
 				log.Println("Convert to PNG")
 				if err := convert2png(stride, strideTotal); err != nil {
-					log.Println(err)
+					rlig.ShowError(err)
 				} else {
 					log.Println("Converted")
 				}
@@ -394,6 +419,7 @@ func main() {
 			}
 		}
 
+		rlig.ErrorPopup()
 		rlig.Render()
 		rl.EndDrawing()
 	}
@@ -5,6 +5,7 @@ import (
 )
 
 var t32Path = ""
+var openPath = ""
 
 var (
 	width  float32 = 600
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/anasrar/chihuahua/pkg/texture"
+	"github.com/anasrar/chihuahua/pkg/utils"
+)
+
+func init() {
+	flag.StringVar(&sourcePath, "path", "", "Path to directory, DAT, TM3, TIM2, TIM3, or T32 file")
+	flag.StringVar(&outputPath, "output", "", "Path to output directory, PNG_<name> next to path when empty")
+}
+
+func main() {
+	flag.Parse()
+
+	if sourcePath == "" {
+		flag.Usage()
+		return
+	}
+
+	if outputPath == "" {
+		outputPath = filepath.Join(
+			utils.ParentDirectory(sourcePath),
+			fmt.Sprintf("PNG_%s", utils.Basename(sourcePath)),
+		)
+	}
+
+	if err := os.MkdirAll(outputPath, os.ModePerm); err != nil {
+		log.Fatalln(err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	manifest, err := texture.Dump(
+		ctx,
+		sourcePath,
+		outputPath,
+		func(record *texture.Record) {
+			log.Printf("%s: done\n", record.Png)
+		},
+	)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	for _, skip := range manifest.Skips {
+		log.Printf("%s [%s] entry %d: skipped, %s\n", skip.Container, texture.NestedString(skip.Nested), skip.Entry, skip.Error)
+	}
+
+	if err := texture.ToPath(manifest, filepath.Join(outputPath, texture.ManifestName)); err != nil {
+		log.Fatalln(err)
+	}
+
+	log.Printf("%d PNG written, %d skipped\n", len(manifest.Records), len(manifest.Skips))
+}
@@ -0,0 +1,4 @@
+package main
+
+var sourcePath = ""
+var outputPath = ""
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/anasrar/chihuahua/pkg/texture"
+	"github.com/anasrar/chihuahua/pkg/utils"
+)
+
+func init() {
+	flag.StringVar(&manifestPath, "manifest", "", "Path to manifest.json from texdump, PNG is relative to manifest directory")
+	flag.StringVar(&outputPath, "output", "", "Path to output directory for rebuilt container")
+	flag.BoolVar(&inPlace, "in-place", false, "Overwrite original container instead of writing to output")
+}
+
+func main() {
+	flag.Parse()
+
+	if manifestPath == "" {
+		flag.Usage()
+		return
+	}
+
+	manifest := texture.New()
+	if err := texture.FromPath(manifest, manifestPath); err != nil {
+		log.Fatalln(err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	result, err := texture.Inject(
+		ctx,
+		manifest,
+		utils.ParentDirectory(manifestPath),
+		outputPath,
+		inPlace,
+		func(record *texture.Record) {
+			log.Printf("%s: injected to %s [%s]\n", record.Png, record.Container, texture.NestedString(record.Nested))
+		},
+	)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	for _, skip := range result.Skips {
+		log.Printf("%s [%s] entry %d: skipped, %s\n", skip.Container, texture.NestedString(skip.Nested), skip.Entry, skip.Error)
+	}
+
+	log.Printf("%d PNG injected, %d skipped\n", len(result.Records), len(result.Skips))
+}
@@ -0,0 +1,5 @@
+package main
+
+var manifestPath = ""
+var outputPath = ""
+var inPlace = false
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"image"
+
 	"github.com/anasrar/chihuahua/pkg/tim2"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
@@ -11,4 +13,7 @@ type Entry struct {
 	Png     []byte
 	Texture rl.Texture2D
 	Picture *tim2.Picture
+	Variant *tim2.Variant
+	// NOTE: TIM2 and TIM3 has different image data, used to decode stored mip level
+	ToPaletted func(picture *tim2.Picture) (*image.Paletted, error)
 }
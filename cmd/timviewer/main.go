@@ -2,7 +2,9 @@ package main
 
 import (
 	"bytes"
+	"flag"
 	"fmt"
+	"image"
 	"image/png"
 	"log"
 	"os"
@@ -10,6 +12,7 @@ import (
 
 	"github.com/AllenDang/cimgui-go/imgui"
 	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/iso9660"
 	rlig "github.com/anasrar/chihuahua/pkg/raylib_imgui"
 	"github.com/anasrar/chihuahua/pkg/tim2"
 	"github.com/anasrar/chihuahua/pkg/tim3"
@@ -19,7 +22,7 @@ import (
 )
 
 func drop(filePath string) error {
-	file, err := os.Open(filePath)
+	file, err := iso9660.Open(filePath)
 	if err != nil {
 		return err
 	}
@@ -30,6 +33,12 @@ func drop(filePath string) error {
 		return err
 	}
 
+	for _, entry := range entries {
+		rl.UnloadTexture(entry.Texture)
+	}
+	entries = []*Entry{}
+	currentEntry = -1
+
 	switch signature {
 	case tim3.Signature:
 		tim := tim3.New()
@@ -37,135 +46,112 @@ func drop(filePath string) error {
 			return err
 		}
 
-		for _, entry := range entries {
-			rl.UnloadTexture(entry.Texture)
-		}
-
-		buf := bytes.NewBuffer([]byte{})
-		if err := png.Encode(buf, tim3.PictureToImage(tim.Pictures[0])); err != nil {
+		result, err := loadEntries(filePath, utils.BasenameWithoutExt(filePath), tim.Pictures, tim3.PictureToPaletted)
+		if err != nil {
 			return err
 		}
-
-		img := rl.LoadImageFromMemory(".png", buf.Bytes(), int32(buf.Len()))
-		texture := rl.LoadTextureFromImage(img)
-		rl.UnloadImage(img)
-
-		entries = []*Entry{
-			{
-				Source:  filePath,
-				Name:    utils.BasenameWithoutExt(filePath),
-				Png:     buf.Bytes(),
-				Texture: texture,
-				Picture: tim.Pictures[0],
-			},
-		}
-
-		currentEntry = 0
-
-		matrix = rl.MatrixTranslate(
-			(width/2)-(float32(texture.Width)/2),
-			(height/2)-(float32(texture.Height)/2),
-			0,
-		)
-
-		mode = ModeSingle
-		canConvert = true
+		entries = result
 	case tim2.Signature:
 		tim := tim2.New()
 		if err := tim2.FromPath(tim, filePath); err != nil {
 			return err
 		}
 
-		for _, entry := range entries {
-			rl.UnloadTexture(entry.Texture)
-		}
-
-		buf := bytes.NewBuffer([]byte{})
-		if err := png.Encode(buf, tim2.PictureToImage(tim.Pictures[0])); err != nil {
+		result, err := loadEntries(filePath, utils.BasenameWithoutExt(filePath), tim.Pictures, tim2.PictureToPaletted)
+		if err != nil {
 			return err
 		}
-
-		img := rl.LoadImageFromMemory(".png", buf.Bytes(), int32(buf.Len()))
-		texture := rl.LoadTextureFromImage(img)
-		rl.UnloadImage(img)
-
-		entries = []*Entry{
-			{
-				Source:  filePath,
-				Name:    utils.BasenameWithoutExt(filePath),
-				Png:     buf.Bytes(),
-				Texture: texture,
-				Picture: tim.Pictures[0],
-			},
-		}
-
-		currentEntry = 0
-
-		matrix = rl.MatrixTranslate(
-			(width/2)-(float32(texture.Width)/2),
-			(height/2)-(float32(texture.Height)/2),
-			0,
-		)
-
-		mode = ModeSingle
-		canConvert = true
+		entries = result
 	case tm3.Signature:
 		tm := tm3.New()
 		if err := tm3.FromPath(tm, filePath); err != nil {
 			return err
 		}
 
-		for _, entry := range entries {
-			rl.UnloadTexture(entry.Texture)
-		}
-
-		entries = []*Entry{}
-
 		for i, entry := range tm.Entries {
 			tim := tim3.New()
 			if err := tim3.FromPathWithOffset(tim, filePath, entry.Offset); err != nil {
 				return err
 			}
 
-			buf := bytes.NewBuffer([]byte{})
-			if err := png.Encode(buf, tim3.PictureToImage(tim.Pictures[0])); err != nil {
+			result, err := loadEntries(filePath, fmt.Sprintf("%s_%03d", utils.FilterUnprintableString(entry.Name), i), tim.Pictures, tim3.PictureToPaletted)
+			if err != nil {
 				return err
 			}
-
-			img := rl.LoadImageFromMemory(".png", buf.Bytes(), int32(buf.Len()))
-			texture := rl.LoadTextureFromImage(img)
-			rl.UnloadImage(img)
-
-			entries = append(
-				entries,
-				&Entry{
-					Source:  filePath,
-					Name:    fmt.Sprintf("%s_%03d", utils.FilterUnprintableString(entry.Name), i),
-					Png:     buf.Bytes(),
-					Texture: texture,
-					Picture: tim.Pictures[0],
-				},
-			)
+			entries = append(entries, result...)
 		}
+	default:
+		return fmt.Errorf("Format not supported")
+	}
 
-		entry := entries[0]
-		currentEntry = 0
+	if len(entries) == 0 {
+		canConvert = false
+		return fmt.Errorf("Picture not found")
+	}
 
-		matrix = rl.MatrixTranslate(
-			(width/2)-(float32(entry.Texture.Width)/2),
-			(height/2)-(float32(entry.Texture.Height)/2),
-			0,
-		)
+	entry := entries[0]
+	currentEntry = 0
 
+	matrix = rl.MatrixTranslate(
+		(width/2)-(float32(entry.Texture.Width)/2),
+		(height/2)-(float32(entry.Texture.Height)/2),
+		0,
+	)
+
+	mode = ModeSingle
+	if len(entries) > 1 {
 		mode = ModeMultiple
-		canConvert = true
-	default:
-		return fmt.Errorf("Format not supported")
 	}
+	canConvert = true
 
 	return nil
 }
 
+// NOTE: one entry for every picture and every clut bank
+func loadEntries(
+	source string,
+	name string,
+	pictures []*tim2.Picture,
+	toImage func(picture *tim2.Picture) (*image.Paletted, error),
+) ([]*Entry, error) {
+	variants, err := tim2.Variants(pictures)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*Entry{}
+	for _, variant := range variants {
+		buf := bytes.NewBuffer([]byte{})
+		paletted, err := toImage(variant.Picture)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := png.Encode(buf, paletted); err != nil {
+			return nil, err
+		}
+
+		img := rl.LoadImageFromMemory(".png", buf.Bytes(), int32(buf.Len()))
+		texture := rl.LoadTextureFromImage(img)
+		rl.UnloadImage(img)
+
+		result = append(
+			result,
+			&Entry{
+				Source:     source,
+				Name:       name + variant.Suffix(),
+				Png:        buf.Bytes(),
+				Texture:    texture,
+				Picture:    variant.Picture,
+				Variant:    variant,
+				ToPaletted: toImage,
+			},
+		)
+	}
+
+	return result, nil
+}
+
 func zoom(wheel float32) {
 	isInsidePreview := rl.CheckCollisionPointRec(rl.GetMousePosition(), zoomDeadZone)
 
@@ -196,11 +182,8 @@ func zoom(wheel float32) {
 	)
 }
 
-func convert2png() {
-	if currentEntry == -1 {
-		return
-	}
-	entry := entries[currentEntry]
+func convert(index int) {
+	entry := entries[index]
 
 	pngFile, err := os.OpenFile(filepath.Join(utils.ParentDirectory(timPath), fmt.Sprintf("%s.png", entry.Name)), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	if err != nil {
@@ -213,10 +196,85 @@ func convert2png() {
 		log.Println(err)
 		return
 	}
-	log.Println("Converted")
+	log.Println("Converted", entry.Name)
+}
+
+func convert2png() {
+	if currentEntry == -1 {
+		return
+	}
+
+	convert(currentEntry)
+}
+
+func convertAll2png() {
+	for i := range entries {
+		convert(i)
+	}
+}
+
+// NOTE: uncompressed DDS or KTX2 with stored mip level, mip level is generated when picture has one level
+func convertTo(ext string, write func(levels []*image.NRGBA, output *os.File) error) {
+	if currentEntry == -1 {
+		return
+	}
+
+	entry := entries[currentEntry]
+
+	levels, err := tim2.PictureMipmaps(entry.Picture, true, func(picture *tim2.Picture) (*image.NRGBA, error) {
+		paletted, err := entry.ToPaletted(picture)
+		if err != nil {
+			return nil, err
+		}
+
+		// NOTE: palette color is NRGBA so conversion is exact
+		nrgba := image.NewNRGBA(paletted.Bounds())
+		for y := paletted.Bounds().Min.Y; y < paletted.Bounds().Max.Y; y++ {
+			for x := paletted.Bounds().Min.X; x < paletted.Bounds().Max.X; x++ {
+				nrgba.Set(x, y, paletted.At(x, y))
+			}
+		}
+
+		return nrgba, nil
+	})
+	if err != nil {
+		rlig.ShowError(err)
+		return
+	}
+
+	file, err := os.OpenFile(filepath.Join(utils.ParentDirectory(timPath), fmt.Sprintf("%s.%s", entry.Name, ext)), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		rlig.ShowError(err)
+		return
+	}
+	defer file.Close()
+
+	if err := write(levels, file); err != nil {
+		rlig.ShowError(err)
+		return
+	}
+	log.Println("Converted", entry.Name)
+}
+
+func convert2dds() {
+	convertTo("dds", func(levels []*image.NRGBA, output *os.File) error {
+		return tim2.MipmapsToDds(levels, tim2.DdsFormatRGBA8, output)
+	})
+}
+
+func convert2ktx2() {
+	convertTo("ktx2", func(levels []*image.NRGBA, output *os.File) error {
+		return tim2.MipmapsToKtx2(levels, output)
+	})
+}
+
+func init() {
+	flag.StringVar(&openPath, "path", "", "Path to TIM2, TIM3, or TM3 file to open on start, file inside ISO use game.iso:/DIR/FILE")
 }
 
 func main() {
+	flag.Parse()
+
 	rl.InitWindow(int32(width), int32(height), "TIM Viewer")
 	defer rl.CloseWindow()
 	rl.SetTargetFPS(30)
@@ -225,6 +283,14 @@ func main() {
 	defer rlig.Unload()
 	imgui.StyleColorsDark()
 
+	if openPath != "" {
+		if err := drop(openPath); err != nil {
+			rlig.ShowError(err)
+		} else {
+			timPath = openPath
+		}
+	}
+
 	for !rl.WindowShouldClose() {
 		rlig.Update()
 
@@ -238,7 +304,7 @@ func main() {
 			defer rl.UnloadDroppedFiles()
 
 			if err := drop(filePath); err != nil {
-				log.Println(err)
+				rlig.ShowError(err)
 			} else {
 				timPath = filePath
 			}
@@ -282,12 +348,17 @@ func main() {
 			entry := entries[currentEntry]
 			imgui.Text(
 				fmt.Sprintf(
-					"%dx%d\n%s\n%s\nClut Colors %d",
+					"%s\n%dx%d\n%s\n%s\nClut Colors %d\nPicture %d/%d\nClut Bank %d/%d",
+					entry.Name,
 					entry.Picture.ImageWidth,
 					entry.Picture.ImageHeight,
 					entry.Picture.ClutType,
 					entry.Picture.ImageType,
 					entry.Picture.ClutColors,
+					entry.Variant.PictureIndex+1,
+					entry.Variant.PictureTotal,
+					entry.Variant.Bank+1,
+					entry.Variant.BankTotal,
 				),
 			)
 			imgui.End()
@@ -329,6 +400,26 @@ func main() {
 			}()
 		}
 		imgui.EndDisabled()
+		imgui.BeginDisabledV(!canConvert || len(entries) < 2)
+		if imgui.Button("Convert All To PNG") {
+			go func() {
+				convertAll2png()
+			}()
+		}
+		imgui.EndDisabled()
+		imgui.BeginDisabledV(!canConvert)
+		if imgui.Button("Convert To DDS") {
+			go func() {
+				convert2dds()
+			}()
+		}
+		imgui.SameLineV(0, 4)
+		if imgui.Button("Convert To KTX2") {
+			go func() {
+				convert2ktx2()
+			}()
+		}
+		imgui.EndDisabled()
 		imgui.End()
 
 		if currentEntry != -1 && showGsInfo {
@@ -407,6 +498,7 @@ func main() {
 			}
 		}
 
+		rlig.ErrorPopup()
 		rlig.Render()
 		rl.EndDrawing()
 	}
@@ -5,6 +5,7 @@ import (
 )
 
 var timPath = ""
+var openPath = ""
 
 var (
 	width  float32 = 600
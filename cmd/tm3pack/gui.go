@@ -2,13 +2,12 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
 
 	"github.com/AllenDang/cimgui-go/imgui"
 	rlig "github.com/anasrar/chihuahua/pkg/raylib_imgui"
 	"github.com/anasrar/chihuahua/pkg/tm3"
+	"github.com/anasrar/chihuahua/pkg/utils"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
@@ -23,19 +22,23 @@ func clearLog() {
 }
 
 func drop(filePath string) error {
-	buf, err := os.ReadFile(filePath)
-	if err != nil {
+	var m tm3.Metadata
+	if err := tm3.MetadataFromPath(&m, filePath); err != nil {
+		datMetadata = nil
 		return err
 	}
 
-	var m tm3.Metadata
-	if err := json.Unmarshal(buf, &m); err != nil {
+	changes, err := m.Validate(utils.ParentDirectory(filePath))
+	if err != nil {
 		datMetadata = nil
 		return err
 	}
 
 	datMetadata = &m
 	writeLog(fmt.Sprintf("Entry Total: %d", m.EntryTotal))
+	for _, change := range changes {
+		writeLog(change)
+	}
 	writeLog("Ready")
 
 	return nil
@@ -108,6 +111,8 @@ func gui() {
 						if err := pack(
 							ctx,
 							metadataPath,
+							// NOTE: changes is already shown on drop
+							func(message string) {},
 							func(total, current uint32, name string) {
 								writeLog(fmt.Sprintf("%d/%d(%s): start", current, total, name))
 							},
@@ -23,6 +23,9 @@ func main() {
 		if err := pack(
 			ctx,
 			metadataPath,
+			func(message string) {
+				log.Println(message)
+			},
 			func(total, current uint32, name string) {
 				log.Printf("% 8d/%d(%s): start\n", current, total, name)
 			},
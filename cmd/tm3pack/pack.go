@@ -2,8 +2,6 @@ package main
 
 import (
 	"context"
-	"encoding/json"
-	"os"
 	"path/filepath"
 
 	"github.com/anasrar/chihuahua/pkg/tm3"
@@ -13,22 +11,32 @@ import (
 func pack(
 	ctx context.Context,
 	metadataPath string,
+	onChange func(message string),
 	onStart,
 	onDone func(total uint32, current uint32, name string),
 ) error {
-	metadataBuf, err := os.ReadFile(metadataPath)
-	if err != nil {
+	var m tm3.Metadata
+	if err := tm3.MetadataFromPath(&m, metadataPath); err != nil {
 		return err
 	}
 
-	var m tm3.Metadata
-	if err := json.Unmarshal(metadataBuf, &m); err != nil {
+	parentDir := utils.ParentDirectory(metadataPath)
+
+	changes, err := m.Validate(parentDir)
+	if err != nil {
 		return err
 	}
 
-	tim := tm3.New()
+	for _, change := range changes {
+		onChange(change)
+	}
 
-	parentDir := utils.ParentDirectory(metadataPath)
+	tim := tm3.New()
+	tim.HeaderSize = m.HeaderSize
+	tim.Alignment = m.Alignment
+	if m.Unknown != nil {
+		tim.Unknown = *m.Unknown
+	}
 
 	for _, entry := range m.Entries {
 		if err := tim.AddEntryFromPathWithName(
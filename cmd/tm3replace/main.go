@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"log"
+)
+
+func init() {
+	flag.StringVar(&containerPath, "path", "", "Path to TM3 or DAT file")
+	flag.StringVar(&nestedPath, "nested", "", "DAT entry indices to TM3 separated by slash (ex: 0/3), empty when path is TM3")
+	flag.StringVar(&entryQuery, "entry", "", "TM3 entry index or name")
+	flag.StringVar(&pngPath, "pngpath", "", "Path to PNG file")
+	flag.StringVar(&outputPath, "output", "", "Path to output file")
+	flag.BoolVar(&inPlace, "in-place", false, "Overwrite path instead of writing to output")
+}
+
+func main() {
+	flag.Parse()
+
+	// NOTE: overwrite path in place must be explicit
+	if containerPath == "" || entryQuery == "" || pngPath == "" || (outputPath == "" && !inPlace) {
+		flag.Usage()
+		return
+	}
+
+	if outputPath != "" && inPlace {
+		log.Fatalln("Output can not be used with in place")
+	}
+
+	output := outputPath
+	if inPlace {
+		output = containerPath
+	}
+
+	if err := replace(containerPath, nestedPath, entryQuery, pngPath, output); err != nil {
+		log.Fatalln(err)
+	}
+
+	log.Println("Replaced")
+}
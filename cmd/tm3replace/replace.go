@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/anasrar/chihuahua/pkg/dat"
+	"github.com/anasrar/chihuahua/pkg/tim3"
+	"github.com/anasrar/chihuahua/pkg/tm3"
+)
+
+func parseNested(nested string) ([]int, error) {
+	indices := []int{}
+	if nested == "" {
+		return indices, nil
+	}
+
+	for _, part := range strings.Split(nested, "/") {
+		index, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("Nested path %s is not valid", nested)
+		}
+		indices = append(indices, index)
+	}
+
+	return indices, nil
+}
+
+func loadPng(pngPath string) (*image.Paletted, error) {
+	pngFile, err := os.Open(pngPath)
+	if err != nil {
+		return nil, err
+	}
+	defer pngFile.Close()
+
+	img, err := png.Decode(pngFile)
+	if err != nil {
+		return nil, err
+	}
+
+	imgPaletted, ok := img.(*image.Paletted)
+	if !ok {
+		return nil, fmt.Errorf("PNG is not in indexed mode")
+	}
+
+	return imgPaletted, nil
+}
+
+func replace(containerPath, nested, entryQuery, pngPath, output string) error {
+	img, err := loadPng(pngPath)
+	if err != nil {
+		return err
+	}
+
+	indices, err := parseNested(nested)
+	if err != nil {
+		return err
+	}
+
+	data, err := dat.ReplaceNested(containerPath, indices, func(offset uint32, size uint32) ([]byte, error) {
+		tm := tm3.New()
+		if err := tm3.FromPathWithOffsetSize(tm, containerPath, offset, size); err != nil {
+			return nil, err
+		}
+
+		index, err := tm.EntryIndex(entryQuery)
+		if err != nil {
+			return nil, err
+		}
+
+		tim := tim3.New()
+		if err := tim3.FromPathWithOffset(tim, containerPath, tm.Entries[index].Offset); err != nil {
+			return nil, err
+		}
+
+		data, err := tim3.ImagePalettedToBytesWithTim(img, tim)
+		if err != nil {
+			return nil, err
+		}
+
+		return tm.Replace(index, data)
+	})
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(output, data, 0644)
+}
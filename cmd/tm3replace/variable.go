@@ -0,0 +1,8 @@
+package main
+
+var containerPath = ""
+var nestedPath = ""
+var entryQuery = ""
+var pngPath = ""
+var outputPath = ""
+var inPlace = false
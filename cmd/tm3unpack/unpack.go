@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -25,7 +24,11 @@ func unpack(
 	outputMetadataPath := filepath.Join(utils.ParentDirectory(datPath), fmt.Sprintf("UNPACK_%s", utils.Basename(datPath)), "METADATA.json")
 
 	md := tm3.Metadata{
+		Version:    tm3.MetadataVersion,
 		EntryTotal: tim.EntryTotal,
+		Unknown:    &tim.Unknown,
+		HeaderSize: tim.HeaderSize,
+		Alignment:  tim.Alignment,
 		Entries:    []*tm3.MetadataEntry{},
 	}
 
@@ -37,6 +40,8 @@ func unpack(
 			&tm3.MetadataEntry{
 				Source: source,
 				Name:   entry.Name,
+				Size:   entry.Size,
+				Offset: entry.Offset - tim.Offset,
 			},
 		)
 	}
@@ -50,20 +55,13 @@ func unpack(
 		return err
 	}
 
-	buf, err := json.MarshalIndent(md, "", "\t")
-	if err != nil {
-		return err
-	}
-
-	metadataFile, err := os.OpenFile(outputMetadataPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
-	if err != nil {
-		return err
-	}
-	defer metadataFile.Close()
-
-	if _, err := metadataFile.Write(buf); err != nil {
-		return err
+	for _, entry := range md.Entries {
+		checksum, err := utils.ChecksumFile(filepath.Join(utils.ParentDirectory(outputMetadataPath), entry.Source))
+		if err != nil {
+			return err
+		}
+		entry.Checksum = checksum
 	}
 
-	return nil
+	return tm3.MetadataToPath(&md, outputMetadataPath)
 }
@@ -0,0 +1,178 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"syscall/js"
+
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/dat"
+	"github.com/anasrar/chihuahua/pkg/tm3"
+)
+
+func noop(total uint32, current uint32, name string) {}
+
+func intsFromJs(value js.Value) []int {
+	result := []int{}
+	if value.IsUndefined() || value.IsNull() {
+		return result
+	}
+
+	for i := range value.Length() {
+		result = append(result, value.Index(i).Int())
+	}
+
+	return result
+}
+
+func uint32FromJs(value js.Value) uint32 {
+	if value.IsUndefined() || value.IsNull() {
+		return 0
+	}
+
+	return uint32(value.Int())
+}
+
+// NOTE: datUnpack(dat: Uint8Array)
+func datUnpack(args []js.Value) (any, error) {
+	buf, err := bytesFromJs(arg(args, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	d := dat.New()
+	if err := dat.FromStream(d, bytes.NewReader(buf)); err != nil {
+		return nil, err
+	}
+
+	entries := []any{}
+	for _, entry := range d.Entries {
+		data := js.Null()
+		if !entry.IsNull {
+			data = bytesToJs(buf[entry.Offset : entry.Offset+entry.Size])
+		}
+
+		entries = append(entries, map[string]any{
+			"isNull": entry.IsNull,
+			"type":   entry.Type,
+			"offset": entry.Offset,
+			"size":   entry.Size,
+			"data":   data,
+		})
+	}
+
+	order := []any{}
+	for _, index := range d.Order {
+		order = append(order, index)
+	}
+
+	return map[string]any{
+		"headerSize": d.HeaderSize,
+		"alignment":  d.Alignment,
+		"order":      order,
+		"entries":    entries,
+	}, nil
+}
+
+// NOTE: datPack({ headerSize?, alignment?, order?, entries: { isNull, type, data }[] })
+func datPack(args []js.Value) (any, error) {
+	value := arg(args, 0)
+	if value.Type() != js.TypeObject {
+		return nil, fmt.Errorf("Expected object, got %s", value.Type())
+	}
+
+	d := dat.New()
+	d.HeaderSize = uint32FromJs(value.Get("headerSize"))
+	d.Alignment = uint32FromJs(value.Get("alignment"))
+	d.Order = intsFromJs(value.Get("order"))
+
+	entries := value.Get("entries")
+	for i := range entries.Length() {
+		entry := entries.Index(i)
+		if entry.Get("isNull").Truthy() {
+			d.AddNullEntry()
+			continue
+		}
+
+		data, err := bytesFromJs(entry.Get("data"))
+		if err != nil {
+			return nil, fmt.Errorf("Entry %d: %w", i, err)
+		}
+
+		d.AddEntryFromBytesWithType(data, entry.Get("type").String())
+	}
+
+	memory := buffer.NewMemory(nil)
+	if err := d.PackToStream(context.Background(), memory, noop, noop); err != nil {
+		return nil, err
+	}
+
+	return bytesToJs(memory.Bytes()), nil
+}
+
+// NOTE: tm3Unpack(tm3: Uint8Array)
+func tm3Unpack(args []js.Value) (any, error) {
+	buf, err := bytesFromJs(arg(args, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	tm := tm3.New()
+	if err := tm3.FromStream(tm, bytes.NewReader(buf)); err != nil {
+		return nil, err
+	}
+
+	entries := []any{}
+	for _, entry := range tm.Entries {
+		entries = append(entries, map[string]any{
+			"name":   entry.Name,
+			"offset": entry.Offset,
+			"size":   entry.Size,
+			"data":   bytesToJs(buf[entry.Offset : entry.Offset+entry.Size]),
+		})
+	}
+
+	return map[string]any{
+		"unknown":    []any{tm.Unknown[0], tm.Unknown[1]},
+		"headerSize": tm.HeaderSize,
+		"alignment":  tm.Alignment,
+		"entries":    entries,
+	}, nil
+}
+
+// NOTE: tm3Pack({ unknown?, headerSize?, alignment?, entries: { name, data }[] })
+func tm3Pack(args []js.Value) (any, error) {
+	value := arg(args, 0)
+	if value.Type() != js.TypeObject {
+		return nil, fmt.Errorf("Expected object, got %s", value.Type())
+	}
+
+	tm := tm3.New()
+	tm.HeaderSize = uint32FromJs(value.Get("headerSize"))
+	tm.Alignment = uint32FromJs(value.Get("alignment"))
+	if unknown := value.Get("unknown"); !unknown.IsUndefined() && !unknown.IsNull() {
+		tm.Unknown = [2]uint32{uint32FromJs(unknown.Index(0)), uint32FromJs(unknown.Index(1))}
+	}
+
+	entries := value.Get("entries")
+	for i := range entries.Length() {
+		entry := entries.Index(i)
+
+		data, err := bytesFromJs(entry.Get("data"))
+		if err != nil {
+			return nil, fmt.Errorf("Entry %d: %w", i, err)
+		}
+
+		tm.AddEntryFromBytesWithName(data, entry.Get("name").String())
+	}
+
+	memory := buffer.NewMemory(nil)
+	if err := tm.PackToStream(context.Background(), memory, noop, noop); err != nil {
+		return nil, err
+	}
+
+	return bytesToJs(memory.Bytes()), nil
+}
@@ -0,0 +1,104 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"syscall/js"
+)
+
+// NOTE: same shape as Result<T> in webapp/src/lib/result
+func result(value any, err error) js.Value {
+	if err != nil {
+		return js.ValueOf(map[string]any{
+			"error": js.Global().Get("Error").New(err.Error()),
+		})
+	}
+
+	return js.ValueOf(map[string]any{
+		"value": value,
+	})
+}
+
+func wrap(fn func(args []js.Value) (any, error)) js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) (value any) {
+		defer func() {
+			if r := recover(); r != nil {
+				value = result(nil, fmt.Errorf("%v", r))
+			}
+		}()
+
+		return result(fn(args))
+	})
+}
+
+func arg(args []js.Value, index int) js.Value {
+	if index >= len(args) {
+		return js.Undefined()
+	}
+
+	return args[index]
+}
+
+func bytesFromJs(value js.Value) ([]byte, error) {
+	if value.IsUndefined() || value.IsNull() {
+		return nil, fmt.Errorf("Expected Uint8Array, got %s", value.Type())
+	}
+
+	if !value.InstanceOf(js.Global().Get("Uint8Array")) {
+		return nil, fmt.Errorf("Expected Uint8Array")
+	}
+
+	buf := make([]byte, value.Get("byteLength").Int())
+	js.CopyBytesToGo(buf, value)
+
+	return buf, nil
+}
+
+func bytesToJs(buf []byte) js.Value {
+	value := js.Global().Get("Uint8Array").New(len(buf))
+	js.CopyBytesToJS(value, buf)
+
+	return value
+}
+
+// NOTE: parsed struct is passed as plain object with the same field as its json tag
+func jsonToJs(value any) (js.Value, error) {
+	buf, err := json.Marshal(value)
+	if err != nil {
+		return js.Undefined(), err
+	}
+
+	return js.Global().Get("JSON").Call("parse", string(buf)), nil
+}
+
+func imageToJs(img *image.NRGBA) js.Value {
+	return js.ValueOf(map[string]any{
+		"width":  img.Rect.Dx(),
+		"height": img.Rect.Dy(),
+		"pixels": bytesToJs(img.Pix),
+	})
+}
+
+func pngPalettedFromJs(value js.Value) (*image.Paletted, error) {
+	buf, err := bytesFromJs(value)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := png.Decode(bytes.NewReader(buf))
+	if err != nil {
+		return nil, err
+	}
+
+	imgPaletted, ok := img.(*image.Paletted)
+	if !ok {
+		return nil, fmt.Errorf("PNG is not in indexed mode")
+	}
+
+	return imgPaletted, nil
+}
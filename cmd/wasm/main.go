@@ -0,0 +1,37 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"syscall/js"
+)
+
+func main() {
+	exports := map[string]any{
+		"version": GitCommitHash,
+
+		"datUnpack": wrap(datUnpack),
+		"datPack":   wrap(datPack),
+		"tm3Unpack": wrap(tm3Unpack),
+		"tm3Pack":   wrap(tm3Pack),
+
+		"tim2ToImages": wrap(tim2ToImages),
+		"tim3ToImages": wrap(tim3ToImages),
+		"t32ToImage":   wrap(t32ToImage),
+		"pngToTim2":    wrap(pngToTim2),
+		"pngToTim3":    wrap(pngToTim3),
+		"pngToT32":     wrap(pngToT32),
+		"scrToGlb":     wrap(scrToGlb),
+
+		"mdbParse": wrap(mdbParse),
+		"motParse": wrap(motParse),
+		"emsParse": wrap(emsParse),
+		"omsParse": wrap(omsParse),
+		"akgParse": wrap(akgParse),
+	}
+
+	js.Global().Set("chihuahua", js.ValueOf(exports))
+
+	// NOTE: keep exported functions alive
+	select {}
+}
@@ -0,0 +1,89 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"bytes"
+	"syscall/js"
+
+	"github.com/anasrar/chihuahua/pkg/akg"
+	"github.com/anasrar/chihuahua/pkg/ems"
+	"github.com/anasrar/chihuahua/pkg/mdb"
+	"github.com/anasrar/chihuahua/pkg/mot"
+	"github.com/anasrar/chihuahua/pkg/oms"
+)
+
+// NOTE: mdbParse(mdb: Uint8Array), same field as MDB json tag
+func mdbParse(args []js.Value) (any, error) {
+	buf, err := bytesFromJs(arg(args, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	m := mdb.New()
+	if err := mdb.FromStream(m, bytes.NewReader(buf)); err != nil {
+		return nil, err
+	}
+
+	return jsonToJs(m)
+}
+
+// NOTE: motParse(mot: Uint8Array)
+func motParse(args []js.Value) (any, error) {
+	buf, err := bytesFromJs(arg(args, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	m := mot.New()
+	if err := mot.FromStream(m, bytes.NewReader(buf)); err != nil {
+		return nil, err
+	}
+
+	return jsonToJs(m)
+}
+
+// NOTE: emsParse(ems: Uint8Array)
+func emsParse(args []js.Value) (any, error) {
+	buf, err := bytesFromJs(arg(args, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	e := ems.New()
+	if err := ems.FromStream(e, bytes.NewReader(buf)); err != nil {
+		return nil, err
+	}
+
+	return jsonToJs(e)
+}
+
+// NOTE: omsParse(oms: Uint8Array)
+func omsParse(args []js.Value) (any, error) {
+	buf, err := bytesFromJs(arg(args, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	o := oms.New()
+	if err := oms.FromStream(o, bytes.NewReader(buf)); err != nil {
+		return nil, err
+	}
+
+	return jsonToJs(o)
+}
+
+// NOTE: akgParse(akg: Uint8Array)
+func akgParse(args []js.Value) (any, error) {
+	buf, err := bytesFromJs(arg(args, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	a := akg.New()
+	if err := akg.FromStream(a, bytes.NewReader(buf)); err != nil {
+		return nil, err
+	}
+
+	return jsonToJs(a)
+}
@@ -0,0 +1,229 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+	"syscall/js"
+
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/scr"
+	"github.com/anasrar/chihuahua/pkg/t32"
+	"github.com/anasrar/chihuahua/pkg/tim2"
+	"github.com/anasrar/chihuahua/pkg/tim3"
+	"github.com/anasrar/chihuahua/pkg/tm3"
+	"github.com/qmuntal/gltf"
+)
+
+func bppFromJs(value js.Value) (uint, error) {
+	if value.IsUndefined() || value.IsNull() {
+		return 8, nil
+	}
+
+	bpp := uint(value.Int())
+	if bpp != 4 && bpp != 8 {
+		return 0, fmt.Errorf("Bpp %d not supported, use 4 or 8", bpp)
+	}
+
+	return bpp, nil
+}
+
+// NOTE: image with picture info shown by web viewer, clut is RGBA per color
+func pictureToJs(picture *tim2.Picture, img *image.NRGBA) js.Value {
+	clut := make([]byte, 0, len(picture.ClutData)*4)
+	for _, c := range picture.ClutData {
+		clut = append(clut, c.R, c.G, c.B, c.A)
+	}
+
+	return js.ValueOf(map[string]any{
+		"width":      img.Rect.Dx(),
+		"height":     img.Rect.Dy(),
+		"pixels":     bytesToJs(img.Pix),
+		"imageType":  picture.ImageType.String(),
+		"clutColors": int(picture.ClutColors),
+		"clut":       bytesToJs(clut),
+	})
+}
+
+// NOTE: tim2ToImages(tim2: Uint8Array)
+func tim2ToImages(args []js.Value) (any, error) {
+	buf, err := bytesFromJs(arg(args, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	tim := tim2.New()
+	if err := tim2.FromStream(tim, bytes.NewReader(buf)); err != nil {
+		return nil, err
+	}
+
+	images := []any{}
+	for _, picture := range tim.Pictures {
+		nrgba, err := tim2.PictureToImage(picture)
+		if err != nil {
+			return nil, err
+		}
+
+		images = append(images, pictureToJs(picture, nrgba))
+	}
+
+	return images, nil
+}
+
+// NOTE: tim3ToImages(tim3: Uint8Array)
+func tim3ToImages(args []js.Value) (any, error) {
+	buf, err := bytesFromJs(arg(args, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	tim := tim3.New()
+	if err := tim3.FromStream(tim, bytes.NewReader(buf)); err != nil {
+		return nil, err
+	}
+
+	images := []any{}
+	for _, picture := range tim.Pictures {
+		nrgba, err := tim3.PictureToImage(picture)
+		if err != nil {
+			return nil, err
+		}
+
+		images = append(images, pictureToJs(picture, nrgba))
+	}
+
+	return images, nil
+}
+
+// NOTE: t32ToImage(t32: Uint8Array)
+func t32ToImage(args []js.Value) (any, error) {
+	buf, err := bytesFromJs(arg(args, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	t := t32.New()
+	if err := t32.FromStream(t, bytes.NewReader(buf)); err != nil {
+		return nil, err
+	}
+
+	nrgba, err := t32.T32ToImage(t)
+	if err != nil {
+		return nil, err
+	}
+
+	return imageToJs(nrgba), nil
+}
+
+// NOTE: pngToTim2(png: Uint8Array, bpp?: 4 | 8)
+func pngToTim2(args []js.Value) (any, error) {
+	img, err := pngPalettedFromJs(arg(args, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	bpp, err := bppFromJs(arg(args, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	memory := buffer.NewMemory(nil)
+	if err := tim2.ImagePalettedToFile(img, bpp, memory); err != nil {
+		return nil, err
+	}
+
+	return bytesToJs(memory.Bytes()), nil
+}
+
+// NOTE: pngToTim3(png: Uint8Array, bpp?: 4 | 8)
+func pngToTim3(args []js.Value) (any, error) {
+	img, err := pngPalettedFromJs(arg(args, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	bpp, err := bppFromJs(arg(args, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	memory := buffer.NewMemory(nil)
+	if err := tim3.ImagePalettedToFile(img, bpp, memory); err != nil {
+		return nil, err
+	}
+
+	return bytesToJs(memory.Bytes()), nil
+}
+
+// NOTE: pngToT32(t32: Uint8Array, png: Uint8Array), original T32 used as template
+func pngToT32(args []js.Value) (any, error) {
+	buf, err := bytesFromJs(arg(args, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := pngPalettedFromJs(arg(args, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	memory := buffer.NewMemory(nil)
+	if err := t32.ImagePalettedToStream(bytes.NewReader(buf), img, memory); err != nil {
+		return nil, err
+	}
+
+	return bytesToJs(memory.Bytes()), nil
+}
+
+// NOTE: scrToGlb(scr: Uint8Array, tm3?: Uint8Array, textureBase?: number)
+func scrToGlb(args []js.Value) (any, error) {
+	buf, err := bytesFromJs(arg(args, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	s := scr.New()
+	if err := scr.FromStream(s, bytes.NewReader(buf)); err != nil {
+		return nil, err
+	}
+
+	var tm *tm3.Tm3
+	var tm3Stream io.ReadSeeker
+	if value := arg(args, 1); !value.IsUndefined() && !value.IsNull() {
+		tm3Buf, err := bytesFromJs(value)
+		if err != nil {
+			return nil, err
+		}
+
+		tm3Stream = bytes.NewReader(tm3Buf)
+		tm = tm3.New()
+		if err := tm3.FromStream(tm, tm3Stream); err != nil {
+			return nil, err
+		}
+	}
+
+	var bindings map[uint16]int
+	if value := arg(args, 2); value.Type() == js.TypeNumber && tm != nil {
+		if bindings, err = s.ResolveMaterials(len(tm.Entries), value.Int()); err != nil {
+			return nil, err
+		}
+	}
+
+	// NOTE: partial export (uncovered material without texture) is still returned as GLB
+	doc, err := scr.ToGltf(s, tm, tm3Stream, bindings, nil)
+	if doc == nil {
+		return nil, err
+	}
+
+	var glb bytes.Buffer
+	encoder := gltf.NewEncoder(&glb)
+	encoder.AsBinary = true
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+
+	return bytesToJs(glb.Bytes()), nil
+}
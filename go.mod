@@ -3,6 +3,7 @@ module github.com/anasrar/chihuahua
 go 1.23.1
 
 require (
+	github.com/AllenDang/cimgui-go v1.2.0
 	github.com/gen2brain/raylib-go/raygui v0.0.0-20241019150900-b7833eeae8d0
 	github.com/gen2brain/raylib-go/raylib v0.0.0-20231118125650-a1c890e8cbfc
 	github.com/qmuntal/gltf v0.27.0
@@ -10,7 +11,6 @@ require (
 )
 
 require (
-	github.com/AllenDang/cimgui-go v1.2.0 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/ebitengine/purego v0.8.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
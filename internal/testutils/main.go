@@ -0,0 +1,50 @@
+// NOTE: helpers shared by package tests, not used by commands
+package testutils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// NOTE: little endian value one after another, same as how most game format is stored
+func Write(buf *bytes.Buffer, values ...any) {
+	for _, value := range values {
+		binary.Write(buf, binary.LittleEndian, value)
+	}
+}
+
+// NOTE: samples are not in repository (samples directory in repository root), test that need them is skipped on
+// clean checkout
+func Sample(t testing.TB, name string) string {
+	_, file, _, _ := runtime.Caller(0)
+	source := filepath.Join(filepath.Dir(file), "../../samples", name)
+	if _, err := os.Stat(source); err != nil {
+		t.Skipf("Sample %s not found", name)
+	}
+
+	return source
+}
+
+// NOTE: onStart and onDone of pack and unpack
+func Noop(total uint32, current uint32, name string) {}
+
+// NOTE: paletted image with every palette color used
+func Paletted(width int, height int, colorTotal int) *image.Paletted {
+	palette := color.Palette{}
+	for i := range colorTotal {
+		palette = append(palette, color.RGBA{R: uint8(i), G: uint8(255 - i), B: uint8(i * 7), A: 0xFF})
+	}
+
+	img := image.NewPaletted(image.Rect(0, 0, width, height), palette)
+	for i := range img.Pix {
+		img.Pix[i] = uint8((i * 3) % colorTotal)
+	}
+
+	return img
+}
@@ -0,0 +1,154 @@
+package testutils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"testing"
+
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/tim2"
+	"github.com/stretchr/testify/assert"
+)
+
+// NOTE: TIM to indexed PNG and back keep image data and CLUT as is, transparent color included. TIM2 and TIM3 share
+// picture, only image data layout is different so toTim (first picture of packed TIM) and toPaletted is per format
+func PalettedRoundTrip(
+	t *testing.T,
+	size int,
+	toTim func(img *image.Paletted, bpp uint) (*tim2.Picture, error),
+	toPaletted func(picture *tim2.Picture) (*image.Paletted, error),
+) {
+	for _, tc := range []struct {
+		name       string
+		bpp        uint
+		colorTotal int
+	}{
+		{"4 bpp", 4, 16},
+		{"8 bpp", 8, 256},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			img := Paletted(size, size, tc.colorTotal)
+			img.Palette[1] = color.NRGBA{R: 0xFF, G: 0x00, B: 0xFF, A: 0x00}
+			original, err := toTim(img, tc.bpp)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			paletted, err := toPaletted(original)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			buf := bytes.Buffer{}
+			if err := png.Encode(&buf, paletted); err != nil {
+				t.Fatal(err)
+			}
+
+			decoded, err := png.Decode(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := toTim(decoded.(*image.Paletted), tc.bpp)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, original.ImageData, result.ImageData)
+			assert.Equal(t, original.ClutData, result.ClutData)
+		})
+	}
+}
+
+// NOTE: colors for WriteClut, alpha is 0x00 or 0xFF so it is the same after PS2 alpha (0x00 - 0x80) round trip
+func Clut(colorTotal int) []*color.RGBA {
+	colors := []*color.RGBA{}
+	for i := range colorTotal {
+		colors = append(colors, &color.RGBA{R: uint8(255 - i), G: 0x40, B: uint8(i), A: uint8(min(i, 1) * 0xFF)})
+	}
+
+	return colors
+}
+
+// NOTE: CLUT is written in place to packed TIM stored after other data (offset), every other byte stay the same.
+// CLUT with 32 colors or more is stored twiddled (second and third 8 colors swapped) and read back in original order
+func WriteClutRoundTrip(
+	t *testing.T,
+	toTim func(img *image.Paletted, bpp uint) ([]byte, error),
+	parse func(stream io.ReadSeeker, offset uint32) (*tim2.Picture, error),
+	write func(stream io.ReadWriteSeeker, offset uint32, pictureIndex int, colors []*color.RGBA) error,
+) {
+	for _, tc := range []struct {
+		name       string
+		bpp        uint
+		colorTotal int
+		slots      map[int]int // NOTE: color index to CLUT slot in file
+	}{
+		{"4 bpp", 4, 16, map[int]int{0: 0, 8: 8, 15: 15}},
+		{"8 bpp twiddle", 8, 256, map[int]int{0: 0, 8: 16, 16: 8, 24: 24, 40: 48, 255: 255}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := toTim(Paletted(32, 32, tc.colorTotal), tc.bpp)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			offset := uint32(16)
+			memory := buffer.NewMemory(append(make([]byte, offset), data...))
+			colors := Clut(tc.colorTotal)
+			if err := write(memory, offset, 0, colors); err != nil {
+				t.Fatal(err)
+			}
+
+			written := memory.Bytes()
+			assert.Equal(t, int(offset)+len(data), len(written))
+
+			picture, err := parse(bytes.NewReader(written), offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, colors, picture.ClutData)
+
+			clut := written[len(written)-tc.colorTotal*4:]
+			for index, slot := range tc.slots {
+				c := colors[index]
+				assert.Equal(t, []byte{c.R, c.G, c.B, tim2.AlphaToGs(c.A)}, clut[slot*4:slot*4+4], "color %d", index)
+			}
+			assert.Equal(t, data[:len(data)-len(clut)], written[offset:len(written)-len(clut)])
+
+			assert.Error(t, write(memory, offset, 0, colors[1:]))
+			assert.Error(t, write(memory, offset, 1, colors))
+		})
+	}
+}
+
+// NOTE: 16x16 8 bit picture then 8x8 4 bit picture with 2 CLUT banks (32 colors). Signature is written over, TIM3 has
+// the same layout
+func Banked(signature uint32) []byte {
+	first := buffer.NewMemory(nil)
+	if err := tim2.ImagePalettedToFile(Paletted(16, 16, 256), 8, first); err != nil {
+		panic(err)
+	}
+
+	second := buffer.NewMemory(nil)
+	if err := tim2.ImagePalettedToFile(Paletted(8, 8, 16), 4, second); err != nil {
+		panic(err)
+	}
+
+	// NOTE: second bank is copy of first bank, picture header start after file header (16)
+	banked := second.Bytes()[16:]
+	banked = append(banked, banked[len(banked)-64:]...)
+	binary.LittleEndian.PutUint32(banked[0:], binary.LittleEndian.Uint32(banked[0:])+64) // NOTE: Picture.total_size
+	binary.LittleEndian.PutUint32(banked[4:], 128)                                       // NOTE: Picture.clut_size
+	binary.LittleEndian.PutUint16(banked[14:], 32)                                       // NOTE: Picture.clut_colors
+
+	data := first.Bytes()
+	binary.LittleEndian.PutUint32(data[0:], signature)
+	binary.LittleEndian.PutUint16(data[6:], 2) // NOTE: FileHeader.pictures
+
+	return append(data, banked...)
+}
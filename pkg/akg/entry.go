@@ -0,0 +1,53 @@
+package akg
+
+const (
+	EntryNameLength uint64 = 8
+)
+
+// NOTE: table 0 record, num_0 and unknown is not researched yet
+type Entry0 struct {
+	Num0    uint16    `json:"num_0"`
+	Unknown [5]uint16 `json:"unknown"`
+	Name    string    `json:"name"`
+}
+
+// NOTE: table 1 record, layout is not researched yet
+type Entry1 struct {
+	Unknown [8]uint16 `json:"unknown"`
+}
+
+// NOTE: table 2 and table 3 record
+type Entry2 struct {
+	Position [3]float32 `json:"position"`
+}
+
+// NOTE: table 7 record
+type Entry7 struct {
+	Name string `json:"name"`
+}
+
+func NewEntry0(num0 uint16, unknown [5]uint16, name string) *Entry0 {
+	return &Entry0{
+		Num0:    num0,
+		Unknown: unknown,
+		Name:    name,
+	}
+}
+
+func NewEntry1(unknown [8]uint16) *Entry1 {
+	return &Entry1{
+		Unknown: unknown,
+	}
+}
+
+func NewEntry2(position [3]float32) *Entry2 {
+	return &Entry2{
+		Position: position,
+	}
+}
+
+func NewEntry7(name string) *Entry7 {
+	return &Entry7{
+		Name: name,
+	}
+}
@@ -0,0 +1,25 @@
+package akg_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/anasrar/chihuahua/pkg/akg"
+	"github.com/anasrar/chihuahua/pkg/buffer"
+)
+
+func FuzzFromStream(f *testing.F) {
+	memory := buffer.NewMemory(nil)
+	if err := akg.ToStream(fixture(), memory); err != nil {
+		f.Fatal(err)
+	}
+
+	f.Add(memory.Bytes())
+	f.Add(make([]byte, akg.HeaderSize))
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		d := akg.New()
+		_ = akg.FromStream(d, bytes.NewReader(data))
+	})
+}
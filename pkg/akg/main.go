@@ -0,0 +1,469 @@
+package akg
+
+import (
+	"cmp"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/iso9660"
+	"github.com/anasrar/chihuahua/pkg/utils"
+)
+
+const (
+	TableTotal int    = 8
+	HeaderSize uint32 = 48 // NOTE: offsets (uint32[8]) + totals (uint16[6]) padded to 16
+	Alignment  uint32 = 16
+)
+
+type Akg struct {
+	Offset   uint32             `json:"offset"`
+	Size     uint32             `json:"size"`
+	Offsets  [TableTotal]uint32 `json:"offsets"` // NOTE: table offset relative to AKG
+	Entries0 []*Entry0          `json:"entries_0"`
+	Entries1 []*Entry1          `json:"entries_1"`
+	Entries2 []*Entry2          `json:"entries_2"`
+	Entries3 []*Entry2          `json:"entries_3"`
+	// NOTE: table 4 has total in header but layout is not researched yet, table 5 and 6 has no total, kept as raw bytes
+	Entry4Total uint16    `json:"entry_4_total"`
+	Table4      []byte    `json:"table_4"`
+	Table5      []byte    `json:"table_5"`
+	Table6      []byte    `json:"table_6"`
+	Entries7    []*Entry7 `json:"entries_7"`
+}
+
+// NOTE: raw table size is distance to the next table, zero offset mean empty table
+func (self *Akg) tableSize(index int) uint32 {
+	if self.Offsets[index] == 0 {
+		return 0
+	}
+
+	end := self.Size
+	for _, offset := range self.Offsets {
+		if offset > self.Offsets[index] && offset < end {
+			end = offset
+		}
+	}
+
+	return end - self.Offsets[index]
+}
+
+func (self *Akg) unmarshal(stream io.ReadSeeker) error {
+	cursor := buffer.NewCursorLE(stream, "AKG")
+
+	size, err := cursor.Size()
+	if err != nil {
+		return err
+	}
+
+	if self.Size == 0 && uint64(self.Offset) <= size {
+		self.Size = uint32(size - uint64(self.Offset))
+	}
+
+	if err := cursor.Within("Size", uint64(self.Offset), uint64(self.Size)); err != nil {
+		return err
+	}
+
+	if _, err := cursor.Move(int64(self.Offset), buffer.SeekStart); err != nil {
+		return err
+	}
+
+	for i := range self.Offsets {
+		if _, err := cursor.ReadUint32("Offsets", &self.Offsets[i]); err != nil {
+			return err
+		}
+
+		if self.Offsets[i] > self.Size {
+			return cursor.Invalid("Offsets", "table %d offset 0x%X is outside of %d bytes", i, self.Offsets[i], self.Size)
+		}
+	}
+
+	totals := [6]uint16{}
+	for i := range totals {
+		if _, err := cursor.ReadUint16("totals", &totals[i]); err != nil {
+			return err
+		}
+	}
+	self.Entry4Total = totals[4]
+
+	// NOTE: table 0
+	if _, err := cursor.Move(int64(self.Offset+self.Offsets[0]), buffer.SeekStart); err != nil {
+		return err
+	}
+
+	if err := cursor.Require("entry0Total", uint64(totals[0])*20); err != nil {
+		return err
+	}
+
+	self.Entries0 = []*Entry0{}
+	for range totals[0] {
+		num0 := uint16(0)
+		if _, err := cursor.ReadUint16("num0", &num0); err != nil {
+			return err
+		}
+
+		unknown := [5]uint16{}
+		for i := range unknown {
+			if _, err := cursor.ReadUint16("unknown", &unknown[i]); err != nil {
+				return err
+			}
+		}
+
+		name := ""
+		if _, err := cursor.ReadString("name", &name, EntryNameLength); err != nil {
+			return err
+		}
+
+		self.Entries0 = append(self.Entries0, NewEntry0(num0, unknown, name))
+	}
+
+	// NOTE: table 1
+	if _, err := cursor.Move(int64(self.Offset+self.Offsets[1]), buffer.SeekStart); err != nil {
+		return err
+	}
+
+	if err := cursor.Require("entry1Total", uint64(totals[1])*16); err != nil {
+		return err
+	}
+
+	self.Entries1 = []*Entry1{}
+	for range totals[1] {
+		unknown := [8]uint16{}
+		for i := range unknown {
+			if _, err := cursor.ReadUint16("unknown", &unknown[i]); err != nil {
+				return err
+			}
+		}
+
+		self.Entries1 = append(self.Entries1, NewEntry1(unknown))
+	}
+
+	// NOTE: table 2 and 3
+	for _, table := range []struct {
+		index   int
+		field   string
+		total   uint16
+		entries *[]*Entry2
+	}{
+		{2, "entry2Total", totals[2], &self.Entries2},
+		{3, "entry3Total", totals[3], &self.Entries3},
+	} {
+		if _, err := cursor.Move(int64(self.Offset+self.Offsets[table.index]), buffer.SeekStart); err != nil {
+			return err
+		}
+
+		if err := cursor.Require(table.field, uint64(table.total)*12); err != nil {
+			return err
+		}
+
+		*table.entries = []*Entry2{}
+		for range table.total {
+			position := [3]float32{}
+			for i := range position {
+				if _, err := cursor.ReadFloat32("position", &position[i]); err != nil {
+					return err
+				}
+			}
+
+			*table.entries = append(*table.entries, NewEntry2(position))
+		}
+	}
+
+	// NOTE: table 4, 5, and 6
+	for _, table := range []struct {
+		index int
+		field string
+		data  *[]byte
+	}{
+		{4, "Table4", &self.Table4},
+		{5, "Table5", &self.Table5},
+		{6, "Table6", &self.Table6},
+	} {
+		if _, err := cursor.Move(int64(self.Offset+self.Offsets[table.index]), buffer.SeekStart); err != nil {
+			return err
+		}
+
+		*table.data = make([]byte, self.tableSize(table.index))
+		if _, err := cursor.ReadBytes(table.field, *table.data); err != nil {
+			return err
+		}
+	}
+
+	// NOTE: table 7
+	if _, err := cursor.Move(int64(self.Offset+self.Offsets[7]), buffer.SeekStart); err != nil {
+		return err
+	}
+
+	if err := cursor.Require("entry7Total", uint64(totals[5])*EntryNameLength); err != nil {
+		return err
+	}
+
+	self.Entries7 = []*Entry7{}
+	for range totals[5] {
+		name := ""
+		if _, err := cursor.ReadString("name", &name, EntryNameLength); err != nil {
+			return err
+		}
+
+		self.Entries7 = append(self.Entries7, NewEntry7(name))
+	}
+
+	return nil
+}
+
+func writeName(stream io.WriteSeeker, name string) error {
+	b := make([]byte, EntryNameLength)
+	copy(b, name)
+
+	_, err := buffer.WriteBytes(stream, b)
+	return err
+}
+
+// NOTE: pad stream to alignment relative to start of AKG, return offset relative to start
+func align(stream io.WriteSeeker, start uint64) (uint32, error) {
+	position := uint64(0)
+	if _, err := buffer.Position(stream, &position); err != nil {
+		return 0, err
+	}
+
+	size := uint32(position - start)
+	if _, err := buffer.WriteBytes(stream, make([]byte, utils.AlignUp(size, Alignment)-size)); err != nil {
+		return 0, err
+	}
+
+	return utils.AlignUp(size, Alignment), nil
+}
+
+func (self *Akg) tableEmpty(index int) bool {
+	switch index {
+	case 0:
+		return len(self.Entries0) == 0
+	case 1:
+		return len(self.Entries1) == 0
+	case 2:
+		return len(self.Entries2) == 0
+	case 3:
+		return len(self.Entries3) == 0
+	case 4:
+		return len(self.Table4) == 0
+	case 5:
+		return len(self.Table5) == 0
+	case 6:
+		return len(self.Table6) == 0
+	default:
+		return len(self.Entries7) == 0
+	}
+}
+
+// NOTE: tables are written in the same order as original offset and aligned to 16, table that was not in original
+// (zero offset) is written after in index order. Offsets are recalculated except empty table with zero offset. Raw
+// table 4, 5, and 6 keep padding up to the next table, padding after other table is not kept when it is bigger than
+// alignment, so repack of that file change the layout
+func (self *Akg) marshal(stream io.WriteSeeker) error {
+	start := uint64(0)
+	if _, err := buffer.Position(stream, &start); err != nil {
+		return err
+	}
+
+	if _, err := buffer.WriteBytes(stream, make([]byte, HeaderSize)); err != nil {
+		return err
+	}
+
+	tables := [TableTotal]func() error{
+		func() error {
+			for _, entry := range self.Entries0 {
+				if _, err := buffer.WriteUint16LE(stream, entry.Num0); err != nil {
+					return err
+				}
+
+				for _, unknown := range entry.Unknown {
+					if _, err := buffer.WriteUint16LE(stream, unknown); err != nil {
+						return err
+					}
+				}
+
+				if err := writeName(stream, entry.Name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		func() error {
+			for _, entry := range self.Entries1 {
+				for _, unknown := range entry.Unknown {
+					if _, err := buffer.WriteUint16LE(stream, unknown); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+		func() error {
+			return writePositions(stream, self.Entries2)
+		},
+		func() error {
+			return writePositions(stream, self.Entries3)
+		},
+		func() error {
+			_, err := buffer.WriteBytes(stream, self.Table4)
+			return err
+		},
+		func() error {
+			_, err := buffer.WriteBytes(stream, self.Table5)
+			return err
+		},
+		func() error {
+			_, err := buffer.WriteBytes(stream, self.Table6)
+			return err
+		},
+		func() error {
+			for _, entry := range self.Entries7 {
+				if err := writeName(stream, entry.Name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+
+	order := []int{}
+	for i := range tables {
+		if self.Offsets[i] == 0 && self.tableEmpty(i) {
+			continue
+		}
+		order = append(order, i)
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(self.Offsets[a]-1, self.Offsets[b]-1) // NOTE: zero offset wrap around to the end
+	})
+
+	for _, i := range order {
+		offset, err := align(stream, start)
+		if err != nil {
+			return err
+		}
+		self.Offsets[i] = offset
+
+		if err := tables[i](); err != nil {
+			return err
+		}
+	}
+
+	size, err := align(stream, start)
+	if err != nil {
+		return err
+	}
+	self.Size = size
+
+	if _, err := buffer.Seek(stream, int64(start), buffer.SeekStart); err != nil {
+		return err
+	}
+
+	for _, offset := range self.Offsets {
+		if _, err := buffer.WriteUint32LE(stream, offset); err != nil {
+			return err
+		}
+	}
+
+	totals := []uint16{
+		uint16(len(self.Entries0)),
+		uint16(len(self.Entries1)),
+		uint16(len(self.Entries2)),
+		uint16(len(self.Entries3)),
+		self.Entry4Total,
+		uint16(len(self.Entries7)),
+	}
+	for _, total := range totals {
+		if _, err := buffer.WriteUint16LE(stream, total); err != nil {
+			return err
+		}
+	}
+
+	if _, err := buffer.Seek(stream, int64(start)+int64(self.Size), buffer.SeekStart); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func writePositions(stream io.WriteSeeker, entries []*Entry2) error {
+	for _, entry := range entries {
+		for _, v := range entry.Position {
+			if _, err := buffer.WriteFloat32LE(stream, v); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// NOTE: every position from table 2 and table 3 in table order
+func (self *Akg) Positions() [][3]float32 {
+	result := [][3]float32{}
+	for _, entries := range [][]*Entry2{self.Entries2, self.Entries3} {
+		for _, entry := range entries {
+			result = append(result, entry.Position)
+		}
+	}
+
+	return result
+}
+
+func New() *Akg {
+	return &Akg{
+		Offset:      0,
+		Size:        0,
+		Offsets:     [TableTotal]uint32{},
+		Entries0:    []*Entry0{},
+		Entries1:    []*Entry1{},
+		Entries2:    []*Entry2{},
+		Entries3:    []*Entry2{},
+		Entry4Total: 0,
+		Table4:      []byte{},
+		Table5:      []byte{},
+		Table6:      []byte{},
+		Entries7:    []*Entry7{},
+	}
+}
+
+func FromStreamWithOffsetSize(akg *Akg, stream io.ReadSeeker, offset uint32, size uint32) error {
+	akg.Offset = offset
+	akg.Size = size
+	return akg.unmarshal(stream)
+}
+
+func FromStream(akg *Akg, stream io.ReadSeeker) error {
+	return FromStreamWithOffsetSize(akg, stream, 0, 0)
+}
+
+func FromPathWithOffsetSize(akg *Akg, filePath string, offset uint32, size uint32) error {
+	file, err := iso9660.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	akg.Offset = offset
+	akg.Size = size
+	return akg.unmarshal(file)
+}
+
+func FromPath(akg *Akg, filePath string) error {
+	return FromPathWithOffsetSize(akg, filePath, 0, 0)
+}
+
+func ToStream(akg *Akg, stream io.WriteSeeker) error {
+	return akg.marshal(stream)
+}
+
+func ToPath(akg *Akg, filePath string) error {
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return akg.marshal(file)
+}
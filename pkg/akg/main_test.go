@@ -0,0 +1,131 @@
+package akg_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/anasrar/chihuahua/internal/testutils"
+	"github.com/anasrar/chihuahua/pkg/akg"
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/stretchr/testify/assert"
+)
+
+func fixture() *akg.Akg {
+	a := akg.New()
+	a.Entries0 = []*akg.Entry0{
+		akg.NewEntry0(1, [5]uint16{1, 2, 3, 4, 5}, "door0\x00\x00\x00"),
+		akg.NewEntry0(2, [5]uint16{}, "door1\x00\x00\x00"),
+	}
+	a.Entries1 = []*akg.Entry1{
+		akg.NewEntry1([8]uint16{8, 7, 6, 5, 4, 3, 2, 1}),
+	}
+	a.Entries2 = []*akg.Entry2{
+		akg.NewEntry2([3]float32{1, 2, 3}),
+		akg.NewEntry2([3]float32{-1, 0, 1}),
+	}
+	a.Entries3 = []*akg.Entry2{
+		akg.NewEntry2([3]float32{4, 5, 6}),
+	}
+	a.Entry4Total = 1
+	a.Table4 = bytes.Repeat([]byte{0xAB}, 16)
+	a.Entries7 = []*akg.Entry7{
+		akg.NewEntry7("area0\x00\x00\x00"),
+	}
+
+	return a
+}
+
+func TestRoundTrip(t *testing.T) {
+	a := fixture()
+
+	memory := buffer.NewMemory(nil)
+	if err := akg.ToStream(a, memory); err != nil {
+		t.Fatal(err)
+	}
+
+	d := akg.New()
+	if err := akg.FromStream(d, bytes.NewReader(memory.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, a.Offsets, d.Offsets)
+	assert.Equal(t, uint32(0), d.Offsets[5])
+	assert.Equal(t, a.Entries0, d.Entries0)
+	assert.Equal(t, a.Entries1, d.Entries1)
+	assert.Equal(t, a.Entries2, d.Entries2)
+	assert.Equal(t, a.Entries3, d.Entries3)
+	assert.Equal(t, a.Entry4Total, d.Entry4Total)
+	assert.Equal(t, a.Table4, d.Table4)
+	assert.Equal(t, a.Entries7, d.Entries7)
+	assert.Equal(t, [][3]float32{{1, 2, 3}, {-1, 0, 1}, {4, 5, 6}}, d.Positions())
+
+	repacked := buffer.NewMemory(nil)
+	if err := akg.ToStream(d, repacked); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, memory.Bytes(), repacked.Bytes())
+}
+
+func TestFromStreamInvalidOffset(t *testing.T) {
+	memory := buffer.NewMemory(nil)
+	if err := akg.ToStream(fixture(), memory); err != nil {
+		t.Fatal(err)
+	}
+
+	data := memory.Bytes()
+	data[0] = 0xFF
+	data[1] = 0xFF
+
+	d := akg.New()
+	assert.Error(t, akg.FromStream(d, bytes.NewReader(data)))
+}
+
+// NOTE: table 7, 3, 2, 1, 0, then 4 with table 5 and 6 empty, reverse of index order
+func unordered() []byte {
+	var buf bytes.Buffer
+	testutils.Write(&buf, [8]uint32{0x80, 0x70, 0x50, 0x40, 0xB0, 0, 0, 0x30}, [6]uint16{2, 1, 2, 1, 1, 1}, [4]byte{})
+	testutils.Write(&buf, [8]byte{'a', 'r', 'e', 'a', '0'}, [8]byte{})
+	testutils.Write(&buf, [3]float32{4, 5, 6}, [4]byte{})
+	testutils.Write(&buf, [3]float32{1, 2, 3}, [3]float32{-1, 0, 1}, [8]byte{})
+	testutils.Write(&buf, [8]uint16{8, 7, 6, 5, 4, 3, 2, 1})
+	testutils.Write(&buf, uint16(1), [5]uint16{1, 2, 3, 4, 5}, [8]byte{'d', 'o', 'o', 'r', '0'})
+	testutils.Write(&buf, uint16(2), [5]uint16{}, [8]byte{'d', 'o', 'o', 'r', '1'}, [8]byte{})
+	testutils.Write(&buf, bytes.Repeat([]byte{0xAB}, 16))
+
+	return buf.Bytes()
+}
+
+func TestToStreamLayout(t *testing.T) {
+	data := unordered()
+
+	a := akg.New()
+	if err := akg.FromStream(a, bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, [][3]float32{{1, 2, 3}, {-1, 0, 1}, {4, 5, 6}}, a.Positions())
+
+	repacked := buffer.NewMemory(nil)
+	if err := akg.ToStream(a, repacked); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, data, repacked.Bytes())
+
+	t.Run("offset", func(t *testing.T) {
+		// NOTE: AKG written after other data, offsets stay relative to AKG
+		memory := buffer.NewMemory(nil)
+		if _, err := buffer.WriteBytes(memory, make([]byte, 8)); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := akg.ToStream(a, memory); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, data, memory.Bytes()[8:])
+
+		d := akg.New()
+		if err := akg.FromStreamWithOffsetSize(d, bytes.NewReader(memory.Bytes()), 8, 0); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, a.Offsets, d.Offsets)
+	})
+}
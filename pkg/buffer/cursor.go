@@ -0,0 +1,223 @@
+package buffer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// NOTE: read only stream with byte order, every error returned as *ParseError with format, field, and offset
+type Cursor struct {
+	Stream io.ReadSeeker
+	Order  binary.ByteOrder
+	Format string
+	last   uint64
+	size   *uint64
+}
+
+func (self *Cursor) wrap(field string, offset uint64, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &ParseError{
+		Format: self.Format,
+		Field:  field,
+		Offset: offset,
+		Err:    err,
+	}
+}
+
+func (self *Cursor) Position() (uint64, error) {
+	position := uint64(0)
+	if _, err := Position(self.Stream, &position); err != nil {
+		return 0, self.wrap("", self.last, err)
+	}
+
+	return position, nil
+}
+
+// NOTE: mismatch error at offset of last read field
+func (self *Cursor) Mismatch(field string, expected any, found any) error {
+	return &ParseError{
+		Format:   self.Format,
+		Field:    field,
+		Offset:   self.last,
+		Expected: expected,
+		Found:    found,
+	}
+}
+
+// NOTE: stream size is cached, stream is not expected to grow while parsing
+func (self *Cursor) Size() (uint64, error) {
+	if self.size != nil {
+		return *self.size, nil
+	}
+
+	current, err := self.Position()
+	if err != nil {
+		return 0, err
+	}
+
+	size, err := Seek(self.Stream, 0, SeekEnd)
+	if err != nil {
+		return 0, self.wrap("", current, err)
+	}
+
+	if _, err := Seek(self.Stream, int64(current), SeekStart); err != nil {
+		return 0, self.wrap("", current, err)
+	}
+
+	self.size = &size
+
+	return size, nil
+}
+
+// NOTE: check range [offset, offset+size) is inside the stream, error point to last read field since that is where the bad value come from
+func (self *Cursor) Within(field string, offset uint64, size uint64) error {
+	total, err := self.Size()
+	if err != nil {
+		return err
+	}
+
+	if offset <= total && size <= total-offset {
+		return nil
+	}
+
+	available := uint64(0)
+	if offset < total {
+		available = total - offset
+	}
+
+	return &ParseError{
+		Format: self.Format,
+		Field:  field,
+		Offset: self.last,
+		Err:    fmt.Errorf("%w, need %d bytes at offset 0x%X, %d bytes available", ErrOutOfBounds, size, offset, available),
+	}
+}
+
+// NOTE: invalid value error at offset of last read field
+func (self *Cursor) Invalid(field string, format string, a ...any) error {
+	return &ParseError{
+		Format: self.Format,
+		Field:  field,
+		Offset: self.last,
+		Err:    fmt.Errorf("%w, %s", ErrInvalid, fmt.Sprintf(format, a...)),
+	}
+}
+
+// NOTE: use before allocate or loop with size or count from file
+func (self *Cursor) Require(field string, size uint64) error {
+	position, err := self.Position()
+	if err != nil {
+		return err
+	}
+
+	return self.Within(field, position, size)
+}
+
+func (self *Cursor) begin() (uint64, error) {
+	offset, err := self.Position()
+	if err != nil {
+		return 0, err
+	}
+
+	self.last = offset
+
+	return offset, nil
+}
+
+func (self *Cursor) read(field string, n any) (uint64, error) {
+	offset, err := self.begin()
+	if err != nil {
+		return 0, err
+	}
+
+	position, err := ReadNumberFactory(self.Stream, n, self.Order)
+	return position, self.wrap(field, offset, err)
+}
+
+func (self *Cursor) Move(offset int64, whence SeekMode) (uint64, error) {
+	current, err := self.Position()
+	if err != nil {
+		return 0, err
+	}
+
+	position, err := Seek(self.Stream, offset, whence)
+	return position, self.wrap("", current, err)
+}
+
+func (self *Cursor) ReadBytes(field string, b []byte) (uint64, error) {
+	offset, err := self.begin()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := ReadBytes(self.Stream, b)
+	return n, self.wrap(field, offset, err)
+}
+
+func (self *Cursor) ReadString(field string, str *string, size uint64) (uint64, error) {
+	offset, err := self.begin()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := ReadString(self.Stream, str, size)
+	return n, self.wrap(field, offset, err)
+}
+
+func (self *Cursor) ReadUint8(field string, n *uint8) (uint64, error) {
+	return self.read(field, n)
+}
+
+func (self *Cursor) ReadInt8(field string, n *int8) (uint64, error) {
+	return self.read(field, n)
+}
+
+func (self *Cursor) ReadUint16(field string, n *uint16) (uint64, error) {
+	return self.read(field, n)
+}
+
+func (self *Cursor) ReadInt16(field string, n *int16) (uint64, error) {
+	return self.read(field, n)
+}
+
+func (self *Cursor) ReadUint32(field string, n *uint32) (uint64, error) {
+	return self.read(field, n)
+}
+
+func (self *Cursor) ReadInt32(field string, n *int32) (uint64, error) {
+	return self.read(field, n)
+}
+
+func (self *Cursor) ReadUint64(field string, n *uint64) (uint64, error) {
+	return self.read(field, n)
+}
+
+func (self *Cursor) ReadInt64(field string, n *int64) (uint64, error) {
+	return self.read(field, n)
+}
+
+func (self *Cursor) ReadFloat32(field string, n *float32) (uint64, error) {
+	return self.read(field, n)
+}
+
+func (self *Cursor) ReadFloat64(field string, n *float64) (uint64, error) {
+	return self.read(field, n)
+}
+
+func NewCursor(stream io.ReadSeeker, order binary.ByteOrder, format string) *Cursor {
+	return &Cursor{
+		Stream: stream,
+		Order:  order,
+		Format: format,
+		last:   0,
+		size:   nil,
+	}
+}
+
+func NewCursorLE(stream io.ReadSeeker, format string) *Cursor {
+	return NewCursor(stream, binary.LittleEndian, format)
+}
@@ -0,0 +1,64 @@
+package buffer_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursor(t *testing.T) {
+	data := []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06}
+
+	t.Run("endianness", func(t *testing.T) {
+		n := uint32(0)
+
+		le := buffer.NewCursorLE(bytes.NewReader(data), "TEST")
+		if _, err := le.ReadUint32("n", &n); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, uint32(0x04030201), n)
+
+		be := buffer.NewCursor(bytes.NewReader(data), binary.BigEndian, "TEST")
+		if _, err := be.ReadUint32("n", &n); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, uint32(0x01020304), n)
+	})
+
+	t.Run("offset error", func(t *testing.T) {
+		cursor := buffer.NewCursorLE(io.NewSectionReader(bytes.NewReader(data), 0, int64(len(data))), "TEST")
+		if _, err := cursor.Move(4, buffer.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+
+		n := uint32(0)
+		_, err := cursor.ReadUint32("n", &n)
+
+		var parseErr *buffer.ParseError
+		assert.True(t, errors.As(err, &parseErr))
+		assert.Equal(t, "TEST", parseErr.Format)
+		assert.Equal(t, "n", parseErr.Field)
+		assert.Equal(t, uint64(4), parseErr.Offset)
+		assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		cursor := buffer.NewCursorLE(bytes.NewReader(data), "TEST")
+		if _, err := cursor.Move(2, buffer.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+
+		n := uint32(0)
+		if _, err := cursor.ReadUint32("signature", &n); err != nil {
+			t.Fatal(err)
+		}
+
+		err := cursor.Mismatch("signature", uint32(0x54534554), n)
+		assert.Equal(t, "TEST signature at offset 0x2: expected 0x54534554, found 0x6050403", err.Error())
+	})
+}
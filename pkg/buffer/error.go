@@ -0,0 +1,72 @@
+package buffer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrOutOfBounds = errors.New("out of bounds")
+	ErrInvalid     = errors.New("invalid value")
+)
+
+// NOTE: Expected and Found only set for value mismatch (ex: signature), Err only set for read or seek failure
+type ParseError struct {
+	Format   string
+	Field    string
+	Offset   uint64
+	Expected any
+	Found    any
+	Err      error
+}
+
+func (self *ParseError) Error() string {
+	where := self.Format
+	if self.Field != "" {
+		where = fmt.Sprintf("%s %s", where, self.Field)
+	}
+
+	if self.Err != nil {
+		return fmt.Sprintf("%s at offset 0x%X: %s", where, self.Offset, self.Err)
+	}
+
+	return fmt.Sprintf("%s at offset 0x%X: expected %s, found %s", where, self.Offset, formatValue(self.Expected), formatValue(self.Found))
+}
+
+func (self *ParseError) Unwrap() error {
+	return self.Err
+}
+
+// NOTE: multi line description for UI
+func (self *ParseError) Details() string {
+	lines := []string{
+		fmt.Sprintf("Format: %s", self.Format),
+	}
+
+	if self.Field != "" {
+		lines = append(lines, fmt.Sprintf("Field: %s", self.Field))
+	}
+
+	lines = append(lines, fmt.Sprintf("Offset: 0x%X (%d)", self.Offset, self.Offset))
+
+	if self.Err != nil {
+		lines = append(lines, fmt.Sprintf("Error: %s", self.Err))
+	} else {
+		lines = append(lines, fmt.Sprintf("Expected: %s", formatValue(self.Expected)))
+		lines = append(lines, fmt.Sprintf("Found: %s", formatValue(self.Found)))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("0x%X", v)
+	case string:
+		return fmt.Sprintf("%q", v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -14,7 +14,7 @@ const (
 	SeekCurrent SeekMode = io.SeekCurrent
 )
 
-func check(stream io.ReadWriteSeeker) error {
+func check(stream any) error {
 	if stream == nil {
 		return fmt.Errorf("Stream is nil")
 	}
@@ -22,7 +22,7 @@ func check(stream io.ReadWriteSeeker) error {
 	return nil
 }
 
-func Seek(stream io.ReadWriteSeeker, offset int64, whence SeekMode) (uint64, error) {
+func Seek(stream io.Seeker, offset int64, whence SeekMode) (uint64, error) {
 	if err := check(stream); err != nil {
 		return 0, err
 	}
@@ -34,7 +34,7 @@ func Seek(stream io.ReadWriteSeeker, offset int64, whence SeekMode) (uint64, err
 	return uint64(position), nil
 }
 
-func Position(stream io.ReadWriteSeeker, position *uint64) (uint64, error) {
+func Position(stream io.Seeker, position *uint64) (uint64, error) {
 	if err := check(stream); err != nil {
 		return 0, err
 	}
@@ -49,19 +49,19 @@ func Position(stream io.ReadWriteSeeker, position *uint64) (uint64, error) {
 	return uint64(pos), nil
 }
 
-func ReadBytes(stream io.ReadWriteSeeker, b []byte) (uint64, error) {
+func ReadBytes(stream io.ReadSeeker, b []byte) (uint64, error) {
 	if err := check(stream); err != nil {
 		return 0, err
 	}
 
-	position, err := stream.Read(b)
+	n, err := io.ReadFull(stream, b)
 	if err != nil {
 		return 0, err
 	}
-	return uint64(position), nil
+	return uint64(n), nil
 }
 
-func ReadString(stream io.ReadWriteSeeker, str *string, size uint64) (uint64, error) {
+func ReadString(stream io.ReadSeeker, str *string, size uint64) (uint64, error) {
 	bytes := make([]byte, size)
 	position, err := ReadBytes(stream, bytes)
 	if err != nil {
@@ -73,7 +73,7 @@ func ReadString(stream io.ReadWriteSeeker, str *string, size uint64) (uint64, er
 	return position, nil
 }
 
-func ReadNumberFactory(stream io.ReadWriteSeeker, n any, endian binary.ByteOrder) (uint64, error) {
+func ReadNumberFactory(stream io.ReadSeeker, n any, endian binary.ByteOrder) (uint64, error) {
 	if err := check(stream); err != nil {
 		return 0, err
 	}
@@ -91,79 +91,79 @@ func ReadNumberFactory(stream io.ReadWriteSeeker, n any, endian binary.ByteOrder
 	return position, nil
 }
 
-func ReadUint8(stream io.ReadWriteSeeker, n *uint8) (uint64, error) {
+func ReadUint8(stream io.ReadSeeker, n *uint8) (uint64, error) {
 	return ReadNumberFactory(stream, n, binary.LittleEndian)
 }
 
-func ReadInt8(stream io.ReadWriteSeeker, n *int8) (uint64, error) {
+func ReadInt8(stream io.ReadSeeker, n *int8) (uint64, error) {
 	return ReadNumberFactory(stream, n, binary.LittleEndian)
 }
 
-func ReadUint16LE(stream io.ReadWriteSeeker, n *uint16) (uint64, error) {
+func ReadUint16LE(stream io.ReadSeeker, n *uint16) (uint64, error) {
 	return ReadNumberFactory(stream, n, binary.LittleEndian)
 }
 
-func ReadUint16BE(stream io.ReadWriteSeeker, n *uint16) (uint64, error) {
+func ReadUint16BE(stream io.ReadSeeker, n *uint16) (uint64, error) {
 	return ReadNumberFactory(stream, n, binary.BigEndian)
 }
 
-func ReadInt16LE(stream io.ReadWriteSeeker, n *int16) (uint64, error) {
+func ReadInt16LE(stream io.ReadSeeker, n *int16) (uint64, error) {
 	return ReadNumberFactory(stream, n, binary.LittleEndian)
 }
 
-func ReadInt16BE(stream io.ReadWriteSeeker, n *int16) (uint64, error) {
+func ReadInt16BE(stream io.ReadSeeker, n *int16) (uint64, error) {
 	return ReadNumberFactory(stream, n, binary.BigEndian)
 }
 
-func ReadUint32LE(stream io.ReadWriteSeeker, n *uint32) (uint64, error) {
+func ReadUint32LE(stream io.ReadSeeker, n *uint32) (uint64, error) {
 	return ReadNumberFactory(stream, n, binary.LittleEndian)
 }
 
-func ReadUint32BE(stream io.ReadWriteSeeker, n *uint32) (uint64, error) {
+func ReadUint32BE(stream io.ReadSeeker, n *uint32) (uint64, error) {
 	return ReadNumberFactory(stream, n, binary.BigEndian)
 }
 
-func ReadInt32LE(stream io.ReadWriteSeeker, n *int32) (uint64, error) {
+func ReadInt32LE(stream io.ReadSeeker, n *int32) (uint64, error) {
 	return ReadNumberFactory(stream, n, binary.LittleEndian)
 }
 
-func ReadInt32BE(stream io.ReadWriteSeeker, n *int32) (uint64, error) {
+func ReadInt32BE(stream io.ReadSeeker, n *int32) (uint64, error) {
 	return ReadNumberFactory(stream, n, binary.BigEndian)
 }
 
-func ReadUint64LE(stream io.ReadWriteSeeker, n *uint64) (uint64, error) {
+func ReadUint64LE(stream io.ReadSeeker, n *uint64) (uint64, error) {
 	return ReadNumberFactory(stream, n, binary.LittleEndian)
 }
 
-func ReadUint64BE(stream io.ReadWriteSeeker, n *uint64) (uint64, error) {
+func ReadUint64BE(stream io.ReadSeeker, n *uint64) (uint64, error) {
 	return ReadNumberFactory(stream, n, binary.BigEndian)
 }
 
-func ReadInt64LE(stream io.ReadWriteSeeker, n *int64) (uint64, error) {
+func ReadInt64LE(stream io.ReadSeeker, n *int64) (uint64, error) {
 	return ReadNumberFactory(stream, n, binary.LittleEndian)
 }
 
-func ReadInt64BE(stream io.ReadWriteSeeker, n *int64) (uint64, error) {
+func ReadInt64BE(stream io.ReadSeeker, n *int64) (uint64, error) {
 	return ReadNumberFactory(stream, n, binary.BigEndian)
 }
 
-func ReadFloat32LE(stream io.ReadWriteSeeker, n *float32) (uint64, error) {
+func ReadFloat32LE(stream io.ReadSeeker, n *float32) (uint64, error) {
 	return ReadNumberFactory(stream, n, binary.LittleEndian)
 }
 
-func ReadFloat32BE(stream io.ReadWriteSeeker, n *float32) (uint64, error) {
+func ReadFloat32BE(stream io.ReadSeeker, n *float32) (uint64, error) {
 	return ReadNumberFactory(stream, n, binary.BigEndian)
 }
 
-func ReadFloat64LE(stream io.ReadWriteSeeker, n *float64) (uint64, error) {
+func ReadFloat64LE(stream io.ReadSeeker, n *float64) (uint64, error) {
 	return ReadNumberFactory(stream, n, binary.LittleEndian)
 }
 
-func ReadFloat64BE(stream io.ReadWriteSeeker, n *float64) (uint64, error) {
+func ReadFloat64BE(stream io.ReadSeeker, n *float64) (uint64, error) {
 	return ReadNumberFactory(stream, n, binary.BigEndian)
 }
 
-func WriteBytes(stream io.ReadWriteSeeker, b []byte) (uint64, error) {
+func WriteBytes(stream io.WriteSeeker, b []byte) (uint64, error) {
 	if err := check(stream); err != nil {
 		return 0, err
 	}
@@ -180,11 +180,11 @@ func WriteBytes(stream io.ReadWriteSeeker, b []byte) (uint64, error) {
 	return position, nil
 }
 
-func WriteString(stream io.ReadWriteSeeker, str string) (uint64, error) {
+func WriteString(stream io.WriteSeeker, str string) (uint64, error) {
 	return WriteBytes(stream, []byte(str))
 }
 
-func WriteNumberFactory(stream io.ReadWriteSeeker, n any, endian binary.ByteOrder) (uint64, error) {
+func WriteNumberFactory(stream io.WriteSeeker, n any, endian binary.ByteOrder) (uint64, error) {
 	if err := check(stream); err != nil {
 		return 0, err
 	}
@@ -202,74 +202,74 @@ func WriteNumberFactory(stream io.ReadWriteSeeker, n any, endian binary.ByteOrde
 	return position, nil
 }
 
-func WriteUint8(stream io.ReadWriteSeeker, n uint8) (uint64, error) {
+func WriteUint8(stream io.WriteSeeker, n uint8) (uint64, error) {
 	return WriteNumberFactory(stream, n, binary.LittleEndian)
 }
 
-func WriteInt8(stream io.ReadWriteSeeker, n int8) (uint64, error) {
+func WriteInt8(stream io.WriteSeeker, n int8) (uint64, error) {
 	return WriteNumberFactory(stream, n, binary.LittleEndian)
 }
 
-func WriteUint16LE(stream io.ReadWriteSeeker, n uint16) (uint64, error) {
+func WriteUint16LE(stream io.WriteSeeker, n uint16) (uint64, error) {
 	return WriteNumberFactory(stream, n, binary.LittleEndian)
 }
 
-func WriteUint16BE(stream io.ReadWriteSeeker, n uint16) (uint64, error) {
+func WriteUint16BE(stream io.WriteSeeker, n uint16) (uint64, error) {
 	return WriteNumberFactory(stream, n, binary.BigEndian)
 }
 
-func WriteInt16LE(stream io.ReadWriteSeeker, n int16) (uint64, error) {
+func WriteInt16LE(stream io.WriteSeeker, n int16) (uint64, error) {
 	return WriteNumberFactory(stream, n, binary.LittleEndian)
 }
 
-func WriteInt16BE(stream io.ReadWriteSeeker, n int16) (uint64, error) {
+func WriteInt16BE(stream io.WriteSeeker, n int16) (uint64, error) {
 	return WriteNumberFactory(stream, n, binary.BigEndian)
 }
 
-func WriteUint32LE(stream io.ReadWriteSeeker, n uint32) (uint64, error) {
+func WriteUint32LE(stream io.WriteSeeker, n uint32) (uint64, error) {
 	return WriteNumberFactory(stream, n, binary.LittleEndian)
 }
 
-func WriteUint32BE(stream io.ReadWriteSeeker, n uint32) (uint64, error) {
+func WriteUint32BE(stream io.WriteSeeker, n uint32) (uint64, error) {
 	return WriteNumberFactory(stream, n, binary.BigEndian)
 }
 
-func WriteInt32LE(stream io.ReadWriteSeeker, n int32) (uint64, error) {
+func WriteInt32LE(stream io.WriteSeeker, n int32) (uint64, error) {
 	return WriteNumberFactory(stream, n, binary.LittleEndian)
 }
 
-func WriteInt32BE(stream io.ReadWriteSeeker, n int32) (uint64, error) {
+func WriteInt32BE(stream io.WriteSeeker, n int32) (uint64, error) {
 	return WriteNumberFactory(stream, n, binary.BigEndian)
 }
 
-func WriteUint64LE(stream io.ReadWriteSeeker, n uint64) (uint64, error) {
+func WriteUint64LE(stream io.WriteSeeker, n uint64) (uint64, error) {
 	return WriteNumberFactory(stream, n, binary.LittleEndian)
 }
 
-func WriteUint64BE(stream io.ReadWriteSeeker, n uint64) (uint64, error) {
+func WriteUint64BE(stream io.WriteSeeker, n uint64) (uint64, error) {
 	return WriteNumberFactory(stream, n, binary.BigEndian)
 }
 
-func WriteInt64LE(stream io.ReadWriteSeeker, n int64) (uint64, error) {
+func WriteInt64LE(stream io.WriteSeeker, n int64) (uint64, error) {
 	return WriteNumberFactory(stream, n, binary.LittleEndian)
 }
 
-func WriteInt64BE(stream io.ReadWriteSeeker, n int64) (uint64, error) {
+func WriteInt64BE(stream io.WriteSeeker, n int64) (uint64, error) {
 	return WriteNumberFactory(stream, n, binary.BigEndian)
 }
 
-func WriteFloat32LE(stream io.ReadWriteSeeker, n float32) (uint64, error) {
+func WriteFloat32LE(stream io.WriteSeeker, n float32) (uint64, error) {
 	return WriteNumberFactory(stream, n, binary.LittleEndian)
 }
 
-func WriteFloat32BE(stream io.ReadWriteSeeker, n float32) (uint64, error) {
+func WriteFloat32BE(stream io.WriteSeeker, n float32) (uint64, error) {
 	return WriteNumberFactory(stream, n, binary.BigEndian)
 }
 
-func WriteFloat64LE(stream io.ReadWriteSeeker, n float64) (uint64, error) {
+func WriteFloat64LE(stream io.WriteSeeker, n float64) (uint64, error) {
 	return WriteNumberFactory(stream, n, binary.LittleEndian)
 }
 
-func WriteFloat64BE(stream io.ReadWriteSeeker, n float64) (uint64, error) {
+func WriteFloat64BE(stream io.WriteSeeker, n float64) (uint64, error) {
 	return WriteNumberFactory(stream, n, binary.BigEndian)
 }
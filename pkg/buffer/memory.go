@@ -0,0 +1,74 @@
+package buffer
+
+import (
+	"fmt"
+	"io"
+)
+
+// NOTE: in memory io.ReadWriteSeeker, write past the end grow the buffer
+type Memory struct {
+	data     []byte
+	position int64
+}
+
+func (self *Memory) Read(b []byte) (int, error) {
+	if self.position >= int64(len(self.data)) {
+		return 0, io.EOF
+	}
+
+	n := copy(b, self.data[self.position:])
+	self.position += int64(n)
+
+	return n, nil
+}
+
+func (self *Memory) Write(b []byte) (int, error) {
+	end := self.position + int64(len(b))
+	if end > int64(len(self.data)) {
+		if end > int64(cap(self.data)) {
+			data := make([]byte, end, max(end, int64(cap(self.data))*2))
+			copy(data, self.data)
+			self.data = data
+		} else {
+			self.data = self.data[:end]
+		}
+	}
+
+	n := copy(self.data[self.position:], b)
+	self.position += int64(n)
+
+	return n, nil
+}
+
+func (self *Memory) Seek(offset int64, whence int) (int64, error) {
+	position := int64(0)
+	switch whence {
+	case io.SeekStart:
+		position = offset
+	case io.SeekCurrent:
+		position = self.position + offset
+	case io.SeekEnd:
+		position = int64(len(self.data)) + offset
+	default:
+		return 0, fmt.Errorf("Seek whence %d not valid", whence)
+	}
+
+	if position < 0 {
+		return 0, fmt.Errorf("Seek to negative position %d", position)
+	}
+
+	self.position = position
+
+	return position, nil
+}
+
+func (self *Memory) Bytes() []byte {
+	return self.data
+}
+
+func NewMemory(data []byte) *Memory {
+	return &Memory{
+		data:     data,
+		position: 0,
+	}
+}
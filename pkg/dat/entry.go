@@ -6,4 +6,5 @@ type Entry struct {
 	Size   uint32 `json:"size"`
 	Offset uint32 `json:"offset"`
 	IsNull bool   `json:"is_null"`
+	Data   []byte `json:"-"` // NOTE: used instead of source when not nil
 }
@@ -0,0 +1,40 @@
+package dat_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/anasrar/chihuahua/internal/testutils"
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/dat"
+)
+
+func FuzzFromStream(f *testing.F) {
+	d := dat.New()
+	d.AddEntryFromBytesWithType([]byte("entry"), "TM3\x00")
+	d.AddNullEntry()
+	d.AddEntryFromBytesWithType([]byte("another entry"), "SCR\x00")
+
+	memory := buffer.NewMemory(nil)
+	if err := d.PackToStream(context.Background(), memory, testutils.Noop, testutils.Noop); err != nil {
+		f.Fatal(err)
+	}
+
+	f.Add(memory.Bytes())
+	f.Add([]byte{})
+	f.Add([]byte{0xFF, 0xFF, 0xFF, 0xFF})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		d := dat.New()
+		if err := dat.FromStream(d, bytes.NewReader(data)); err != nil {
+			return
+		}
+
+		for _, entry := range d.Entries {
+			if uint64(entry.Offset)+uint64(entry.Size) > uint64(len(data)) {
+				t.Fatalf("entry 0x%X+%d is outside of %d bytes", entry.Offset, entry.Size, len(data))
+			}
+		}
+	})
+}
@@ -7,9 +7,11 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/iso9660"
 	"github.com/anasrar/chihuahua/pkg/utils"
 )
 
@@ -21,20 +23,38 @@ type Dat struct {
 	Offset     uint32   `json:"offset"`
 	Size       uint32   `json:"size"`
 	EntryTotal uint32   `json:"entry_total"`
+	HeaderSize uint32   `json:"header_size"`
+	Alignment  uint32   `json:"alignment"`
+	Order      []int    `json:"order"`
 	Entries    []*Entry `json:"entries"`
 }
 
-func (self *Dat) unmarshal(source string, stream io.ReadWriteSeeker) error {
-	if self.Size == 0 {
-		size, _ := buffer.Seek(stream, 0, buffer.SeekEnd)
-		self.Size = uint32(size) - self.Offset
+func (self *Dat) unmarshal(source string, stream io.ReadSeeker) error {
+	cursor := buffer.NewCursorLE(stream, "DAT")
+
+	size, err := cursor.Size()
+	if err != nil {
+		return err
+	}
+
+	if self.Size == 0 && uint64(self.Offset) <= size {
+		self.Size = uint32(size - uint64(self.Offset))
+	}
+
+	if err := cursor.Within("Size", uint64(self.Offset), uint64(self.Size)); err != nil {
+		return err
+	}
+
+	if _, err := cursor.Move(int64(self.Offset), buffer.SeekStart); err != nil {
+		return err
 	}
 
-	if _, err := buffer.Seek(stream, int64(self.Offset), buffer.SeekStart); err != nil {
+	if _, err := cursor.ReadUint32("EntryTotal", &self.EntryTotal); err != nil {
 		return err
 	}
 
-	if _, err := buffer.ReadUint32LE(stream, &self.EntryTotal); err != nil {
+	// NOTE: offset (uint32) + type (char[4]) for every entry
+	if err := cursor.Require("EntryTotal", uint64(self.EntryTotal)*8); err != nil {
 		return err
 	}
 
@@ -47,12 +67,16 @@ func (self *Dat) unmarshal(source string, stream io.ReadWriteSeeker) error {
 			IsNull: false,
 		}
 
-		if _, err := buffer.ReadUint32LE(stream, &entry.Offset); err != nil {
+		if _, err := cursor.ReadUint32("entry.Offset", &entry.Offset); err != nil {
 			return err
 		}
 
 		entry.IsNull = entry.Offset == 0
 
+		if entry.Offset > self.Size {
+			return cursor.Invalid("entry.Offset", "offset 0x%X is outside of %d bytes container", entry.Offset, self.Size)
+		}
+
 		if !entry.IsNull {
 			entry.Offset += self.Offset
 		}
@@ -61,97 +85,223 @@ func (self *Dat) unmarshal(source string, stream io.ReadWriteSeeker) error {
 	}
 
 	for _, entry := range self.Entries {
-		if _, err := buffer.ReadString(stream, &entry.Type, EntryTypeLength); err != nil {
+		if _, err := cursor.ReadString("entry.Type", &entry.Type, EntryTypeLength); err != nil {
 			return err
 		}
 	}
 
+	// NOTE: entry data is not always stored in entry order, size is distance to the next stored entry
+	self.Order = []int{}
 	for i, entry := range self.Entries {
-		if entry.IsNull {
-			continue
+		if !entry.IsNull {
+			self.Order = append(self.Order, i)
 		}
-
-		if i == int(self.EntryTotal-1) {
-			entry.Size = (self.Offset + self.Size) - entry.Offset
-		} else {
-			index := i + 1
-			offset := uint32(0)
-			for {
-				nextEntry := self.Entries[index]
-				if !nextEntry.IsNull {
-					offset = nextEntry.Offset
-					break
-				}
-
-				if index == int(self.EntryTotal-1) {
-					offset = self.Size
-					break
-				}
-
-				index += 1
+	}
+	sort.SliceStable(self.Order, func(a, b int) bool {
+		return self.Entries[self.Order[a]].Offset < self.Entries[self.Order[b]].Offset
+	})
+
+	for i, index := range self.Order {
+		entry := self.Entries[index]
+		end := self.Offset + self.Size
+		for _, next := range self.Order[i+1:] {
+			if self.Entries[next].Offset > entry.Offset {
+				end = self.Entries[next].Offset
+				break
 			}
-
-			entry.Size = offset - entry.Offset
 		}
+
+		entry.Size = end - entry.Offset
 	}
 
+	// NOTE: keep header size and alignment so pack can reproduce original layout
+	offsets := []uint32{self.Size}
+	for _, index := range self.Order {
+		offsets = append(offsets, self.Entries[index].Offset-self.Offset)
+	}
+	if len(self.Order) > 0 {
+		self.HeaderSize = offsets[1]
+	}
+	self.Alignment = utils.Alignment(offsets, MaxAlignment)
+
 	return nil
 }
 
+func headerSize(entryTotal uint32) uint32 {
+	// NOTE: entry total (4) + offset (uint32) + type (char[4])
+	return 4 + entryTotal*4 + entryTotal*4
+}
+
+func pad(entryTotal uint32) uint32 {
+	return uint32(math.Ceil(float64(entryTotal*2+1)/8)*8) * 4
+}
+
+// NOTE: pack order follow original order when it cover every non null entry, otherwise entry order. Invalid order
+// from METADATA.json is reported by Metadata.Validate before pack
+func (self *Dat) packOrder() []int {
+	result := []int{}
+	for i, entry := range self.Entries {
+		if !entry.IsNull {
+			result = append(result, i)
+		}
+	}
+
+	if len(self.Order) != len(result) {
+		return result
+	}
+
+	seen := map[int]bool{}
+	for _, index := range self.Order {
+		if index < 0 || index >= len(self.Entries) || self.Entries[index].IsNull || seen[index] {
+			return result
+		}
+		seen[index] = true
+	}
+
+	return self.Order
+}
+
+// NOTE: source file is checked again because it can change after entry is added
+func entrySize(entry *Entry) (uint32, error) {
+	if entry.Data != nil {
+		return uint32(len(entry.Data)), nil
+	}
+
+	size, err := iso9660.Size(entry.Source)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint32(size), nil
+}
+
+func copyEntry(dst io.WriteSeeker, entry *Entry, alignment uint32) (uint32, error) {
+	written := int64(len(entry.Data))
+	if entry.Data != nil {
+		if _, err := buffer.WriteBytes(dst, entry.Data); err != nil {
+			return 0, err
+		}
+	} else {
+		entryFile, err := iso9660.Open(entry.Source)
+		if err != nil {
+			return 0, err
+		}
+		defer entryFile.Close()
+
+		if written, err = io.Copy(dst, entryFile); err != nil {
+			return 0, err
+		}
+	}
+
+	size := uint32(written)
+	if _, err := buffer.WriteBytes(dst, make([]byte, utils.AlignUp(size, alignment)-size)); err != nil {
+		return 0, err
+	}
+
+	return size, nil
+}
+
 func (self *Dat) Pack(
 	ctx context.Context,
 	output string,
 	onStart,
 	onDone func(total uint32, current uint32, name string),
 ) error {
-	pad := uint(math.Ceil(float64(self.EntryTotal*2+1)/8)*8) * 4
+	return self.PackWithWorkers(ctx, output, 0, onStart, onDone)
+}
 
+func (self *Dat) PackWithWorkers(
+	ctx context.Context,
+	output string,
+	workers int,
+	onStart,
+	onDone func(total uint32, current uint32, name string),
+) error {
 	packFile, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	if err != nil {
 		return err
 	}
 	defer packFile.Close()
 
-	if _, err := buffer.WriteBytes(packFile, make([]byte, pad)); err != nil {
-		return err
-	}
+	return self.PackToStreamWithWorkers(ctx, packFile, workers, onStart, onDone)
+}
 
-	for i, entry := range self.Entries {
-		if entry.IsNull {
-			continue
-		}
+func (self *Dat) PackToStream(
+	ctx context.Context,
+	packFile io.WriteSeeker,
+	onStart,
+	onDone func(total uint32, current uint32, name string),
+) error {
+	return self.PackToStreamWithWorkers(ctx, packFile, 0, onStart, onDone)
+}
 
-		onStart(self.EntryTotal, uint32(i+1), utils.Basename(entry.Source))
-		position := uint64(0)
-		if _, err := buffer.Position(packFile, &position); err != nil {
-			return err
-		}
-		entry.Offset = uint32(position)
+// NOTE: entry offset is computed before writing so every entry can be written at the same time,
+// stream without io.WriterAt (Memory) is written by one worker
+func (self *Dat) PackToStreamWithWorkers(
+	ctx context.Context,
+	packFile io.WriteSeeker,
+	workers int,
+	onStart,
+	onDone func(total uint32, current uint32, name string),
+) error {
+	// NOTE: use original header size when available, otherwise use default padding
+	p := pad(self.EntryTotal)
+	if self.HeaderSize >= headerSize(self.EntryTotal) {
+		p = self.HeaderSize
+	}
 
-		entryFile, err := os.Open(entry.Source)
+	order := self.packOrder()
+	position := p
+	for _, i := range order {
+		entry := self.Entries[i]
+
+		size, err := entrySize(entry)
 		if err != nil {
 			return err
 		}
-		defer entryFile.Close()
 
-		buf := make([]byte, entry.Size)
+		entry.Offset = position
+		entry.Size = size
+		position += utils.AlignUp(size, self.Alignment)
+	}
+
+	if _, err := buffer.WriteBytes(packFile, make([]byte, p)); err != nil {
+		return err
+	}
+
+	writerAt, parallel := packFile.(io.WriterAt)
+	if !parallel {
+		workers = 1
+	}
+
+	prog := progress{onStart: onStart, onDone: onDone}
+	if err := work(ctx, workers, order, func(i int) error {
+		entry := self.Entries[i]
+		name := utils.Basename(entry.Source)
+
+		prog.start(self.EntryTotal, uint32(i+1), name)
 
-		if _, err := entryFile.Read(buf); err != nil {
+		var dst io.WriteSeeker = packFile
+		if parallel {
+			dst = io.NewOffsetWriter(writerAt, int64(entry.Offset))
+		} else if _, err := buffer.Seek(packFile, int64(entry.Offset), buffer.SeekStart); err != nil {
 			return err
 		}
 
-		if _, err := packFile.Write(buf); err != nil {
+		size, err := copyEntry(dst, entry, self.Alignment)
+		if err != nil {
 			return err
 		}
 
-		onDone(self.EntryTotal, uint32(i+1), utils.Basename(entry.Source))
-
-		select {
-		case <-ctx.Done():
-			return fmt.Errorf("Canceled")
-		default:
+		if size != entry.Size {
+			return fmt.Errorf("Entry %d size changed from %d to %d bytes while packing", i, entry.Size, size)
 		}
 
+		prog.done(self.EntryTotal, uint32(i+1), name)
+
+		return nil
+	}); err != nil {
+		return err
 	}
 
 	if _, err := buffer.Seek(packFile, 0, buffer.SeekStart); err != nil {
@@ -177,63 +327,120 @@ func (self *Dat) Pack(
 	return nil
 }
 
+func (self *Dat) unpackEntry(sourceFile io.ReaderAt, entry *Entry, target string) error {
+	unpackFile, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer unpackFile.Close()
+
+	if _, err := io.Copy(unpackFile, io.NewSectionReader(sourceFile, int64(entry.Offset), int64(entry.Size))); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (self *Dat) Unpack(
 	ctx context.Context,
 	dir string,
 	onStart,
 	onDone func(total uint32, current uint32, name string),
+) error {
+	return self.UnpackWithWorkers(ctx, dir, 0, onStart, onDone)
+}
+
+// NOTE: source file is opened once and shared by every worker, section reader use ReadAt so it is safe for concurrent use
+func (self *Dat) UnpackWithWorkers(
+	ctx context.Context,
+	dir string,
+	workers int,
+	onStart,
+	onDone func(total uint32, current uint32, name string),
 ) error {
 	total := len(self.Entries)
+	indices := []int{}
+	sources := map[string]iso9660.File{}
+	defer func() {
+		for _, sourceFile := range sources {
+			sourceFile.Close()
+		}
+	}()
+
 	for i, entry := range self.Entries {
 		if entry.IsNull {
 			continue
 		}
+		indices = append(indices, i)
 
-		normalizeType := utils.FilterUnprintableString(entry.Type)
-		filename := fmt.Sprintf("%s_%03d.%s", normalizeType, i, strings.ToLower(normalizeType))
-
-		onStart(uint32(total), uint32(i+1), filename)
-
-		target := filepath.Join(dir, normalizeType)
+		target := filepath.Join(dir, utils.FilterUnprintableString(entry.Type))
 		if err := os.MkdirAll(target, os.ModePerm); err != nil {
 			return err
 		}
 
-		sourceFile, err := os.Open(entry.Source)
+		if _, found := sources[entry.Source]; found {
+			continue
+		}
+
+		sourceFile, err := iso9660.Open(entry.Source)
 		if err != nil {
 			return err
 		}
-		defer sourceFile.Close()
+		sources[entry.Source] = sourceFile
+	}
+
+	prog := progress{onStart: onStart, onDone: onDone}
+	return work(ctx, workers, indices, func(i int) error {
+		entry := self.Entries[i]
 
-		if _, err := sourceFile.Seek(int64(entry.Offset), io.SeekStart); err != nil {
+		normalizeType := utils.FilterUnprintableString(entry.Type)
+		filename := fmt.Sprintf("%s_%03d.%s", normalizeType, i, strings.ToLower(normalizeType))
+
+		prog.start(uint32(total), uint32(i+1), filename)
+
+		if err := self.unpackEntry(sources[entry.Source], entry, filepath.Join(dir, normalizeType, filename)); err != nil {
 			return err
 		}
 
-		b := make([]byte, entry.Size)
+		prog.done(uint32(total), uint32(i+1), filename)
 
-		if _, err := sourceFile.Read(b); err != nil {
-			return err
+		return nil
+	})
+}
+
+// NOTE: read every non null entry into data so parsed dat can be packed again without the source file
+func (self *Dat) Load(stream io.ReadSeeker) error {
+	cursor := buffer.NewCursorLE(stream, "DAT")
+
+	for _, entry := range self.Entries {
+		if entry.IsNull || entry.Data != nil {
+			continue
 		}
 
-		unpackFile, err := os.OpenFile(filepath.Join(target, filename), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
-		if err != nil {
+		if _, err := cursor.Move(int64(entry.Offset), buffer.SeekStart); err != nil {
 			return err
 		}
-		defer unpackFile.Close()
 
-		if _, err := unpackFile.Write(b); err != nil {
+		data := make([]byte, entry.Size)
+		if _, err := cursor.ReadBytes("entry.Data", data); err != nil {
 			return err
 		}
+		entry.Data = data
+	}
 
-		onDone(uint32(total), uint32(i+1), filename)
+	return nil
+}
 
-		select {
-		case <-ctx.Done():
-			return fmt.Errorf("Canceled")
-		default:
-		}
+func (self *Dat) SetEntryData(index int, data []byte) error {
+	if index < 0 || index >= len(self.Entries) {
+		return fmt.Errorf("Entry index %d is out of range", index)
 	}
 
+	entry := self.Entries[index]
+	entry.Data = data
+	entry.Size = uint32(len(data))
+	entry.IsNull = len(data) == 0
+
 	return nil
 }
 
@@ -256,7 +463,7 @@ func (self *Dat) AddEntryFromPathWithType(
 	source string,
 	t string,
 ) error {
-	file, err := os.Open(source)
+	file, err := iso9660.Open(source)
 	if err != nil {
 		return err
 	}
@@ -283,17 +490,49 @@ func (self *Dat) AddEntryFromPathWithType(
 	return nil
 }
 
+func (self *Dat) AddEntryFromBytesWithType(
+	data []byte,
+	t string,
+) {
+	self.Entries = append(
+		self.Entries,
+		&Entry{
+			Source: "",
+			Type:   t,
+			Size:   uint32(len(data)),
+			Offset: 0,
+			IsNull: len(data) == 0,
+			Data:   data,
+		},
+	)
+
+	self.EntryTotal += 1
+}
+
 func New() *Dat {
 	return &Dat{
 		Offset:     0,
 		Size:       0,
 		EntryTotal: 0,
+		HeaderSize: 0,
+		Alignment:  0,
+		Order:      []int{},
 		Entries:    []*Entry{},
 	}
 }
 
+func FromStreamWithOffsetSize(dat *Dat, stream io.ReadSeeker, offset uint32, size uint32) error {
+	dat.Offset = offset
+	dat.Size = size
+	return dat.unmarshal("", stream)
+}
+
+func FromStream(dat *Dat, stream io.ReadSeeker) error {
+	return FromStreamWithOffsetSize(dat, stream, 0, 0)
+}
+
 func FromPathWithOffsetSize(dat *Dat, filePath string, offset uint32, size uint32) error {
-	file, err := os.Open(filePath)
+	file, err := iso9660.Open(filePath)
 	if err != nil {
 		return err
 	}
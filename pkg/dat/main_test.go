@@ -1,16 +1,25 @@
 package dat_test
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/anasrar/chihuahua/internal/testutils"
+	"github.com/anasrar/chihuahua/pkg/buffer"
 	"github.com/anasrar/chihuahua/pkg/dat"
+	"github.com/anasrar/chihuahua/pkg/utils"
 	"github.com/stretchr/testify/assert"
 )
 
 func Test(t *testing.T) {
 	t.Run("pl00.dat", func(t *testing.T) {
 		d := dat.New()
-		if err := dat.FromPath(d, "../../samples/pl00.dat"); err != nil {
+		if err := dat.FromPath(d, testutils.Sample(t, "pl00.dat")); err != nil {
 			t.Fatal(err)
 		}
 
@@ -20,7 +29,7 @@ func Test(t *testing.T) {
 
 	t.Run("ema0.dat", func(t *testing.T) {
 		d := dat.New()
-		if err := dat.FromPath(d, "../../samples/ema0.dat"); err != nil {
+		if err := dat.FromPath(d, testutils.Sample(t, "ema0.dat")); err != nil {
 			t.Fatal(err)
 		}
 
@@ -30,7 +39,7 @@ func Test(t *testing.T) {
 
 	t.Run("ema4.dat", func(t *testing.T) {
 		d := dat.New()
-		if err := dat.FromPath(d, "../../samples/ema4.dat"); err != nil {
+		if err := dat.FromPath(d, testutils.Sample(t, "ema4.dat")); err != nil {
 			t.Fatal(err)
 		}
 
@@ -40,7 +49,7 @@ func Test(t *testing.T) {
 
 	t.Run("ema6.dat", func(t *testing.T) {
 		d := dat.New()
-		if err := dat.FromPath(d, "../../samples/ema6.dat"); err != nil {
+		if err := dat.FromPath(d, testutils.Sample(t, "ema6.dat")); err != nil {
 			t.Fatal(err)
 		}
 
@@ -50,7 +59,7 @@ func Test(t *testing.T) {
 
 	t.Run("r006.dat", func(t *testing.T) {
 		d := dat.New()
-		if err := dat.FromPath(d, "../../samples/r006.dat"); err != nil {
+		if err := dat.FromPath(d, testutils.Sample(t, "r006.dat")); err != nil {
 			t.Fatal(err)
 		}
 
@@ -60,7 +69,7 @@ func Test(t *testing.T) {
 
 	t.Run("r006.dat: SCP", func(t *testing.T) {
 		d := dat.New()
-		if err := dat.FromPathWithOffsetSize(d, "../../samples/r006.dat", 480, 1022720); err != nil {
+		if err := dat.FromPathWithOffsetSize(d, testutils.Sample(t, "r006.dat"), 480, 1022720); err != nil {
 			t.Fatal(err)
 		}
 
@@ -70,7 +79,7 @@ func Test(t *testing.T) {
 
 	t.Run("r100.dat", func(t *testing.T) {
 		d := dat.New()
-		if err := dat.FromPath(d, "../../samples/r100.dat"); err != nil {
+		if err := dat.FromPath(d, testutils.Sample(t, "r100.dat")); err != nil {
 			t.Fatal(err)
 		}
 
@@ -78,3 +87,433 @@ func Test(t *testing.T) {
 		assert.Equal(t, uint32(0x28), uint32(len(d.Entries)))
 	})
 }
+
+func repack(t *testing.T, source string) string {
+	d := dat.New()
+	if err := dat.FromPath(d, source); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := d.Unpack(context.Background(), dir, testutils.Noop, testutils.Noop); err != nil {
+		t.Fatal(err)
+	}
+
+	p := dat.New()
+	p.HeaderSize = d.HeaderSize
+	p.Alignment = d.Alignment
+	p.Order = d.Order
+	for i, entry := range d.Entries {
+		if entry.IsNull {
+			p.AddNullEntry()
+			continue
+		}
+
+		normalizeType := utils.FilterUnprintableString(entry.Type)
+		name := fmt.Sprintf("%s_%03d.%s", normalizeType, i, strings.ToLower(normalizeType))
+		if err := p.AddEntryFromPathWithType(filepath.Join(dir, normalizeType, name), entry.Type); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	output := filepath.Join(dir, "OUTPUT.dat")
+	if err := p.Pack(context.Background(), output, testutils.Noop, testutils.Noop); err != nil {
+		t.Fatal(err)
+	}
+
+	return output
+}
+
+func TestPack(t *testing.T) {
+	t.Run("synthetic", func(t *testing.T) {
+		dir := t.TempDir()
+
+		p := dat.New()
+		p.Alignment = 64
+		for i, size := range []int{100, 0, 64, 33, 0} {
+			if size == 0 {
+				p.AddNullEntry()
+				continue
+			}
+
+			source := filepath.Join(dir, fmt.Sprintf("%d.bin", i))
+			if err := os.WriteFile(source, bytes.Repeat([]byte{uint8(i + 1)}, size), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.AddEntryFromPathWithType(source, "BIN\x00"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// NOTE: store entry data in reverse order
+		p.Order = []int{3, 2, 0}
+
+		source := filepath.Join(dir, "SOURCE.dat")
+		if err := p.Pack(context.Background(), source, testutils.Noop, testutils.Noop); err != nil {
+			t.Fatal(err)
+		}
+
+		d := dat.New()
+		if err := dat.FromPath(d, source); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, uint32(64), d.HeaderSize)
+		assert.Equal(t, uint32(64), d.Alignment)
+		assert.Equal(t, []int{3, 2, 0}, d.Order)
+		assert.Equal(t, uint32(192), d.Entries[0].Offset)
+		assert.Equal(t, uint32(128), d.Entries[0].Size)
+		assert.Equal(t, uint32(64), d.Entries[3].Offset)
+		assert.Equal(t, uint32(64), d.Entries[3].Size)
+		assert.True(t, d.Entries[4].IsNull)
+
+		assert.Nil(t, dat.Verify(repack(t, source), source))
+	})
+
+	for _, name := range []string{"pl00.dat", "ema0.dat", "ema4.dat", "ema6.dat", "r006.dat", "r100.dat"} {
+		t.Run(name, func(t *testing.T) {
+			source := testutils.Sample(t, name)
+
+			assert.Nil(t, dat.Verify(repack(t, source), source))
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	p := dat.New()
+	p.Alignment = 16
+	p.AddEntryFromBytesWithType(bytes.Repeat([]byte{1}, 32), "OMS\x00")
+	p.AddNullEntry()
+	p.AddEntryFromBytesWithType(bytes.Repeat([]byte{2}, 16), "EMS\x00")
+
+	memory := buffer.NewMemory(nil)
+	if err := p.PackToStream(context.Background(), memory, testutils.Noop, testutils.Noop); err != nil {
+		t.Fatal(err)
+	}
+	source := memory.Bytes()
+
+	d := dat.New()
+	if err := dat.FromStream(d, bytes.NewReader(source)); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Load(bytes.NewReader(source)); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("unchanged", func(t *testing.T) {
+		memory := buffer.NewMemory(nil)
+		if err := d.PackToStream(context.Background(), memory, testutils.Noop, testutils.Noop); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, source, memory.Bytes())
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		assert.Error(t, d.SetEntryData(3, nil))
+		assert.Nil(t, d.SetEntryData(0, bytes.Repeat([]byte{3}, 48)))
+
+		memory := buffer.NewMemory(nil)
+		if err := d.PackToStream(context.Background(), memory, testutils.Noop, testutils.Noop); err != nil {
+			t.Fatal(err)
+		}
+
+		r := dat.New()
+		if err := dat.FromStream(r, bytes.NewReader(memory.Bytes())); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, uint32(48), r.Entries[0].Size)
+		assert.True(t, r.Entries[1].IsNull)
+		assert.Equal(t, r.Entries[0].Offset+48, r.Entries[2].Offset)
+		assert.Equal(t, byte(2), memory.Bytes()[r.Entries[2].Offset])
+	})
+}
+
+func TestWorkers(t *testing.T) {
+	dir := t.TempDir()
+
+	p := dat.New()
+	p.Alignment = 16
+	for i := range 64 {
+		if i%7 == 3 {
+			p.AddNullEntry()
+			continue
+		}
+
+		source := filepath.Join(dir, fmt.Sprintf("%d.bin", i))
+		if err := os.WriteFile(source, bytes.Repeat([]byte{uint8(i + 1)}, 1+i*37), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.AddEntryFromPathWithType(source, "BIN\x00"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// NOTE: memory does not implement io.WriterAt so it is packed by one worker
+	memory := buffer.NewMemory(nil)
+	if err := p.PackToStream(context.Background(), memory, testutils.Noop, testutils.Noop); err != nil {
+		t.Fatal(err)
+	}
+
+	source := filepath.Join(dir, "SOURCE.dat")
+	started := 0
+	done := 0
+	if err := p.PackWithWorkers(
+		context.Background(),
+		source,
+		8,
+		func(total uint32, current uint32, name string) { started += 1 },
+		func(total uint32, current uint32, name string) { done += 1 },
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	packed, err := os.ReadFile(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, memory.Bytes(), packed)
+	assert.Equal(t, 55, started)
+	assert.Equal(t, 55, done)
+
+	t.Run("unpack", func(t *testing.T) {
+		d := dat.New()
+		if err := dat.FromPath(d, source); err != nil {
+			t.Fatal(err)
+		}
+
+		output := t.TempDir()
+		if err := d.UnpackWithWorkers(context.Background(), output, 8, testutils.Noop, testutils.Noop); err != nil {
+			t.Fatal(err)
+		}
+
+		for i, entry := range p.Entries {
+			if entry.IsNull {
+				continue
+			}
+
+			expected, err := os.ReadFile(entry.Source)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			unpacked, err := os.ReadFile(filepath.Join(output, "BIN", fmt.Sprintf("BIN_%03d.bin", i)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// NOTE: unpacked entry keep alignment padding
+			assert.Equal(t, expected, unpacked[:len(expected)])
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		d := dat.New()
+		if err := dat.FromPath(d, source); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.EqualError(t, d.UnpackWithWorkers(ctx, t.TempDir(), 8, testutils.Noop, testutils.Noop), "Canceled")
+		assert.EqualError(t, p.PackWithWorkers(ctx, filepath.Join(dir, "CANCELED.dat"), 8, testutils.Noop, testutils.Noop), "Canceled")
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		if err := os.WriteFile(p.Entries[0].Source, []byte{1}, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Nil(t, p.PackWithWorkers(context.Background(), filepath.Join(dir, "CHANGED.dat"), 8, testutils.Noop, testutils.Noop))
+		assert.Equal(t, uint32(1), p.Entries[0].Size)
+	})
+}
+
+func TestReplaceNested(t *testing.T) {
+	pack := func(d *dat.Dat) []byte {
+		memory := buffer.NewMemory(nil)
+		if err := d.PackToStream(context.Background(), memory, testutils.Noop, testutils.Noop); err != nil {
+			t.Fatal(err)
+		}
+		return memory.Bytes()
+	}
+
+	nested := dat.New()
+	nested.Alignment = 16
+	nested.AddEntryFromBytesWithType([]byte{1, 2, 3}, "BIN\x00")
+	nested.AddEntryFromBytesWithType([]byte{4, 5}, "BIN\x00")
+
+	root := dat.New()
+	root.Alignment = 16
+	root.AddEntryFromBytesWithType([]byte{6}, "BIN\x00")
+	root.AddNullEntry()
+	root.AddEntryFromBytesWithType(pack(nested), "DAT\x00")
+
+	source := filepath.Join(t.TempDir(), "ROOT.dat")
+	if err := os.WriteFile(source, pack(root), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entry := func(data []byte, indices ...int) []byte {
+		offset := uint32(0)
+		size := uint32(0)
+		for _, index := range indices {
+			d := dat.New()
+			if err := dat.FromStreamWithOffsetSize(d, bytes.NewReader(data), offset, size); err != nil {
+				t.Fatal(err)
+			}
+			offset = d.Entries[index].Offset
+			size = d.Entries[index].Size
+		}
+		return data[offset : offset+size]
+	}
+
+	data, err := dat.ReplaceNested(source, []int{2, 0}, func(offset uint32, size uint32) ([]byte, error) {
+		original, err := os.ReadFile(source)
+		if err != nil {
+			return nil, err
+		}
+		assert.Equal(t, []byte{1, 2, 3}, original[offset : offset+size][:3])
+
+		return bytes.Repeat([]byte{9}, 20), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []byte{6}, entry(data, 0)[:1])
+	assert.Equal(t, bytes.Repeat([]byte{9}, 20), entry(data, 2, 0)[:20])
+	assert.Equal(t, []byte{4, 5}, entry(data, 2, 1)[:2])
+
+	t.Run("root", func(t *testing.T) {
+		data, err := dat.ReplaceNested(source, []int{}, func(offset uint32, size uint32) ([]byte, error) {
+			assert.Equal(t, uint32(0), offset)
+			return []byte{7}, nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, []byte{7}, data)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		replace := func(offset uint32, size uint32) ([]byte, error) { return nil, nil }
+
+		_, err := dat.ReplaceNested(source, []int{1}, replace)
+		assert.EqualError(t, err, "Entry 1 is null")
+
+		_, err = dat.ReplaceNested(source, []int{2, 5}, replace)
+		assert.EqualError(t, err, "Entry index 5 out of range, total 2")
+	})
+}
+
+func TestMetadata(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.bin"), []byte{1, 2, 3}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	legacy := `{"entry_total":3,"entries":[` +
+		`{"is_null":false,"source":"a.bin","type":"BIN\u0000"},` +
+		`{"is_null":true,"source":"","type":"\u0000\u0000\u0000\u0000"},` +
+		`{"is_null":false,"source":"missing.bin","type":"TOOLONG"}]}`
+	metadataPath := filepath.Join(dir, "METADATA.json")
+	if err := os.WriteFile(metadataPath, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var m dat.Metadata
+	if err := dat.MetadataFromPath(&m, metadataPath); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, dat.MetadataVersion, m.Version)
+	assert.Equal(t, uint32(3), m.EntryTotal)
+
+	_, err := m.Validate(dir)
+	assert.ErrorContains(t, err, "Entry 2 type \"TOOLONG\" must be 4 characters")
+	assert.ErrorContains(t, err, "Entry 2 source missing.bin not found")
+	assert.NotContains(t, err.Error(), "Entry 0")
+
+	m.Entries = m.Entries[:2]
+	m.EntryTotal = 2
+	changes, err := m.Validate(dir)
+	assert.Nil(t, err)
+	assert.Empty(t, changes)
+
+	t.Run("changes", func(t *testing.T) {
+		m.Entries[0].Size = 3
+		m.Entries[0].Checksum = utils.Checksum([]byte{1, 2, 3})
+
+		changes, err := m.Validate(dir)
+		assert.Nil(t, err)
+		assert.Empty(t, changes)
+
+		if err := os.WriteFile(filepath.Join(dir, "a.bin"), []byte{1, 2, 3, 4}, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		changes, err = m.Validate(dir)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{
+			fmt.Sprintf("Entry 0 source a.bin changed, checksum %s to %s, size 3 to 4 bytes", utils.Checksum([]byte{1, 2, 3}), utils.Checksum([]byte{1, 2, 3, 4})),
+		}, changes)
+	})
+
+	t.Run("offset", func(t *testing.T) {
+		// NOTE: 2 entries header is padded to 0x20, a.bin is the first packed entry
+		m.Alignment = 16
+		m.Entries[0].Checksum = ""
+		m.Entries[0].Size = 0
+
+		m.Entries[0].Offset = 0x20
+		changes, err := m.Validate(dir)
+		assert.Nil(t, err)
+		assert.Empty(t, changes)
+
+		m.Entries[0].Offset = 0x30
+		changes, err = m.Validate(dir)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"Entry 0 offset moved from 0x30 to 0x20"}, changes)
+
+		m.Entries[0].Offset = 0x24
+		_, err = m.Validate(dir)
+		assert.EqualError(t, err, "Entry 0 offset 0x24 is not aligned to 16")
+
+		m.Entries[0].Offset = 0x10
+		_, err = m.Validate(dir)
+		assert.EqualError(t, err, "Entry 0 offset 0x10 is inside 32 bytes header")
+	})
+
+	t.Run("order", func(t *testing.T) {
+		m.Entries[0].Offset = 0
+
+		m.Order = []int{0}
+		changes, err := m.Validate(dir)
+		assert.Nil(t, err)
+		assert.Empty(t, changes)
+
+		for _, tc := range []struct {
+			order    []int
+			expected []string
+		}{
+			{[]int{}, nil},
+			{[]int{0, 0}, []string{"Order has 2 index, expected 1 non null entries", "Order index 0 is duplicated"}},
+			{[]int{1}, []string{"Order index 1 is null entry"}},
+			{[]int{2}, []string{"Order index 2 out of range, total 2"}},
+			{[]int{-1}, []string{"Order index -1 out of range, total 2"}},
+		} {
+			m.Order = tc.order
+			_, err := m.Validate(dir)
+			if tc.expected == nil {
+				assert.Nil(t, err, "order %v", tc.order)
+				continue
+			}
+			assert.EqualError(t, err, strings.Join(tc.expected, "\n"), "order %v", tc.order)
+		}
+
+		m.Order = nil
+	})
+
+	m.Version = dat.MetadataVersion + 1
+	assert.NotNil(t, m.Migrate())
+}
@@ -1,12 +1,132 @@
 package dat
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/anasrar/chihuahua/pkg/utils"
+)
+
+const (
+	// NOTE: version 0 is metadata without version field (source, type, is_null only)
+	MetadataVersion uint32 = 1
+)
+
 type MetadataEntry struct {
-	IsNull bool   `json:"is_null"`
-	Source string `json:"source"`
-	Type   string `json:"type"`
+	IsNull   bool   `json:"is_null"`
+	Source   string `json:"source"`
+	Type     string `json:"type"`
+	Size     uint32 `json:"size,omitempty"`
+	Checksum string `json:"checksum,omitempty"`
+	Offset   uint32 `json:"offset,omitempty"` // NOTE: offset relative to container when unpacked
 }
 
 type Metadata struct {
+	Version    uint32           `json:"version"`
 	EntryTotal uint32           `json:"entry_total"`
+	HeaderSize uint32           `json:"header_size,omitempty"`
+	Alignment  uint32           `json:"alignment,omitempty"`
+	Order      []int            `json:"order,omitempty"`
 	Entries    []*MetadataEntry `json:"entries"`
 }
+
+func (self *Metadata) Migrate() error {
+	return utils.MigrateMetadata(&self.Version, MetadataVersion, &self.EntryTotal, len(self.Entries))
+}
+
+// NOTE: report every problem found before pack and every source changed since unpack, dir is METADATA.json parent directory
+func (self *Metadata) Validate(dir string) ([]string, error) {
+	errs := []error{}
+	sources := []*utils.MetadataSource{}
+	for i, entry := range self.Entries {
+		if entry.IsNull {
+			sources = append(sources, nil)
+			continue
+		}
+
+		if len(entry.Type) != int(EntryTypeLength) {
+			errs = append(errs, fmt.Errorf("Entry %d type %q must be %d characters", i, entry.Type, EntryTypeLength))
+		} else if utils.FilterUnprintableString(entry.Type) == "" {
+			errs = append(errs, fmt.Errorf("Entry %d type %q has no printable character", i, entry.Type))
+		}
+
+		sources = append(sources, &utils.MetadataSource{Source: entry.Source, Size: entry.Size, Checksum: entry.Checksum, Offset: entry.Offset})
+	}
+
+	// NOTE: same header size as pack
+	start := pad(uint32(len(self.Entries)))
+	if self.HeaderSize >= headerSize(uint32(len(self.Entries))) {
+		start = self.HeaderSize
+	}
+
+	order := self.Order
+	if orderErrs := self.validateOrder(); len(orderErrs) != 0 {
+		errs = append(errs, orderErrs...)
+		order = nil
+	}
+
+	changes, err := utils.ValidateMetadata(dir, self.EntryTotal, self.Alignment, start, order, sources)
+
+	return changes, errors.Join(append(errs, err)...)
+}
+
+// NOTE: order is optional, when it is set it must list every non null entry once, pack ignore invalid order
+func (self *Metadata) validateOrder() []error {
+	if len(self.Order) == 0 {
+		return nil
+	}
+
+	errs := []error{}
+
+	total := 0
+	for _, entry := range self.Entries {
+		if !entry.IsNull {
+			total++
+		}
+	}
+
+	if len(self.Order) != total {
+		errs = append(errs, fmt.Errorf("Order has %d index, expected %d non null entries", len(self.Order), total))
+	}
+
+	seen := map[int]bool{}
+	for _, index := range self.Order {
+		switch {
+		case index < 0 || index >= len(self.Entries):
+			errs = append(errs, fmt.Errorf("Order index %d out of range, total %d", index, len(self.Entries)))
+		case self.Entries[index].IsNull:
+			errs = append(errs, fmt.Errorf("Order index %d is null entry", index))
+		case seen[index]:
+			errs = append(errs, fmt.Errorf("Order index %d is duplicated", index))
+		}
+		seen[index] = true
+	}
+
+	return errs
+}
+
+func MetadataFromPath(metadata *Metadata, filePath string) error {
+	buf, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(buf, metadata); err != nil {
+		return err
+	}
+
+	return metadata.Migrate()
+}
+
+func MetadataToPath(metadata *Metadata, filePath string) error {
+	metadata.Version = MetadataVersion
+
+	buf, err := json.MarshalIndent(metadata, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filePath, buf, 0644)
+}
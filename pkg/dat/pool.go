@@ -0,0 +1,88 @@
+package dat
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// NOTE: worker count used when workers is 0 or less
+func DefaultWorkers() int {
+	return runtime.NumCPU()
+}
+
+// NOTE: run job for every index with at most workers goroutine.
+// First error or ctx cancel stop dispatching new job, running job is finished before return
+func work(ctx context.Context, workers int, indices []int, job func(index int) error) error {
+	if workers <= 0 {
+		workers = DefaultWorkers()
+	}
+
+	inner, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan int)
+	wg := sync.WaitGroup{}
+	once := sync.Once{}
+	var result error
+
+	for range min(workers, len(indices)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				if inner.Err() != nil {
+					continue
+				}
+
+				if err := job(index); err != nil {
+					once.Do(func() {
+						result = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for _, index := range indices {
+		select {
+		case <-inner.Done():
+			break dispatch
+		case jobs <- index:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if result != nil {
+		return result
+	}
+
+	if ctx.Err() != nil {
+		return fmt.Errorf("Canceled")
+	}
+
+	return nil
+}
+
+// NOTE: callback from worker is serialized so caller (GUI log and progress) does not need to lock
+type progress struct {
+	lock    sync.Mutex
+	onStart func(total uint32, current uint32, name string)
+	onDone  func(total uint32, current uint32, name string)
+}
+
+func (self *progress) start(total uint32, current uint32, name string) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.onStart(total, current, name)
+}
+
+func (self *progress) done(total uint32, current uint32, name string) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.onDone(total, current, name)
+}
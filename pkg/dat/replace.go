@@ -0,0 +1,103 @@
+package dat
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/anasrar/chihuahua/pkg/iso9660"
+	"github.com/anasrar/chihuahua/pkg/utils"
+)
+
+const (
+	MaxAlignment uint32 = 2048
+)
+
+// NOTE: rebuild DAT with entry data replaced, original header and other entries are copied as is
+func (self *Dat) Replace(index int, data []byte) ([]byte, error) {
+	if index < 0 || index >= len(self.Entries) {
+		return nil, fmt.Errorf("Entry index %d out of range, total %d", index, len(self.Entries))
+	}
+
+	if self.Entries[index].IsNull {
+		return nil, fmt.Errorf("Entry %d is null", index)
+	}
+
+	order := self.packOrder()
+	first := self.Entries[order[0]]
+
+	sourceFile, err := iso9660.Open(first.Source)
+	if err != nil {
+		return nil, err
+	}
+	defer sourceFile.Close()
+
+	alignment := self.Alignment
+
+	result := make([]byte, first.Offset-self.Offset)
+	if _, err := sourceFile.ReadAt(result, int64(self.Offset)); err != nil {
+		return nil, err
+	}
+
+	for _, i := range order {
+		entry := self.Entries[i]
+
+		// NOTE: entry total (4) + offset (uint32)
+		binary.LittleEndian.PutUint32(result[4+i*4:], uint32(len(result)))
+
+		if i == index {
+			size := uint32(len(data))
+			result = append(result, data...)
+			result = append(result, make([]byte, utils.AlignUp(size, alignment)-size)...)
+			continue
+		}
+
+		buf := make([]byte, entry.Size)
+		if _, err := sourceFile.ReadAt(buf, int64(entry.Offset)); err != nil {
+			return nil, err
+		}
+		result = append(result, buf...)
+	}
+
+	return result, nil
+}
+
+// NOTE: walk DAT entry indices from file to the innermost entry, replace gets offset and size of the innermost entry
+// (0 for the whole file when indices is empty) and return its new data. Every DAT is rebuilt from the innermost to
+// the file, result is the whole file
+func ReplaceNested(filePath string, indices []int, replace func(offset uint32, size uint32) ([]byte, error)) ([]byte, error) {
+	dats := []*Dat{}
+	offset := uint32(0)
+	size := uint32(0)
+	for _, index := range indices {
+		d := New()
+		if err := FromPathWithOffsetSize(d, filePath, offset, size); err != nil {
+			return nil, err
+		}
+
+		if index < 0 || index >= len(d.Entries) {
+			return nil, fmt.Errorf("Entry index %d out of range, total %d", index, len(d.Entries))
+		}
+
+		entry := d.Entries[index]
+		if entry.IsNull {
+			return nil, fmt.Errorf("Entry %d is null", index)
+		}
+
+		dats = append(dats, d)
+		offset = entry.Offset
+		size = entry.Size
+	}
+
+	data, err := replace(offset, size)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(dats) - 1; i >= 0; i-- {
+		if data, err = dats[i].Replace(indices[i], data); err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
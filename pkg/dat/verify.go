@@ -0,0 +1,62 @@
+package dat
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/anasrar/chihuahua/pkg/iso9660"
+)
+
+const (
+	verifyChunkSize int = 0x10000
+)
+
+// NOTE: compare packed DAT with source DAT byte by byte, report first mismatch offset
+func Verify(packPath string, sourcePath string) error {
+	packFile, err := iso9660.Open(packPath)
+	if err != nil {
+		return err
+	}
+	defer packFile.Close()
+
+	sourceFile, err := iso9660.Open(sourcePath)
+	if err != nil {
+		return err
+	}
+	defer sourceFile.Close()
+
+	packBuf := make([]byte, verifyChunkSize)
+	sourceBuf := make([]byte, verifyChunkSize)
+	offset := 0
+	for {
+		packN, packErr := io.ReadFull(packFile, packBuf)
+		sourceN, sourceErr := io.ReadFull(sourceFile, sourceBuf)
+
+		if packErr != nil && packErr != io.EOF && packErr != io.ErrUnexpectedEOF {
+			return packErr
+		}
+		if sourceErr != nil && sourceErr != io.EOF && sourceErr != io.ErrUnexpectedEOF {
+			return sourceErr
+		}
+
+		n := min(packN, sourceN)
+		if !bytes.Equal(packBuf[:n], sourceBuf[:n]) {
+			for i := range n {
+				if packBuf[i] != sourceBuf[i] {
+					return fmt.Errorf("DAT not match at offset 0x%X", offset+i)
+				}
+			}
+		}
+
+		if packN != sourceN {
+			return fmt.Errorf("DAT size not match at offset 0x%X", offset+n)
+		}
+
+		if packN < verifyChunkSize {
+			return nil
+		}
+
+		offset += n
+	}
+}
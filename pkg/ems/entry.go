@@ -1,11 +1,54 @@
 package ems
 
+import (
+	"encoding/binary"
+	"math"
+)
+
+const (
+	EntrySize uint32 = 64
+)
+
+// NOTE: only translation (0x04) is confirmed, enemy is drawn there in game. Every other offset is a guess from
+// comparing room files and is not verified yet: id (0x00), flag (0x02), rotation (0x10), trigger (0x1C), wave (0x1E),
+// and 0x20 - 0x3F is unknown
 type Entry struct {
+	Id          uint16     `json:"id"`   // NOTE: guess, likely enemy id (emXX)
+	Flag        uint16     `json:"flag"` // NOTE: guess
 	Translation [3]float32 `json:"translation"`
+	Rotation    [3]float32 `json:"rotation"` // NOTE: guess, likely euler in radian
+	Trigger     uint16     `json:"trigger"`  // NOTE: guess
+	Wave        uint16     `json:"wave"`     // NOTE: guess
+	Unknown     [32]byte   `json:"unknown"`
+	Size        uint32     `json:"size"` // NOTE: bytes stored in file, last entry in some file is shorter than EntrySize
 }
 
 func NewEntry(translation [3]float32) *Entry {
 	return &Entry{
+		Id:          0,
+		Flag:        0,
 		Translation: translation,
+		Rotation:    [3]float32{0, 0, 0},
+		Trigger:     0,
+		Wave:        0,
+		Unknown:     [32]byte{},
+		Size:        EntrySize,
 	}
 }
+
+func (self *Entry) bytes() []byte {
+	b := make([]byte, EntrySize)
+	binary.LittleEndian.PutUint16(b[0:], self.Id)
+	binary.LittleEndian.PutUint16(b[2:], self.Flag)
+	for i, v := range self.Translation {
+		binary.LittleEndian.PutUint32(b[4+i*4:], math.Float32bits(v))
+	}
+	for i, v := range self.Rotation {
+		binary.LittleEndian.PutUint32(b[16+i*4:], math.Float32bits(v))
+	}
+	binary.LittleEndian.PutUint16(b[28:], self.Trigger)
+	binary.LittleEndian.PutUint16(b[30:], self.Wave)
+	copy(b[32:], self.Unknown[:])
+
+	return b
+}
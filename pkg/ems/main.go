@@ -1,11 +1,14 @@
 package ems
 
 import (
-	"fmt"
+	"encoding/binary"
+	"errors"
 	"io"
+	"math"
 	"os"
 
 	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/iso9660"
 )
 
 const (
@@ -18,21 +21,23 @@ type Ems struct {
 	Entries    []*Entry `json:"entries"`
 }
 
-func (self *Ems) unmarshal(stream io.ReadWriteSeeker) error {
-	if _, err := buffer.Seek(stream, int64(self.Offset), buffer.SeekStart); err != nil {
+func (self *Ems) unmarshal(stream io.ReadSeeker) error {
+	cursor := buffer.NewCursorLE(stream, "EMS")
+
+	if _, err := cursor.Move(int64(self.Offset), buffer.SeekStart); err != nil {
 		return err
 	}
 
 	signature := uint32(0)
-	if _, err := buffer.ReadUint32LE(stream, &signature); err != nil {
+	if _, err := cursor.ReadUint32("signature", &signature); err != nil {
 		return err
 	}
 
 	if signature != Signature {
-		return fmt.Errorf("EMS signature not match")
+		return cursor.Mismatch("signature", Signature, signature)
 	}
 
-	if _, err := buffer.ReadUint32LE(stream, &self.EntryTotal); err != nil {
+	if _, err := cursor.ReadUint32("EntryTotal", &self.EntryTotal); err != nil {
 		return err
 	}
 
@@ -40,44 +45,111 @@ func (self *Ems) unmarshal(stream io.ReadWriteSeeker) error {
 		return nil
 	}
 
+	// NOTE: id (2) + flag (2) + translation (float[3]) + rest (48) for every entry, last padding can be cut off
+	if err := cursor.Require("EntryTotal", uint64(self.EntryTotal-1)*64+16); err != nil {
+		return err
+	}
+
 	for range self.EntryTotal {
-		// TODO: research this padding
-		if _, err := buffer.Seek(stream, 4, buffer.SeekCurrent); err != nil {
+		entry := NewEntry([3]float32{0, 0, 0})
+
+		if _, err := cursor.ReadUint16("entry.Id", &entry.Id); err != nil {
 			return err
 		}
 
-		translationX := float32(0)
-		if _, err := buffer.ReadFloat32LE(stream, &translationX); err != nil {
+		if _, err := cursor.ReadUint16("entry.Flag", &entry.Flag); err != nil {
 			return err
 		}
 
-		translationY := float32(0)
-		if _, err := buffer.ReadFloat32LE(stream, &translationY); err != nil {
-			return err
+		for i := range entry.Translation {
+			if _, err := cursor.ReadFloat32("entry.Translation", &entry.Translation[i]); err != nil {
+				return err
+			}
 		}
 
-		translationZ := float32(0)
-		if _, err := buffer.ReadFloat32LE(stream, &translationZ); err != nil {
+		// NOTE: last entry can be cut off after translation, missing bytes are left as zero and size is kept for write
+		tail := [48]byte{}
+		n, err := io.ReadFull(cursor.Stream, tail[:])
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
 			return err
 		}
+		entry.Size = 16 + uint32(n)
+
+		for i := range entry.Rotation {
+			entry.Rotation[i] = math.Float32frombits(binary.LittleEndian.Uint32(tail[i*4:]))
+		}
+		entry.Trigger = binary.LittleEndian.Uint16(tail[12:])
+		entry.Wave = binary.LittleEndian.Uint16(tail[14:])
+		copy(entry.Unknown[:], tail[16:])
+
+		self.Entries = append(self.Entries, entry)
+	}
+
+	return nil
+}
 
-		self.Entries = append(
-			self.Entries,
-			NewEntry(
-				[3]float32{translationX, translationY, translationZ},
-			),
-		)
+func (self *Ems) marshal(stream io.WriteSeeker) error {
+	if _, err := buffer.WriteUint32LE(stream, Signature); err != nil {
+		return err
+	}
 
-		// TODO: research this padding
-		if _, err := buffer.Seek(stream, 48, buffer.SeekCurrent); err != nil {
+	if _, err := buffer.WriteUint32LE(stream, uint32(len(self.Entries))); err != nil {
+		return err
+	}
+
+	for i := range self.Entries {
+		if _, err := buffer.WriteBytes(stream, self.entryBytes(i)); err != nil {
 			return err
 		}
-
 	}
 
+	self.EntryTotal = uint32(len(self.Entries))
+
 	return nil
 }
 
+func (self *Ems) AddEntry(entry *Entry) {
+	self.Entries = append(self.Entries, entry)
+	self.EntryTotal += 1
+}
+
+func (self *Ems) RemoveEntry(index int) {
+	if index < 0 || index >= len(self.Entries) {
+		return
+	}
+
+	self.Entries = append(self.Entries[:index], self.Entries[index+1:]...)
+	self.EntryTotal -= 1
+}
+
+// NOTE: cut off last entry is written with its original size as long as the missing bytes are still zero
+func (self *Ems) entryBytes(index int) []byte {
+	entry := self.Entries[index]
+	b := entry.bytes()
+
+	if index != len(self.Entries)-1 || entry.Size == 0 || entry.Size >= EntrySize {
+		return b
+	}
+
+	for _, v := range b[entry.Size:] {
+		if v != 0 {
+			return b
+		}
+	}
+
+	return b[:entry.Size]
+}
+
+// NOTE: signature (4) + entry total (4) + entries
+func (self *Ems) Size() uint32 {
+	size := uint32(8)
+	for i := range self.Entries {
+		size += uint32(len(self.entryBytes(i)))
+	}
+
+	return size
+}
+
 func New() *Ems {
 	return &Ems{
 		Offset:     0,
@@ -86,8 +158,17 @@ func New() *Ems {
 	}
 }
 
+func FromStreamWithOffset(ems *Ems, stream io.ReadSeeker, offset uint32) error {
+	ems.Offset = offset
+	return ems.unmarshal(stream)
+}
+
+func FromStream(ems *Ems, stream io.ReadSeeker) error {
+	return FromStreamWithOffset(ems, stream, 0)
+}
+
 func FromPathWithOffset(ems *Ems, filePath string, offset uint32) error {
-	file, err := os.Open(filePath)
+	file, err := iso9660.Open(filePath)
 	if err != nil {
 		return err
 	}
@@ -100,3 +181,17 @@ func FromPathWithOffset(ems *Ems, filePath string, offset uint32) error {
 func FromPath(ems *Ems, filePath string) error {
 	return FromPathWithOffset(ems, filePath, 0)
 }
+
+func ToStream(ems *Ems, stream io.WriteSeeker) error {
+	return ems.marshal(stream)
+}
+
+func ToPath(ems *Ems, filePath string) error {
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return ems.marshal(file)
+}
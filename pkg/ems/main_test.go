@@ -0,0 +1,144 @@
+package ems_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/anasrar/chihuahua/internal/testutils"
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/ems"
+	"github.com/stretchr/testify/assert"
+)
+
+func fixture(translations ...[3]float32) []byte {
+	var buf bytes.Buffer
+
+	testutils.Write(&buf, ems.Signature, uint32(len(translations)))
+	for _, translation := range translations {
+		testutils.Write(&buf, uint32(0), translation, [48]byte{})
+	}
+
+	return buf.Bytes()
+}
+
+func TestFromStream(t *testing.T) {
+	t.Run("entries", func(t *testing.T) {
+		e := ems.New()
+		if err := ems.FromStream(e, bytes.NewReader(fixture([3]float32{1, 2, 3}, [3]float32{-4, 5, -6}))); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, uint32(2), e.EntryTotal)
+		assert.Equal(t, [3]float32{1, 2, 3}, e.Entries[0].Translation)
+		assert.Equal(t, [3]float32{-4, 5, -6}, e.Entries[1].Translation)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		e := ems.New()
+		if err := ems.FromStream(e, bytes.NewReader(fixture())); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, 0, len(e.Entries))
+	})
+
+	t.Run("entry total exceeds stream", func(t *testing.T) {
+		data := fixture([3]float32{1, 2, 3})
+		binary.LittleEndian.PutUint32(data[4:], 0xFFFFFFFF)
+
+		e := ems.New()
+		assert.Error(t, ems.FromStream(e, bytes.NewReader(data)))
+	})
+}
+
+func TestEntry(t *testing.T) {
+	var buf bytes.Buffer
+	testutils.Write(&buf, ems.Signature, uint32(1))
+	testutils.Write(&buf, uint16(0x1A), uint16(0x8001), [3]float32{1, 2, 3}, [3]float32{0, 1.5, 0}, uint16(7), uint16(2))
+	testutils.Write(&buf, bytes.Repeat([]byte{0xAB}, 32))
+
+	e := ems.New()
+	if err := ems.FromStream(e, bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+
+	entry := e.Entries[0]
+	assert.Equal(t, uint16(0x1A), entry.Id)
+	assert.Equal(t, uint16(0x8001), entry.Flag)
+	assert.Equal(t, [3]float32{1, 2, 3}, entry.Translation)
+	assert.Equal(t, [3]float32{0, 1.5, 0}, entry.Rotation)
+	assert.Equal(t, uint16(7), entry.Trigger)
+	assert.Equal(t, uint16(2), entry.Wave)
+	assert.Equal(t, byte(0xAB), entry.Unknown[31])
+
+	t.Run("cut off", func(t *testing.T) {
+		data := buf.Bytes()[:8+16]
+
+		e := ems.New()
+		if err := ems.FromStream(e, bytes.NewReader(data)); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, [3]float32{1, 2, 3}, e.Entries[0].Translation)
+		assert.Equal(t, [3]float32{0, 0, 0}, e.Entries[0].Rotation)
+		assert.Equal(t, uint32(16), e.Entries[0].Size)
+
+		memory := buffer.NewMemory(nil)
+		if err := ems.ToStream(e, memory); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, data, memory.Bytes())
+		assert.Equal(t, uint32(len(data)), e.Size())
+
+		e.Entries[0].Wave = 1
+		memory = buffer.NewMemory(nil)
+		if err := ems.ToStream(e, memory); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 8+int(ems.EntrySize), len(memory.Bytes()))
+	})
+}
+
+func TestToPath(t *testing.T) {
+	e := ems.New()
+	if err := ems.FromStream(e, bytes.NewReader(fixture([3]float32{1, 2, 3}, [3]float32{-4, 5, -6}))); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(t.TempDir(), "OUTPUT.ems")
+	if err := ems.ToPath(e, output); err != nil {
+		t.Fatal(err)
+	}
+
+	packed, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, fixture([3]float32{1, 2, 3}, [3]float32{-4, 5, -6}), packed)
+
+	t.Run("edit", func(t *testing.T) {
+		entry := ems.NewEntry([3]float32{7, 8, 9})
+		entry.Id = 3
+		entry.Wave = 1
+		e.AddEntry(entry)
+		e.RemoveEntry(0)
+
+		if err := ems.ToPath(e, output); err != nil {
+			t.Fatal(err)
+		}
+
+		d := ems.New()
+		if err := ems.FromPath(d, output); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, uint32(2), d.EntryTotal)
+		assert.Equal(t, uint32(len(packed)), e.Size())
+		assert.Equal(t, [3]float32{-4, 5, -6}, d.Entries[0].Translation)
+		assert.Equal(t, uint16(3), d.Entries[1].Id)
+		assert.Equal(t, uint16(1), d.Entries[1].Wave)
+	})
+}
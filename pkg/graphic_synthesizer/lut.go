@@ -0,0 +1,191 @@
+package graphicsynthesizer
+
+import (
+	"sync"
+)
+
+// NOTE: word address of PSMCT32 pixel, same layout as WriteTexPSMCT32 with dbp 0
+func addressPSMCT32(dbw int, x int, y int) int {
+	pageX := x / 64
+	pageY := y / 32
+	page := pageX + pageY*dbw
+
+	px := x - (pageX * 64)
+	py := y - (pageY * 32)
+
+	blockX := px / 8
+	blockY := py / 8
+	block := block32[blockX+blockY*8]
+
+	bx := px - blockX*8
+	by := py - blockY*8
+
+	column := by / 2
+
+	cx := bx
+	cy := by - column*2
+	cw := columnWord32[cx+cy*8]
+
+	return page*2048 + block*64 + column*16 + cw
+}
+
+// NOTE: byte address of PSMT8 pixel, same layout as WriteTexPSMT8 with dbp 0
+func addressPSMT8(dbw int, x int, y int) int {
+	pageX := x / 128
+	pageY := y / 64
+	page := pageX + pageY*dbw
+
+	px := x - (pageX * 128)
+	py := y - (pageY * 64)
+
+	blockX := px / 16
+	blockY := py / 16
+	block := block8[blockX+blockY*8]
+
+	bx := px - blockX*16
+	by := py - blockY*16
+
+	column := by / 4
+
+	cx := bx
+	cy := by - column*4
+	cw := columnWord8[column&1][cx+cy*16]
+	cb := columnByte8[cx+cy*16]
+
+	return (page*2048+block*64+column*16+cw)*4 + cb
+}
+
+// NOTE: nibble address of PSMT4 pixel, same layout as WriteTexPSMT4 with dbp 0
+func addressPSMT4(dbw int, x int, y int) int {
+	pageX := x / 128
+	pageY := y / 128
+	page := pageX + pageY*dbw
+
+	px := x - (pageX * 128)
+	py := y - (pageY * 128)
+
+	blockX := px / 32
+	blockY := py / 16
+	block := Block4[blockX+blockY*4]
+
+	bx := px - blockX*32
+	by := py - blockY*16
+
+	column := by / 4
+
+	cx := bx
+	cy := by - column*4
+	cw := ColumnWord4[column&1][cx+cy*32]
+	cb := ColumnByte4[cx+cy*32]
+
+	return ((page*2048+block*64+column*16+cw)*4+(cb>>1))*2 + (cb & 1)
+}
+
+type lutKey struct {
+	bpp    int
+	width  int
+	height int
+}
+
+var luts = sync.Map{}
+
+// NOTE: table[i] is swizzled position of linear pixel i (byte for 8 bit, nibble for 4 bit), -1 when pixel is outside PSMCT32 rect.
+// Swizzled data is PSMCT32 rect (width / 2 x height / 2 for 8 bit, width / 2 x height / 4 for 4 bit) read as bytes.
+// Table is built once for every size and shared, it is read only so it is safe for concurrent use
+func lut(bpp int, width int, height int) []int32 {
+	key := lutKey{bpp: bpp, width: width, height: height}
+	if table, found := luts.Load(key); found {
+		return table.([]int32)
+	}
+
+	rrw := width / 2
+	rrh := height / 2
+	unit := 1 // NOTE: GS address unit per swizzled position, byte for 8 bit and nibble for 4 bit
+	if bpp == 4 {
+		rrh = height / 4
+		unit = 2
+	}
+
+	// NOTE: GS address to swizzled position
+	gs := []int32{}
+	for y := range rrh {
+		for x := range rrw {
+			word := addressPSMCT32(rrw/64, x, y)
+			for b := range 4 * unit {
+				address := word*4*unit + b
+				for address >= len(gs) {
+					gs = append(gs, -1)
+				}
+				gs[address] = int32(((y*rrw+x)*4)*unit + b)
+			}
+		}
+	}
+
+	table := make([]int32, width*height)
+	for y := range height {
+		for x := range width {
+			address := 0
+			switch bpp {
+			case 4:
+				address = addressPSMT4((width/64)>>1, x, y)
+			case 8:
+				address = addressPSMT8((width/64)>>1, x, y)
+			}
+
+			table[y*width+x] = -1
+			if address < len(gs) {
+				table[y*width+x] = gs[address]
+			}
+		}
+	}
+
+	luts.Store(key, table)
+
+	return table
+}
+
+// NOTE: dst and src must have the same size, dst is fully overwritten
+func Swizzle8To(dst []byte, src []byte, width int, height int) {
+	clear(dst)
+	for i, position := range lut(8, width, height)[:min(len(src), width*height)] {
+		if position >= 0 && int(position) < len(dst) {
+			dst[position] = src[i]
+		}
+	}
+}
+
+// NOTE: dst and src must have the same size, dst is fully overwritten
+func Unswizzle8To(dst []byte, src []byte, width int, height int) {
+	for i, position := range lut(8, width, height)[:min(len(dst), width*height)] {
+		dst[i] = 0
+		if position >= 0 && int(position) < len(src) {
+			dst[i] = src[position]
+		}
+	}
+}
+
+// NOTE: dst and src must have the same size, two pixel per byte with low nibble first, dst is fully overwritten
+func Swizzle4To(dst []byte, src []byte, width int, height int) {
+	clear(dst)
+	for i, position := range lut(4, width, height)[:min(len(src)*2, width*height)] {
+		if position < 0 || int(position/2) >= len(dst) {
+			continue
+		}
+
+		pixel := (src[i/2] >> ((i & 1) * 4)) & 0xF
+		dst[position/2] |= pixel << ((position & 1) * 4)
+	}
+}
+
+// NOTE: dst and src must have the same size, two pixel per byte with low nibble first, dst is fully overwritten
+func Unswizzle4To(dst []byte, src []byte, width int, height int) {
+	clear(dst)
+	for i, position := range lut(4, width, height)[:min(len(dst)*2, width*height)] {
+		if position < 0 || int(position/2) >= len(src) {
+			continue
+		}
+
+		pixel := (src[position/2] >> ((position & 1) * 4)) & 0xF
+		dst[i/2] |= pixel << ((i & 1) * 4)
+	}
+}
@@ -315,38 +315,27 @@ func ReadTexPSMT4(dbp int, dbw int, dsax int, dsay int, rrw int, rrh int, data [
 	}
 }
 
+// NOTE: swizzle use lookup table and do not touch GsMem, safe for concurrent use
 func Unswizzle4(data []byte, width int, height int) []byte {
 	result := make([]byte, len(data))
-	rrw := width / 2
-	rrh := height / 4
-	WriteTexPSMCT32(0, rrw/64, 0, 0, rrw, rrh, data)
-	ReadTexPSMT4(0, width/64, 0, 0, width, height, result)
+	Unswizzle4To(result, data, width, height)
 	return result
 }
 
 func Swizzle4(data []byte, width, height int) []byte {
 	result := make([]byte, len(data))
-	rrw := width / 2
-	rrh := height / 4
-	WriteTexPSMT4(0, width/64, 0, 0, width, height, data)
-	ReadTexPSMCT32(0, rrw/64, 0, 0, rrw, rrh, result)
+	Swizzle4To(result, data, width, height)
 	return result
 }
 
 func Unswizzle8(data []byte, width int, height int) []byte {
 	result := make([]byte, len(data))
-	rrw := width / 2
-	rrh := height / 2
-	WriteTexPSMCT32(0, rrw/64, 0, 0, rrw, rrh, data)
-	ReadTexPSMT8(0, width/64, 0, 0, width, height, result)
+	Unswizzle8To(result, data, width, height)
 	return result
 }
 
 func Swizzle8(data []byte, width, height int) []byte {
 	result := make([]byte, len(data))
-	rrw := width / 2
-	rrh := height / 2
-	WriteTexPSMT8(0, width/64, 0, 0, width, height, data)
-	ReadTexPSMCT32(0, rrw/64, 0, 0, rrw, rrh, result)
+	Swizzle8To(result, data, width, height)
 	return result
 }
@@ -0,0 +1,104 @@
+package graphicsynthesizer_test
+
+import (
+	"fmt"
+	"testing"
+
+	graphicsynthesizer "github.com/anasrar/chihuahua/pkg/graphic_synthesizer"
+	"github.com/stretchr/testify/assert"
+)
+
+func pattern(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = uint8(i*7 + i/256)
+	}
+
+	return data
+}
+
+func TestSwizzle(t *testing.T) {
+	for _, size := range [][2]int{{128, 128}, {256, 128}, {256, 256}} {
+		width, height := size[0], size[1]
+
+		t.Run(fmt.Sprintf("%dx%d 8 bit", width, height), func(t *testing.T) {
+			data := pattern(width * height)
+			swizzled := graphicsynthesizer.Swizzle8(data, width, height)
+			assert.NotEqual(t, data, swizzled)
+			assert.Equal(t, data, graphicsynthesizer.Unswizzle8(swizzled, width, height))
+		})
+
+		t.Run(fmt.Sprintf("%dx%d 4 bit", width, height), func(t *testing.T) {
+			data := pattern(width * height / 2)
+			swizzled := graphicsynthesizer.Swizzle4(data, width, height)
+			assert.NotEqual(t, data, swizzled)
+			assert.Equal(t, data, graphicsynthesizer.Unswizzle4(swizzled, width, height))
+		})
+	}
+}
+
+// NOTE: lookup table must give the same result as going through GS memory
+func TestLut(t *testing.T) {
+	for _, size := range [][2]int{{128, 64}, {128, 128}, {256, 256}, {512, 256}} {
+		width, height := size[0], size[1]
+
+		t.Run(fmt.Sprintf("%dx%d 8 bit", width, height), func(t *testing.T) {
+			data := pattern(width * height)
+			expected := make([]byte, len(data))
+			graphicsynthesizer.WriteTexPSMT8(0, width/64, 0, 0, width, height, data)
+			graphicsynthesizer.ReadTexPSMCT32(0, width/2/64, 0, 0, width/2, height/2, expected)
+			assert.Equal(t, expected, graphicsynthesizer.Swizzle8(data, width, height))
+
+			graphicsynthesizer.WriteTexPSMCT32(0, width/2/64, 0, 0, width/2, height/2, data)
+			graphicsynthesizer.ReadTexPSMT8(0, width/64, 0, 0, width, height, expected)
+			assert.Equal(t, expected, graphicsynthesizer.Unswizzle8(data, width, height))
+		})
+
+		// NOTE: PSMT4 page is 128x128, smaller 4 bit texture is not swizzled
+		if height < 128 {
+			continue
+		}
+
+		t.Run(fmt.Sprintf("%dx%d 4 bit", width, height), func(t *testing.T) {
+			data := pattern(width * height / 2)
+			expected := make([]byte, len(data))
+			graphicsynthesizer.WriteTexPSMT4(0, width/64, 0, 0, width, height, data)
+			graphicsynthesizer.ReadTexPSMCT32(0, width/2/64, 0, 0, width/2, height/4, expected)
+			assert.Equal(t, expected, graphicsynthesizer.Swizzle4(data, width, height))
+
+			graphicsynthesizer.WriteTexPSMCT32(0, width/2/64, 0, 0, width/2, height/4, data)
+			graphicsynthesizer.ReadTexPSMT4(0, width/64, 0, 0, width, height, expected)
+			assert.Equal(t, expected, graphicsynthesizer.Unswizzle4(data, width, height))
+		})
+	}
+}
+
+func BenchmarkSwizzle(b *testing.B) {
+	width, height := 256, 256
+	data8 := pattern(width * height)
+	data4 := pattern(width * height / 2)
+
+	b.Run("Swizzle8", func(b *testing.B) {
+		for range b.N {
+			graphicsynthesizer.Swizzle8(data8, width, height)
+		}
+	})
+
+	b.Run("Unswizzle8", func(b *testing.B) {
+		for range b.N {
+			graphicsynthesizer.Unswizzle8(data8, width, height)
+		}
+	})
+
+	b.Run("Swizzle4", func(b *testing.B) {
+		for range b.N {
+			graphicsynthesizer.Swizzle4(data4, width, height)
+		}
+	})
+
+	b.Run("Unswizzle4", func(b *testing.B) {
+		for range b.N {
+			graphicsynthesizer.Unswizzle4(data4, width, height)
+		}
+	})
+}
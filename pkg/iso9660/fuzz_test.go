@@ -0,0 +1,31 @@
+package iso9660_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/anasrar/chihuahua/pkg/iso9660"
+)
+
+// NOTE: system area is not read, fuzz data start from first volume descriptor to keep input small
+func FuzzFromStream(f *testing.F) {
+	image, _ := fixture(f)
+	systemArea := int(iso9660.SystemAreaSectors * iso9660.SectorSize)
+
+	f.Add(image[systemArea:])
+	f.Add([]byte{})
+	f.Add(bytes.Repeat([]byte{0xFF}, int(iso9660.SectorSize)))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		data = append(make([]byte, systemArea), data...)
+
+		iso := iso9660.New()
+		if err := iso9660.FromStream(iso, bytes.NewReader(data)); err != nil {
+			return
+		}
+
+		_ = iso.Walk(bytes.NewReader(data), func(p string, record *iso9660.Record) error {
+			return nil
+		})
+	})
+}
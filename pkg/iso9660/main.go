@@ -0,0 +1,315 @@
+package iso9660
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/anasrar/chihuahua/pkg/buffer"
+)
+
+const (
+	SectorSize        uint32 = 2048
+	SystemAreaSectors uint32 = 16
+
+	VolumeDescriptorPrimary       uint8 = 1
+	VolumeDescriptorSupplementary uint8 = 2 // NOTE: Joliet, directory tree point to the same file data
+	VolumeDescriptorTerminator    uint8 = 255
+
+	FlagDirectory   uint8 = 0x02
+	FlagMultiExtent uint8 = 0x80
+
+	RecordHeaderSize uint64 = 33 // NOTE: directory record without name
+	RootRecordOffset uint64 = 156
+)
+
+var Identifier = "CD001"
+
+type Record struct {
+	Name     string `json:"name"` // NOTE: without version (;1)
+	Lba      uint32 `json:"lba"`
+	Size     uint32 `json:"size"`
+	IsDir    bool   `json:"is_dir"`
+	Position uint64 `json:"position"` // NOTE: offset of directory record in image
+}
+
+func (self *Record) Offset(blockSize uint32) uint64 {
+	return uint64(self.Lba) * uint64(blockSize)
+}
+
+type Iso struct {
+	BlockSize       uint32  `json:"block_size"`
+	VolumeSpaceSize uint32  `json:"volume_space_size"`
+	Descriptor      uint64  `json:"descriptor"` // NOTE: offset of primary volume descriptor
+	Root            *Record `json:"root"`
+
+	Supplementary []uint64  `json:"supplementary"` // NOTE: offset of supplementary volume descriptor
+	Roots         []*Record `json:"roots"`         // NOTE: root of supplementary volume descriptor
+}
+
+// NOTE: record is stored as both endian, only little endian half is read
+func readRecord(cursor *buffer.Cursor, position uint64) (*Record, uint8, error) {
+	if _, err := cursor.Move(int64(position), buffer.SeekStart); err != nil {
+		return nil, 0, err
+	}
+
+	length := uint8(0)
+	if _, err := cursor.ReadUint8("record.Length", &length); err != nil {
+		return nil, 0, err
+	}
+
+	if length == 0 {
+		return nil, 0, nil
+	}
+
+	if uint64(length) < RecordHeaderSize {
+		return nil, 0, cursor.Invalid("record.Length", "record length %d is smaller than %d", length, RecordHeaderSize)
+	}
+
+	data := make([]byte, length-1)
+	if _, err := cursor.ReadBytes("record", data); err != nil {
+		return nil, 0, err
+	}
+
+	nameLength := uint64(data[31])
+	if RecordHeaderSize+nameLength > uint64(length) {
+		return nil, 0, cursor.Invalid("record.NameLength", "name length %d is outside of %d bytes record", nameLength, length)
+	}
+
+	flags := data[24]
+	if flags&FlagMultiExtent != 0 {
+		return nil, 0, cursor.Invalid("record.Flags", "multi extent file is not supported")
+	}
+
+	name := string(data[32 : 32+nameLength])
+	if i := strings.LastIndex(name, ";"); i >= 0 {
+		name = name[:i]
+	}
+	if name != "." && strings.HasSuffix(name, ".") {
+		name = strings.TrimSuffix(name, ".")
+	}
+
+	return &Record{
+		Name:     name,
+		Lba:      binary.LittleEndian.Uint32(data[1:]),
+		Size:     binary.LittleEndian.Uint32(data[9:]),
+		IsDir:    flags&FlagDirectory != 0,
+		Position: position,
+	}, length, nil
+}
+
+func (self *Iso) unmarshal(stream io.ReadSeeker) error {
+	cursor := buffer.NewCursorLE(stream, "ISO")
+
+	for sector := SystemAreaSectors; ; sector++ {
+		position := uint64(sector) * uint64(SectorSize)
+		if _, err := cursor.Move(int64(position), buffer.SeekStart); err != nil {
+			return err
+		}
+
+		t := uint8(0)
+		if _, err := cursor.ReadUint8("descriptor.Type", &t); err != nil {
+			return err
+		}
+
+		identifier := ""
+		if _, err := cursor.ReadString("descriptor.Identifier", &identifier, uint64(len(Identifier))); err != nil {
+			return err
+		}
+
+		if identifier != Identifier {
+			return cursor.Mismatch("descriptor.Identifier", Identifier, identifier)
+		}
+
+		if t == VolumeDescriptorTerminator {
+			break
+		}
+
+		if t == VolumeDescriptorSupplementary {
+			root, _, err := readRecord(cursor, position+RootRecordOffset)
+			if err != nil {
+				return err
+			}
+
+			if root != nil && root.IsDir {
+				self.Supplementary = append(self.Supplementary, position)
+				self.Roots = append(self.Roots, root)
+			}
+			continue
+		}
+
+		if t != VolumeDescriptorPrimary || self.Root != nil {
+			continue
+		}
+
+		self.Descriptor = position
+
+		if _, err := cursor.Move(int64(position+80), buffer.SeekStart); err != nil {
+			return err
+		}
+
+		if _, err := cursor.ReadUint32("VolumeSpaceSize", &self.VolumeSpaceSize); err != nil {
+			return err
+		}
+
+		blockSize := uint16(0)
+		if _, err := cursor.Move(int64(position+128), buffer.SeekStart); err != nil {
+			return err
+		}
+
+		if _, err := cursor.ReadUint16("BlockSize", &blockSize); err != nil {
+			return err
+		}
+
+		if blockSize == 0 {
+			return cursor.Invalid("BlockSize", "block size is 0")
+		}
+		self.BlockSize = uint32(blockSize)
+
+		root, _, err := readRecord(cursor, position+RootRecordOffset)
+		if err != nil {
+			return err
+		}
+
+		if root == nil || !root.IsDir {
+			return cursor.Invalid("Root", "root directory record is missing")
+		}
+		self.Root = root
+	}
+
+	if self.Root == nil {
+		return cursor.Invalid("descriptor.Type", "primary volume descriptor not found")
+	}
+
+	return nil
+}
+
+// NOTE: record does not cross sector, zero length mean rest of sector is padding. Self and parent record is skipped
+func (self *Iso) ReadDir(stream io.ReadSeeker, dir *Record) ([]*Record, error) {
+	if !dir.IsDir {
+		return nil, fmt.Errorf("%s is not directory", dir.Name)
+	}
+
+	cursor := buffer.NewCursorLE(stream, "ISO")
+	start := dir.Offset(self.BlockSize)
+	if err := cursor.Within("Directory", start, uint64(dir.Size)); err != nil {
+		return nil, err
+	}
+
+	records := []*Record{}
+	for offset := uint64(0); offset < uint64(dir.Size); {
+		record, length, err := readRecord(cursor, start+offset)
+		if err != nil {
+			return nil, err
+		}
+
+		if record == nil {
+			offset = (offset/uint64(SectorSize) + 1) * uint64(SectorSize)
+			continue
+		}
+		offset += uint64(length)
+
+		if record.Name == "\x00" || record.Name == "\x01" {
+			continue
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// NOTE: path inside ISO is case insensitive, separator can be slash or backslash
+func (self *Iso) Find(stream io.ReadSeeker, p string) (*Record, error) {
+	current := self.Root
+	for _, name := range strings.Split(strings.Trim(strings.ReplaceAll(p, "\\", "/"), "/"), "/") {
+		if name == "" {
+			continue
+		}
+
+		records, err := self.ReadDir(stream, current)
+		if err != nil {
+			return nil, err
+		}
+
+		var found *Record = nil
+		for _, record := range records {
+			if strings.EqualFold(record.Name, name) {
+				found = record
+				break
+			}
+		}
+
+		if found == nil {
+			return nil, fmt.Errorf("File %s not found in ISO", p)
+		}
+		current = found
+	}
+
+	return current, nil
+}
+
+// NOTE: walk every file and directory depth first, p is absolute path inside ISO (ex: /DAT/pl00.dat)
+func (self *Iso) Walk(stream io.ReadSeeker, fn func(p string, record *Record) error) error {
+	return self.walk(stream, self.Root, fn)
+}
+
+func (self *Iso) walk(stream io.ReadSeeker, root *Record, fn func(p string, record *Record) error) error {
+	visited := map[uint32]bool{root.Lba: true}
+
+	var walk func(dir string, record *Record) error
+	walk = func(dir string, record *Record) error {
+		records, err := self.ReadDir(stream, record)
+		if err != nil {
+			return err
+		}
+
+		for _, child := range records {
+			p := path.Join(dir, child.Name)
+			if err := fn(p, child); err != nil {
+				return err
+			}
+
+			if !child.IsDir || visited[child.Lba] {
+				continue
+			}
+			visited[child.Lba] = true
+
+			if err := walk(p, child); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return walk("/", root)
+}
+
+func New() *Iso {
+	return &Iso{
+		BlockSize:       SectorSize,
+		VolumeSpaceSize: 0,
+		Descriptor:      0,
+		Root:            nil,
+		Supplementary:   []uint64{},
+		Roots:           []*Record{},
+	}
+}
+
+func FromStream(iso *Iso, stream io.ReadSeeker) error {
+	return iso.unmarshal(stream)
+}
+
+func FromPath(iso *Iso, filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return iso.unmarshal(file)
+}
@@ -0,0 +1,475 @@
+package iso9660_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/anasrar/chihuahua/internal/testutils"
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/dat"
+	"github.com/anasrar/chihuahua/pkg/iso9660"
+	"github.com/anasrar/chihuahua/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func bothUint32(b []byte, v uint32) {
+	binary.LittleEndian.PutUint32(b[0:], v)
+	binary.BigEndian.PutUint32(b[4:], v)
+}
+
+func record(name string, lba uint32, size uint32, flags uint8) []byte {
+	length := 33 + len(name)
+	if length%2 != 0 {
+		length++
+	}
+
+	b := make([]byte, length)
+	b[0] = uint8(length)
+	bothUint32(b[2:], lba)
+	bothUint32(b[10:], size)
+	b[25] = flags
+	b[32] = uint8(len(name))
+	copy(b[33:], name)
+
+	return b
+}
+
+func directory(self uint32, parent uint32, records ...[]byte) []byte {
+	b := []byte{}
+	b = append(b, record("\x00", self, iso9660.SectorSize, iso9660.FlagDirectory)...)
+	b = append(b, record("\x01", parent, iso9660.SectorSize, iso9660.FlagDirectory)...)
+	for _, r := range records {
+		b = append(b, r...)
+	}
+
+	return b
+}
+
+// NOTE: sector 18 root, 19 DAT directory, 20 README.TXT, and 21 PL00.DAT
+func fixture(t testing.TB) ([]byte, []byte) {
+	p := dat.New()
+	p.Alignment = 16
+	p.AddEntryFromBytesWithType(bytes.Repeat([]byte{1}, 32), "OMS\x00")
+	p.AddNullEntry()
+	p.AddEntryFromBytesWithType(bytes.Repeat([]byte{2}, 16), "EMS\x00")
+
+	memory := buffer.NewMemory(nil)
+	if err := p.PackToStream(context.Background(), memory, testutils.Noop, testutils.Noop); err != nil {
+		t.Fatal(err)
+	}
+	datData := memory.Bytes()
+
+	sector := int(iso9660.SectorSize)
+	image := make([]byte, sector*22)
+
+	pvd := image[16*sector:]
+	pvd[0] = iso9660.VolumeDescriptorPrimary
+	copy(pvd[1:], iso9660.Identifier)
+	pvd[6] = 1
+	bothUint32(pvd[80:], 22)
+	binary.LittleEndian.PutUint16(pvd[128:], uint16(sector))
+	binary.BigEndian.PutUint16(pvd[130:], uint16(sector))
+	copy(pvd[156:], record("\x00", 18, iso9660.SectorSize, iso9660.FlagDirectory))
+
+	terminator := image[17*sector:]
+	terminator[0] = iso9660.VolumeDescriptorTerminator
+	copy(terminator[1:], iso9660.Identifier)
+	terminator[6] = 1
+
+	copy(image[18*sector:], directory(18, 18,
+		record("DAT", 19, iso9660.SectorSize, iso9660.FlagDirectory),
+		record("README.TXT;1", 20, 5, 0),
+	))
+	copy(image[19*sector:], directory(19, 18,
+		record("PL00.DAT;1", 21, uint32(len(datData)), 0),
+	))
+	copy(image[20*sector:], "hello")
+	copy(image[21*sector:], datData)
+
+	return image, datData
+}
+
+func Test(t *testing.T) {
+	image, datData := fixture(t)
+
+	iso := iso9660.New()
+	if err := iso9660.FromStream(iso, bytes.NewReader(image)); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, iso9660.SectorSize, iso.BlockSize)
+	assert.Equal(t, uint32(22), iso.VolumeSpaceSize)
+	assert.Equal(t, uint64(16*iso9660.SectorSize), iso.Descriptor)
+	assert.Equal(t, uint32(18), iso.Root.Lba)
+
+	record, err := iso.Find(bytes.NewReader(image), "\\dat\\pl00.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "PL00.DAT", record.Name)
+	assert.Equal(t, uint32(21), record.Lba)
+	assert.Equal(t, uint32(len(datData)), record.Size)
+
+	_, err = iso.Find(bytes.NewReader(image), "/DAT/PL01.DAT")
+	assert.EqualError(t, err, "File /DAT/PL01.DAT not found in ISO")
+
+	paths := []string{}
+	if err := iso.Walk(bytes.NewReader(image), func(p string, record *iso9660.Record) error {
+		paths = append(paths, p)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"/DAT", "/DAT/PL00.DAT", "/README.TXT"}, paths)
+
+	t.Run("invalid", func(t *testing.T) {
+		broken := bytes.Clone(image)
+		copy(broken[16*iso9660.SectorSize+1:], "CD002")
+
+		assert.Error(t, iso9660.FromStream(iso9660.New(), bytes.NewReader(broken)))
+	})
+
+	t.Run("no primary", func(t *testing.T) {
+		broken := bytes.Clone(image)
+		broken[16*iso9660.SectorSize] = 3
+
+		err := iso9660.FromStream(iso9660.New(), bytes.NewReader(broken))
+
+		var parseErr *buffer.ParseError
+		assert.True(t, errors.As(err, &parseErr))
+		assert.Equal(t, "descriptor.Type", parseErr.Field)
+		assert.ErrorIs(t, err, buffer.ErrInvalid)
+	})
+}
+
+func TestOpen(t *testing.T) {
+	image, datData := fixture(t)
+
+	dir := t.TempDir()
+	isoPath := filepath.Join(dir, "game.iso")
+	if err := os.WriteFile(isoPath, image, 0644); err != nil {
+		t.Fatal(err)
+	}
+	datPath := isoPath + ":/DAT/pl00.dat"
+
+	assert.Equal(t, dir, utils.ParentDirectory(datPath))
+	assert.Equal(t, "pl00.dat", utils.Basename(datPath))
+
+	data, err := iso9660.ReadFile(datPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, datData, data)
+
+	size, err := iso9660.Size(datPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint64(len(datData)), size)
+
+	_, err = iso9660.Open(isoPath + ":/DAT")
+	assert.EqualError(t, err, "/DAT is directory")
+
+	t.Run("dat", func(t *testing.T) {
+		d := dat.New()
+		if err := dat.FromPath(d, datPath); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, uint32(3), d.EntryTotal)
+
+		output := t.TempDir()
+		if err := d.Unpack(context.Background(), output, testutils.Noop, testutils.Noop); err != nil {
+			t.Fatal(err)
+		}
+
+		oms, err := os.ReadFile(filepath.Join(output, "OMS", "OMS_000.oms"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, bytes.Repeat([]byte{1}, 32), oms)
+
+		packed := filepath.Join(output, "OUTPUT.dat")
+		if err := d.Load(bytes.NewReader(datData)); err != nil {
+			t.Fatal(err)
+		}
+		if err := d.Pack(context.Background(), packed, testutils.Noop, testutils.Noop); err != nil {
+			t.Fatal(err)
+		}
+		assert.Nil(t, dat.Verify(packed, datPath))
+	})
+}
+
+func TestRebuild(t *testing.T) {
+	image, _ := fixture(t)
+
+	dir := t.TempDir()
+	isoPath := filepath.Join(dir, "game.iso")
+	if err := os.WriteFile(isoPath, image, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	replace := filepath.Join(dir, "replace")
+	if err := os.MkdirAll(filepath.Join(replace, "dat"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	readme := []byte("bye")
+	datData := bytes.Repeat([]byte{3}, int(iso9660.SectorSize)+1)
+	if err := os.WriteFile(filepath.Join(replace, "README.TXT"), readme, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(replace, "dat", "pl00.dat"), datData, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	replacements, err := iso9660.ReplacementsFromDir(replace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "/README.TXT", replacements[0].Path)
+	assert.Equal(t, "/dat/pl00.dat", replacements[1].Path)
+
+	outputPath := filepath.Join(dir, "PATCHED_game.iso")
+	if err := iso9660.RebuildToPath(context.Background(), isoPath, replacements, outputPath, iso9660.PatchFormatIso, testutils.Noop, testutils.Noop); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 24*int(iso9660.SectorSize), len(output))
+
+	iso := iso9660.New()
+	if err := iso9660.FromStream(iso, bytes.NewReader(output)); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint32(24), iso.VolumeSpaceSize)
+
+	record, err := iso.Find(bytes.NewReader(output), "/README.TXT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint32(20), record.Lba)
+	assert.Equal(t, uint32(len(readme)), record.Size)
+
+	record, err = iso.Find(bytes.NewReader(output), "/DAT/PL00.DAT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint32(22), record.Lba)
+	assert.Equal(t, uint32(len(datData)), record.Size)
+
+	data, err := iso9660.ReadFile(outputPath + ":/README.TXT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, readme, data)
+	assert.Equal(t, make([]byte, int(iso9660.SectorSize)-len(readme)), output[20*int(iso9660.SectorSize)+len(readme):21*int(iso9660.SectorSize)])
+
+	data, err = iso9660.ReadFile(outputPath + ":/DAT/PL00.DAT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, datData, data)
+
+	t.Run("padding", func(t *testing.T) {
+		// NOTE: 2 empty blocks after volume space, file that still fit does not change volume space size
+		padded := append(bytes.Clone(image), make([]byte, 2*int(iso9660.SectorSize))...)
+		paddedPath := filepath.Join(dir, "padded.iso")
+		if err := os.WriteFile(paddedPath, padded, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := iso9660.RebuildToPath(
+			context.Background(),
+			paddedPath,
+			replacements[:1],
+			outputPath,
+			iso9660.PatchFormatIso,
+			testutils.Noop,
+			testutils.Noop,
+		); err != nil {
+			t.Fatal(err)
+		}
+
+		output, err := os.ReadFile(outputPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, len(padded), len(output))
+
+		descriptor := 16*int(iso9660.SectorSize) + 80
+		assert.Equal(t, padded[descriptor:descriptor+8], output[descriptor:descriptor+8])
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		err := iso9660.RebuildToPath(
+			context.Background(),
+			isoPath,
+			[]*iso9660.Replacement{{Path: "/DAT/PL01.DAT", Source: filepath.Join(replace, "README.TXT")}},
+			outputPath,
+			iso9660.PatchFormatIso,
+			testutils.Noop,
+			testutils.Noop,
+		)
+		assert.EqualError(t, err, "File /DAT/PL01.DAT not found in ISO")
+	})
+}
+
+// NOTE: minimal PPF 3.0 applier, only record without undo data
+func applyPpf(t *testing.T, source []byte, patch []byte) []byte {
+	assert.Equal(t, iso9660.PpfMagic, string(patch[:5]))
+
+	result := bytes.Clone(source)
+	for offset := 60; offset < len(patch); {
+		position := int(binary.LittleEndian.Uint64(patch[offset:]))
+		length := int(patch[offset+8])
+		if end := position + length; end > len(result) {
+			result = append(result, make([]byte, end-len(result))...)
+		}
+		copy(result[position:], patch[offset+9:offset+9+length])
+		offset += 9 + length
+	}
+
+	return result
+}
+
+// NOTE: minimal VCDIFF decoder, only instruction written by WriteXdelta
+func applyXdelta(t *testing.T, source []byte, patch []byte) []byte {
+	assert.Equal(t, iso9660.VcdiffMagic, patch[:4])
+
+	offset := 5
+	varint := func(b []byte, i *int) int {
+		value := 0
+		for {
+			value = value<<7 | int(b[*i]&0x7F)
+			*i++
+			if b[*i-1]&0x80 == 0 {
+				return value
+			}
+		}
+	}
+
+	result := []byte{}
+	for offset < len(patch) {
+		indicator := patch[offset]
+		offset++
+
+		segment := []byte{}
+		if indicator&iso9660.VcdiffSource != 0 {
+			size := varint(patch, &offset)
+			position := varint(patch, &offset)
+			segment = source[position : position+size]
+		}
+
+		varint(patch, &offset)
+		targetSize := varint(patch, &offset)
+		offset++
+		dataSize := varint(patch, &offset)
+		instructionSize := varint(patch, &offset)
+		addressSize := varint(patch, &offset)
+
+		data := patch[offset : offset+dataSize]
+		instructions := patch[offset+dataSize : offset+dataSize+instructionSize]
+		addresses := patch[offset+dataSize+instructionSize : offset+dataSize+instructionSize+addressSize]
+		offset += dataSize + instructionSize + addressSize
+
+		window := []byte{}
+		dataOffset, addressOffset := 0, 0
+		for i := 0; i < len(instructions); {
+			instruction := instructions[i]
+			i++
+			size := varint(instructions, &i)
+
+			switch instruction {
+			case iso9660.VcdiffAdd:
+				window = append(window, data[dataOffset:dataOffset+size]...)
+				dataOffset += size
+			case iso9660.VcdiffCopy:
+				address := varint(addresses, &addressOffset)
+				window = append(window, segment[address:address+size]...)
+			default:
+				t.Fatalf("unexpected instruction %d", instruction)
+			}
+		}
+
+		assert.Equal(t, targetSize, len(window))
+		result = append(result, window...)
+	}
+
+	return result
+}
+
+func TestPatch(t *testing.T) {
+	chunk := int(iso9660.PatchChunkSize)
+
+	source := make([]byte, chunk*2+100)
+	for i := range source {
+		source[i] = uint8(i * 7 % 251)
+	}
+
+	target := append(bytes.Clone(source), bytes.Repeat([]byte{9}, chunk+300)...)
+	copy(target[10:], "changed")
+	copy(target[20:], "gap")
+	target[chunk-1] = 0xFF
+	target[chunk] = 0xFF
+	copy(target[chunk+1000:], bytes.Repeat([]byte{0xAA}, 600))
+
+	assert.Equal(t, iso9660.PatchFormatXdelta, iso9660.PatchFormatFromString("xdelta"))
+	assert.Equal(t, iso9660.PatchFormatPpf, iso9660.PatchFormatFromPath("game.ppf"))
+	assert.Equal(t, ".xdelta", iso9660.PatchFormatXdelta.Ext())
+
+	t.Run("ppf", func(t *testing.T) {
+		patch := buffer.NewMemory(nil)
+		if err := iso9660.WritePatch(iso9660.PatchFormatPpf, bytes.NewReader(source), bytes.NewReader(target), patch, "game.iso"); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, target, applyPpf(t, source, patch.Bytes()))
+	})
+
+	t.Run("xdelta", func(t *testing.T) {
+		patch := buffer.NewMemory(nil)
+		if err := iso9660.WritePatch(iso9660.PatchFormatXdelta, bytes.NewReader(source), bytes.NewReader(target), patch, "game.iso"); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, target, applyXdelta(t, source, patch.Bytes()))
+		assert.Less(t, len(patch.Bytes()), chunk+4096)
+
+		// NOTE: applyXdelta only decode instruction written by WritePatch, xdelta3 check the patch is valid VCDIFF
+		t.Run("xdelta3", func(t *testing.T) {
+			xdelta3, err := exec.LookPath("xdelta3")
+			if err != nil {
+				t.Skip("xdelta3 not found in PATH")
+			}
+
+			dir := t.TempDir()
+			sourcePath := filepath.Join(dir, "source.iso")
+			patchPath := filepath.Join(dir, "patch.xdelta")
+			targetPath := filepath.Join(dir, "target.iso")
+			if err := os.WriteFile(sourcePath, source, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := os.WriteFile(patchPath, patch.Bytes(), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if output, err := exec.Command(xdelta3, "-d", "-f", "-s", sourcePath, patchPath, targetPath).CombinedOutput(); err != nil {
+				t.Fatalf("%v: %s", err, output)
+			}
+
+			result, err := os.ReadFile(targetPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, target, result)
+		})
+	})
+}
@@ -0,0 +1,286 @@
+package iso9660
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+type PatchFormat uint8
+
+const (
+	PatchFormatUnknown PatchFormat = iota
+	PatchFormatIso
+	PatchFormatXdelta
+	PatchFormatPpf
+)
+
+func (self PatchFormat) String() string {
+	switch self {
+	case PatchFormatIso:
+		return "ISO"
+	case PatchFormatXdelta:
+		return "XDELTA"
+	case PatchFormatPpf:
+		return "PPF"
+	default:
+		return "Unknown"
+	}
+}
+
+func (self PatchFormat) Ext() string {
+	switch self {
+	case PatchFormatIso:
+		return ".iso"
+	case PatchFormatXdelta:
+		return ".xdelta"
+	case PatchFormatPpf:
+		return ".ppf"
+	default:
+		return ""
+	}
+}
+
+func PatchFormatFromString(str string) PatchFormat {
+	switch strings.ToUpper(str) {
+	case "ISO":
+		return PatchFormatIso
+	case "XDELTA", "XDELTA3", "VCDIFF":
+		return PatchFormatXdelta
+	case "PPF":
+		return PatchFormatPpf
+	default:
+		return PatchFormatUnknown
+	}
+}
+
+func PatchFormatFromPath(p string) PatchFormat {
+	return PatchFormatFromString(strings.TrimPrefix(filepath.Ext(p), "."))
+}
+
+const (
+	PatchChunkSize uint64 = 1024 * 1024 // NOTE: source and target is compared per chunk, also VCDIFF window size
+
+	PpfDescriptionSize = 50
+	PpfRecordMaxSize   = 255
+	PpfGapSize         = 8 // NOTE: equal bytes shorter than this is merged into record, cheaper than new record header
+
+	VcdiffCopyMinSize       = 32 // NOTE: equal bytes shorter than this is added as data
+	VcdiffSource      uint8 = 0x01
+	VcdiffAdd         uint8 = 1  // NOTE: default code table, ADD with size after instruction
+	VcdiffCopy        uint8 = 19 // NOTE: default code table, COPY mode 0 (VCD_SELF) with size after instruction
+)
+
+var (
+	PpfMagic    = "PPF30"
+	VcdiffMagic = []byte{0xD6, 0xC3, 0xC4, 0x00}
+)
+
+// NOTE: fill chunk until full or end of stream, missing byte mean stream is shorter
+func readChunk(stream io.Reader, chunk []byte) (int, error) {
+	n, err := io.ReadFull(stream, chunk)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return n, nil
+	}
+
+	return n, err
+}
+
+// NOTE: PPF 3.0 without block check and undo data. Byte after end of source is always written so patched image has the same size
+func WritePpf(source io.Reader, target io.Reader, output io.Writer, description string) error {
+	header := make([]byte, 60)
+	copy(header, PpfMagic)
+	header[5] = 2 // NOTE: encoding method, 2 is PPF 3.0
+	copy(header[6:], strings.Repeat(" ", PpfDescriptionSize))
+	copy(header[6:6+PpfDescriptionSize], description)
+	if _, err := output.Write(header); err != nil {
+		return err
+	}
+
+	sourceChunk := make([]byte, PatchChunkSize)
+	targetChunk := make([]byte, PatchChunkSize)
+	record := make([]byte, 9+PpfRecordMaxSize)
+
+	for offset := uint64(0); ; offset += PatchChunkSize {
+		sourceSize, err := readChunk(source, sourceChunk)
+		if err != nil {
+			return err
+		}
+
+		targetSize, err := readChunk(target, targetChunk)
+		if err != nil {
+			return err
+		}
+
+		if targetSize == 0 {
+			break
+		}
+
+		differ := func(i int) bool {
+			return i >= sourceSize || sourceChunk[i] != targetChunk[i]
+		}
+
+		for i := 0; i < targetSize; {
+			if !differ(i) {
+				i++
+				continue
+			}
+
+			end := i + 1
+			for end < targetSize && end-i < PpfRecordMaxSize {
+				if differ(end) {
+					end++
+					continue
+				}
+
+				next := end
+				for next < targetSize && next-end < PpfGapSize && !differ(next) {
+					next++
+				}
+
+				if next == targetSize || next-end == PpfGapSize || next-i >= PpfRecordMaxSize {
+					break
+				}
+				end = next
+			}
+
+			binary.LittleEndian.PutUint64(record, offset+uint64(i))
+			record[8] = uint8(end - i)
+			copy(record[9:], targetChunk[i:end])
+			if _, err := output.Write(record[:9+end-i]); err != nil {
+				return err
+			}
+
+			i = end
+		}
+
+		if targetSize < len(targetChunk) {
+			break
+		}
+	}
+
+	return nil
+}
+
+// NOTE: base 128 big endian, every byte except last has continuation bit
+func appendVarint(b []byte, value uint64) []byte {
+	digits := []byte{uint8(value & 0x7F)}
+	for value >>= 7; value > 0; value >>= 7 {
+		digits = append(digits, uint8(value&0x7F)|0x80)
+	}
+
+	for i := len(digits) - 1; i >= 0; i-- {
+		b = append(b, digits[i])
+	}
+
+	return b
+}
+
+// NOTE: VCDIFF (RFC 3284) that can be applied with xdelta3, one window per chunk and window only copy from the same
+// offset in source. Data is not compressed, compress patch file if size matter
+func WriteXdelta(source io.Reader, target io.Reader, output io.Writer) error {
+	if _, err := output.Write(append(VcdiffMagic, 0)); err != nil {
+		return err
+	}
+
+	sourceChunk := make([]byte, PatchChunkSize)
+	targetChunk := make([]byte, PatchChunkSize)
+
+	for offset := uint64(0); ; offset += PatchChunkSize {
+		sourceSize, err := readChunk(source, sourceChunk)
+		if err != nil {
+			return err
+		}
+
+		targetSize, err := readChunk(target, targetChunk)
+		if err != nil {
+			return err
+		}
+
+		if targetSize == 0 {
+			break
+		}
+
+		segmentSize := min(sourceSize, targetSize)
+
+		data := []byte{}
+		instructions := []byte{}
+		addresses := []byte{}
+
+		add := func(start int, end int) {
+			if start == end {
+				return
+			}
+
+			data = append(data, targetChunk[start:end]...)
+			instructions = append(instructions, VcdiffAdd)
+			instructions = appendVarint(instructions, uint64(end-start))
+		}
+
+		pending := 0
+		for i := 0; i < segmentSize; {
+			if sourceChunk[i] != targetChunk[i] {
+				i++
+				continue
+			}
+
+			end := i
+			for end < segmentSize && sourceChunk[end] == targetChunk[end] {
+				end++
+			}
+
+			if end-i >= VcdiffCopyMinSize {
+				add(pending, i)
+				instructions = append(instructions, VcdiffCopy)
+				instructions = appendVarint(instructions, uint64(end-i))
+				addresses = appendVarint(addresses, uint64(i))
+				pending = end
+			}
+
+			i = end
+		}
+		add(pending, targetSize)
+
+		delta := appendVarint([]byte{}, uint64(targetSize))
+		delta = append(delta, 0) // NOTE: delta indicator, no secondary compression
+		delta = appendVarint(delta, uint64(len(data)))
+		delta = appendVarint(delta, uint64(len(instructions)))
+		delta = appendVarint(delta, uint64(len(addresses)))
+
+		window := []byte{0}
+		if segmentSize > 0 {
+			window[0] = VcdiffSource
+			window = appendVarint(window, uint64(segmentSize))
+			window = appendVarint(window, offset)
+		}
+		window = appendVarint(window, uint64(len(delta)+len(data)+len(instructions)+len(addresses)))
+
+		for _, b := range [][]byte{window, delta, data, instructions, addresses} {
+			if _, err := output.Write(b); err != nil {
+				return err
+			}
+		}
+
+		if targetSize < len(targetChunk) {
+			break
+		}
+	}
+
+	return nil
+}
+
+func WritePatch(format PatchFormat, source io.Reader, target io.Reader, output io.Writer, description string) error {
+	switch format {
+	case PatchFormatIso:
+		_, err := io.Copy(output, target)
+		return err
+	case PatchFormatXdelta:
+		return WriteXdelta(source, target, output)
+	case PatchFormatPpf:
+		return WritePpf(source, target, output, description)
+	default:
+		return fmt.Errorf("Unknown patch format %s", format)
+	}
+}
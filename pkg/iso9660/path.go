@@ -0,0 +1,104 @@
+package iso9660
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/anasrar/chihuahua/pkg/utils"
+)
+
+// NOTE: file on disk or file inside ISO, ReadAt is safe for concurrent use
+type File interface {
+	io.ReadSeeker
+	io.ReaderAt
+	io.Closer
+}
+
+type isoFile struct {
+	*io.SectionReader
+	file *os.File
+}
+
+func (self *isoFile) Close() error {
+	return self.file.Close()
+}
+
+func find(isoPath string, inner string) (*os.File, *Iso, *Record, error) {
+	file, err := os.Open(isoPath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	iso := New()
+	if err := FromStream(iso, file); err != nil {
+		file.Close()
+		return nil, nil, nil, err
+	}
+
+	record, err := iso.Find(file, inner)
+	if err != nil {
+		file.Close()
+		return nil, nil, nil, err
+	}
+
+	if record.IsDir {
+		file.Close()
+		return nil, nil, nil, fmt.Errorf("%s is directory", inner)
+	}
+
+	return file, iso, record, nil
+}
+
+// NOTE: open file on disk or file inside ISO (game.iso:/DAT/pl00.dat) without extracting it
+func Open(p string) (File, error) {
+	isoPath, inner, found := utils.SplitIsoPath(p)
+	if !found {
+		file, err := os.Open(p)
+		if err != nil {
+			return nil, err
+		}
+
+		return file, nil
+	}
+
+	file, iso, record, err := find(isoPath, inner)
+	if err != nil {
+		return nil, err
+	}
+
+	return &isoFile{
+		SectionReader: io.NewSectionReader(file, int64(record.Offset(iso.BlockSize)), int64(record.Size)),
+		file:          file,
+	}, nil
+}
+
+func Size(p string) (uint64, error) {
+	isoPath, inner, found := utils.SplitIsoPath(p)
+	if !found {
+		info, err := os.Stat(p)
+		if err != nil {
+			return 0, err
+		}
+
+		return uint64(info.Size()), nil
+	}
+
+	file, _, record, err := find(isoPath, inner)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	return uint64(record.Size), nil
+}
+
+func ReadFile(p string) ([]byte, error) {
+	file, err := Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
@@ -0,0 +1,293 @@
+package iso9660
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anasrar/chihuahua/pkg/buffer"
+)
+
+type Replacement struct {
+	Path   string `json:"path"`   // NOTE: path inside ISO
+	Source string `json:"source"` // NOTE: file on disk
+}
+
+// NOTE: every file in dir replace file with the same path inside ISO (ex: dir/DAT/pl00.dat replace /DAT/pl00.dat)
+func ReplacementsFromDir(dir string) ([]*Replacement, error) {
+	replacements := []*Replacement{}
+	if err := filepath.WalkDir(dir, func(p string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		relative, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+
+		replacements = append(replacements, &Replacement{
+			Path:   "/" + filepath.ToSlash(relative),
+			Source: p,
+		})
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return replacements, nil
+}
+
+func blocks(size uint64, blockSize uint32) uint64 {
+	return (size + uint64(blockSize) - 1) / uint64(blockSize)
+}
+
+func writeBoth32(output io.WriteSeeker, position uint64, value uint32) error {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint32(b[0:], value)
+	binary.BigEndian.PutUint32(b[4:], value)
+
+	if _, err := buffer.Seek(output, int64(position), buffer.SeekStart); err != nil {
+		return err
+	}
+
+	if _, err := buffer.WriteBytes(output, b); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// NOTE: position of every file record grouped by LBA, from primary and supplementary (Joliet) tree
+func (self *Iso) filePositions(stream io.ReadSeeker) (map[uint32][]uint64, error) {
+	result := map[uint32][]uint64{}
+	for _, root := range append([]*Record{self.Root}, self.Roots...) {
+		if err := self.walk(stream, root, func(p string, record *Record) error {
+			if !record.IsDir {
+				result[record.Lba] = append(result[record.Lba], record.Position)
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// NOTE: original image is copied to output, replaced file is written in place when it still fit in its blocks,
+// otherwise it is moved to the end of image. Directory record (LBA and size) and volume space size are updated,
+// path table only reference directory so it is not changed
+func (self *Iso) Rebuild(
+	ctx context.Context,
+	stream io.ReadSeeker,
+	replacements []*Replacement,
+	output io.WriteSeeker,
+	onStart,
+	onDone func(total uint32, current uint32, name string),
+) error {
+	positions, err := self.filePositions(stream)
+	if err != nil {
+		return err
+	}
+
+	records := []*Record{}
+	for _, replacement := range replacements {
+		record, err := self.Find(stream, replacement.Path)
+		if err != nil {
+			return err
+		}
+
+		if record.IsDir {
+			return fmt.Errorf("%s is directory", replacement.Path)
+		}
+		records = append(records, record)
+	}
+
+	if _, err := buffer.Seek(stream, 0, buffer.SeekStart); err != nil {
+		return err
+	}
+
+	size, err := io.Copy(output, stream)
+	if err != nil {
+		return err
+	}
+
+	// NOTE: image can be bigger than volume space (UDF bridge or padding), moved file is written after both
+	next := max(uint64(self.VolumeSpaceSize), blocks(uint64(size), self.BlockSize))
+	relocated := false
+	total := uint32(len(replacements))
+
+	for i, replacement := range replacements {
+		record := records[i]
+		name := strings.TrimPrefix(replacement.Path, "/")
+
+		onStart(total, uint32(i+1), name)
+
+		sourceFile, err := os.Open(replacement.Source)
+		if err != nil {
+			return err
+		}
+
+		info, err := sourceFile.Stat()
+		if err != nil {
+			sourceFile.Close()
+			return err
+		}
+
+		sourceSize := uint64(info.Size())
+		if sourceSize > math.MaxUint32 {
+			sourceFile.Close()
+			return fmt.Errorf("%s is bigger than 4 GiB", replacement.Source)
+		}
+
+		lba := uint64(record.Lba)
+		used := blocks(sourceSize, self.BlockSize)
+		old := blocks(uint64(record.Size), self.BlockSize)
+		if used > old {
+			lba = next
+			next += used
+			old = used
+			relocated = true
+		}
+
+		if lba > math.MaxUint32 {
+			sourceFile.Close()
+			return fmt.Errorf("ISO is bigger than %d blocks", uint32(math.MaxUint32))
+		}
+
+		if _, err := buffer.Seek(output, int64(lba*uint64(self.BlockSize)), buffer.SeekStart); err != nil {
+			sourceFile.Close()
+			return err
+		}
+
+		_, err = io.Copy(output, sourceFile)
+		sourceFile.Close()
+		if err != nil {
+			return err
+		}
+
+		// NOTE: clear rest of old blocks so old data is not left in image
+		if _, err := buffer.WriteBytes(output, make([]byte, old*uint64(self.BlockSize)-sourceSize)); err != nil {
+			return err
+		}
+
+		// NOTE: empty file can share LBA with other file, only its own record is updated
+		linked := []uint64{record.Position}
+		if record.Size != 0 {
+			linked = positions[record.Lba]
+		}
+
+		for _, position := range linked {
+			if err := writeBoth32(output, position+2, uint32(lba)); err != nil {
+				return err
+			}
+
+			if err := writeBoth32(output, position+10, uint32(sourceSize)); err != nil {
+				return err
+			}
+		}
+
+		onDone(total, uint32(i+1), name)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("Canceled")
+		default:
+		}
+	}
+
+	// NOTE: volume space size only change when file is moved past it, padding after volume space is not counted
+	// when every file still fit in its blocks
+	if relocated {
+		for _, descriptor := range append([]uint64{self.Descriptor}, self.Supplementary...) {
+			if err := writeBoth32(output, descriptor+80, uint32(next)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// NOTE: patch format need original image to diff with, rebuilt image is written to temporary file next to output first
+func RebuildToPath(
+	ctx context.Context,
+	isoPath string,
+	replacements []*Replacement,
+	outputPath string,
+	format PatchFormat,
+	onStart,
+	onDone func(total uint32, current uint32, name string),
+) error {
+	if format == PatchFormatUnknown {
+		return fmt.Errorf("Unknown patch format %s", format)
+	}
+
+	if filepath.Clean(isoPath) == filepath.Clean(outputPath) {
+		return fmt.Errorf("Output must be different from ISO")
+	}
+
+	isoFile, err := os.Open(isoPath)
+	if err != nil {
+		return err
+	}
+	defer isoFile.Close()
+
+	iso := New()
+	if err := FromStream(iso, isoFile); err != nil {
+		return err
+	}
+
+	if format == PatchFormatIso {
+		outputFile, err := os.OpenFile(outputPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		if err != nil {
+			return err
+		}
+		defer outputFile.Close()
+
+		return iso.Rebuild(ctx, isoFile, replacements, outputFile, onStart, onDone)
+	}
+
+	tempFile, err := os.CreateTemp(filepath.Dir(outputPath), "*.iso")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	if err := iso.Rebuild(ctx, isoFile, replacements, tempFile, onStart, onDone); err != nil {
+		return err
+	}
+
+	for _, stream := range []io.Seeker{isoFile, tempFile} {
+		if _, err := buffer.Seek(stream, 0, buffer.SeekStart); err != nil {
+			return err
+		}
+	}
+
+	outputFile, err := os.OpenFile(outputPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer outputFile.Close()
+
+	// NOTE: PPF record is small, buffered so it is not one syscall per record
+	writer := bufio.NewWriter(outputFile)
+	if err := WritePatch(format, bufio.NewReader(isoFile), bufio.NewReader(tempFile), writer, filepath.Base(isoPath)); err != nil {
+		return err
+	}
+
+	return writer.Flush()
+}
@@ -0,0 +1,22 @@
+package mdb_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/anasrar/chihuahua/pkg/mdb"
+)
+
+func FuzzFromStream(f *testing.F) {
+	seed := binary.LittleEndian.AppendUint32(nil, mdb.Signature)
+	f.Add(seed)
+	f.Add(append(seed, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF))
+	f.Add(append(seed, make([]byte, 128)...))
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		d := mdb.New()
+		_ = mdb.FromStream(d, bytes.NewReader(data))
+	})
+}
@@ -1,14 +1,13 @@
 package mdb
 
 import (
-	"fmt"
 	"io"
 	"math"
-	"os"
 	"strconv"
 
 	"github.com/anasrar/chihuahua/pkg/bone"
 	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/iso9660"
 )
 
 const (
@@ -24,80 +23,96 @@ type Mdb struct {
 	VertexBuffers     []*VertexBuffer `json:"vertex_buffers"`
 }
 
-func (self *Mdb) unmarshal(stream io.ReadWriteSeeker) error {
-	if _, err := buffer.Seek(stream, int64(self.Offset), buffer.SeekStart); err != nil {
+func (self *Mdb) unmarshal(stream io.ReadSeeker) error {
+	cursor := buffer.NewCursorLE(stream, "MDB")
+
+	if _, err := cursor.Move(int64(self.Offset), buffer.SeekStart); err != nil {
 		return err
 	}
 
 	signature := uint32(0)
-	if _, err := buffer.ReadUint32LE(stream, &signature); err != nil {
+	if _, err := cursor.ReadUint32("signature", &signature); err != nil {
 		return err
 	}
 
 	if signature != Signature {
-		return fmt.Errorf("MDB signature not match")
+		return cursor.Mismatch("signature", Signature, signature)
 	}
 
 	boneOffset := uint32(0)
-	if _, err := buffer.ReadUint32LE(stream, &boneOffset); err != nil {
+	if _, err := cursor.ReadUint32("boneOffset", &boneOffset); err != nil {
 		return err
 	}
 	boneOffset += self.Offset
 
-	if _, err := buffer.ReadUint16LE(stream, &self.BoneTotal); err != nil {
+	if _, err := cursor.ReadUint16("BoneTotal", &self.BoneTotal); err != nil {
 		return err
 	}
 
-	if _, err := buffer.ReadUint16LE(stream, &self.VertexBufferTotal); err != nil {
+	if _, err := cursor.ReadUint16("VertexBufferTotal", &self.VertexBufferTotal); err != nil {
 		return err
 	}
 
 	// TODO: research this padding
-	if _, err := buffer.Seek(stream, 18, buffer.SeekCurrent); err != nil {
+	if _, err := cursor.Move(18, buffer.SeekCurrent); err != nil {
 		return err
 	}
 
 	flag := uint16(0)
-	if _, err := buffer.ReadUint16LE(stream, &flag); err != nil {
+	if _, err := cursor.ReadUint16("flag", &flag); err != nil {
 		return err
 	}
 	isRoom := flag == 0
 
+	if err := cursor.Require("VertexBufferTotal", uint64(self.VertexBufferTotal)*4); err != nil {
+		return err
+	}
+
 	vertexBufferOffsets := []uint64{}
 	vertexBufferOffset := uint32(0)
 	for range self.VertexBufferTotal {
-		if _, err := buffer.ReadUint32LE(stream, &vertexBufferOffset); err != nil {
+		if _, err := cursor.ReadUint32("vertexBufferOffset", &vertexBufferOffset); err != nil {
 			return err
 		}
 		vertexBufferOffsets = append(vertexBufferOffsets, uint64(self.Offset+vertexBufferOffset))
 	}
 
-	if _, err := buffer.Seek(stream, int64(boneOffset), buffer.SeekStart); err != nil {
+	if _, err := cursor.Move(int64(boneOffset), buffer.SeekStart); err != nil {
+		return err
+	}
+
+	// NOTE: position (float[3]) + padding (2) + parent (int16)
+	if err := cursor.Require("BoneTotal", uint64(self.BoneTotal)*16); err != nil {
 		return err
 	}
+
 	for i := range self.BoneTotal {
 		x := float32(0)
-		if _, err := buffer.ReadFloat32LE(stream, &x); err != nil {
+		if _, err := cursor.ReadFloat32("x", &x); err != nil {
 			return err
 		}
 		y := float32(0)
-		if _, err := buffer.ReadFloat32LE(stream, &y); err != nil {
+		if _, err := cursor.ReadFloat32("y", &y); err != nil {
 			return err
 		}
 		z := float32(0)
-		if _, err := buffer.ReadFloat32LE(stream, &z); err != nil {
+		if _, err := cursor.ReadFloat32("z", &z); err != nil {
 			return err
 		}
 		// TODO: research this padding
-		if _, err := buffer.Seek(stream, 2, buffer.SeekCurrent); err != nil {
+		if _, err := cursor.Move(2, buffer.SeekCurrent); err != nil {
 			return err
 		}
 		parent := int16(0)
-		if _, err := buffer.ReadInt16LE(stream, &parent); err != nil {
+		if _, err := cursor.ReadInt16("parent", &parent); err != nil {
 			return err
 		}
 		parent += 1
 
+		if parent < 0 || parent > int16(self.BoneTotal) {
+			return cursor.Invalid("parent", "parent %d is outside of %d bones", parent-1, self.BoneTotal)
+		}
+
 		self.Bones = append(
 			self.Bones,
 			bone.New(
@@ -115,42 +130,42 @@ func (self *Mdb) unmarshal(stream io.ReadWriteSeeker) error {
 	}
 
 	for _, vertexBufferOffset := range vertexBufferOffsets {
-		if _, err := buffer.Seek(stream, int64(vertexBufferOffset), buffer.SeekStart); err != nil {
+		if _, err := cursor.Move(int64(vertexBufferOffset), buffer.SeekStart); err != nil {
 			return err
 		}
 
 		positionsOffset := uint32(0)
-		if _, err := buffer.ReadUint32LE(stream, &positionsOffset); err != nil {
+		if _, err := cursor.ReadUint32("positionsOffset", &positionsOffset); err != nil {
 			return err
 		}
 
 		normalsOffset := uint32(0)
-		if _, err := buffer.ReadUint32LE(stream, &normalsOffset); err != nil {
+		if _, err := cursor.ReadUint32("normalsOffset", &normalsOffset); err != nil {
 			return err
 		}
 
 		uvsOffset := uint32(0)
-		if _, err := buffer.ReadUint32LE(stream, &uvsOffset); err != nil {
+		if _, err := cursor.ReadUint32("uvsOffset", &uvsOffset); err != nil {
 			return err
 		}
 
 		colorsOffset := uint32(0)
-		if _, err := buffer.ReadUint32LE(stream, &colorsOffset); err != nil {
+		if _, err := cursor.ReadUint32("colorsOffset", &colorsOffset); err != nil {
 			return err
 		}
 
 		weightsOffset := uint32(0)
-		if _, err := buffer.ReadUint32LE(stream, &weightsOffset); err != nil {
+		if _, err := cursor.ReadUint32("weightsOffset", &weightsOffset); err != nil {
 			return err
 		}
 
 		verticesTotal := uint16(0)
-		if _, err := buffer.ReadUint16LE(stream, &verticesTotal); err != nil {
+		if _, err := cursor.ReadUint16("verticesTotal", &verticesTotal); err != nil {
 			return err
 		}
 
 		material := uint16(0)
-		if _, err := buffer.ReadUint16LE(stream, &material); err != nil {
+		if _, err := cursor.ReadUint16("material", &material); err != nil {
 			return err
 		}
 
@@ -164,7 +179,7 @@ func (self *Mdb) unmarshal(stream io.ReadWriteSeeker) error {
 			Material: material,
 		}
 
-		if _, err := buffer.Seek(stream, int64(vertexBufferOffset)+int64(positionsOffset), buffer.SeekStart); err != nil {
+		if _, err := cursor.Move(int64(vertexBufferOffset)+int64(positionsOffset), buffer.SeekStart); err != nil {
 			return err
 		}
 
@@ -173,15 +188,15 @@ func (self *Mdb) unmarshal(stream io.ReadWriteSeeker) error {
 
 			if isRoom {
 				x := int16(0)
-				if _, err := buffer.ReadInt16LE(stream, &x); err != nil {
+				if _, err := cursor.ReadInt16("x", &x); err != nil {
 					return err
 				}
 				y := int16(0)
-				if _, err := buffer.ReadInt16LE(stream, &y); err != nil {
+				if _, err := cursor.ReadInt16("y", &y); err != nil {
 					return err
 				}
 				z := int16(0)
-				if _, err := buffer.ReadInt16LE(stream, &z); err != nil {
+				if _, err := cursor.ReadInt16("z", &z); err != nil {
 					return err
 				}
 
@@ -191,11 +206,13 @@ func (self *Mdb) unmarshal(stream io.ReadWriteSeeker) error {
 				)
 
 				flag := uint16(0)
-				if _, err := buffer.ReadUint16LE(stream, &flag); err != nil {
+				if _, err := cursor.ReadUint16("flag", &flag); err != nil {
 					return err
 				}
 				if flag == 32768 {
 					continue
+				} else if (flag == 0 || flag == 1) && k < 2 {
+					return cursor.Invalid("flag", "vertex %d can not close a triangle", k)
 				} else if flag == 0 {
 					vb.Indices = append(
 						vb.Indices,
@@ -210,26 +227,28 @@ func (self *Mdb) unmarshal(stream io.ReadWriteSeeker) error {
 
 			} else {
 				x := float32(0)
-				if _, err := buffer.ReadFloat32LE(stream, &x); err != nil {
+				if _, err := cursor.ReadFloat32("x", &x); err != nil {
 					return err
 				}
 				y := float32(0)
-				if _, err := buffer.ReadFloat32LE(stream, &y); err != nil {
+				if _, err := cursor.ReadFloat32("y", &y); err != nil {
 					return err
 				}
 				z := float32(0)
-				if _, err := buffer.ReadFloat32LE(stream, &z); err != nil {
+				if _, err := cursor.ReadFloat32("z", &z); err != nil {
 					return err
 				}
 
 				vb.Vertices = append(vb.Vertices, [3]float32{x, y, z})
 
 				flag := int32(0)
-				if _, err := buffer.ReadInt32LE(stream, &flag); err != nil {
+				if _, err := cursor.ReadInt32("flag", &flag); err != nil {
 					return err
 				}
 				if flag == 32768 {
 					continue
+				} else if (flag == 0 || flag == 1) && k < 2 {
+					return cursor.Invalid("flag", "vertex %d can not close a triangle", k)
 				} else if flag == 0 {
 					vb.Indices = append(
 						vb.Indices,
@@ -247,13 +266,13 @@ func (self *Mdb) unmarshal(stream io.ReadWriteSeeker) error {
 		}
 
 		if normalsOffset != 0 {
-			if _, err := buffer.Seek(stream, int64(vertexBufferOffset)+int64(normalsOffset), buffer.SeekStart); err != nil {
+			if _, err := cursor.Move(int64(vertexBufferOffset)+int64(normalsOffset), buffer.SeekStart); err != nil {
 				return err
 			}
 
 			xyzw := make([]byte, 4)
 			for range verticesTotal {
-				if _, err := buffer.ReadBytes(stream, xyzw); err != nil {
+				if _, err := cursor.ReadBytes("xyzw", xyzw); err != nil {
 					return err
 				}
 				x := ((float64(xyzw[0]) / 127.5) - 1) * -1
@@ -271,17 +290,17 @@ func (self *Mdb) unmarshal(stream io.ReadWriteSeeker) error {
 			}
 		}
 
-		if _, err := buffer.Seek(stream, int64(vertexBufferOffset)+int64(uvsOffset), buffer.SeekStart); err != nil {
+		if _, err := cursor.Move(int64(vertexBufferOffset)+int64(uvsOffset), buffer.SeekStart); err != nil {
 			return err
 		}
 
 		for range verticesTotal {
 			_u := int16(0)
-			if _, err := buffer.ReadInt16LE(stream, &_u); err != nil {
+			if _, err := cursor.ReadInt16("u", &_u); err != nil {
 				return err
 			}
 			_v := int16(0)
-			if _, err := buffer.ReadInt16LE(stream, &_v); err != nil {
+			if _, err := cursor.ReadInt16("v", &_v); err != nil {
 				return err
 			}
 
@@ -291,20 +310,20 @@ func (self *Mdb) unmarshal(stream io.ReadWriteSeeker) error {
 		}
 
 		if colorsOffset != 0 {
-			if _, err := buffer.Seek(stream, int64(vertexBufferOffset)+int64(colorsOffset), buffer.SeekStart); err != nil {
+			if _, err := cursor.Move(int64(vertexBufferOffset)+int64(colorsOffset), buffer.SeekStart); err != nil {
 				return err
 			}
 			// TODO: vertex colors
 		}
 
 		if weightsOffset != 0 {
-			if _, err := buffer.Seek(stream, int64(vertexBufferOffset)+int64(weightsOffset), buffer.SeekStart); err != nil {
+			if _, err := cursor.Move(int64(vertexBufferOffset)+int64(weightsOffset), buffer.SeekStart); err != nil {
 				return err
 			}
 
 			xyzw := make([]byte, 4)
 			for range verticesTotal {
-				if _, err := buffer.ReadBytes(stream, xyzw); err != nil {
+				if _, err := cursor.ReadBytes("xyzw", xyzw); err != nil {
 					return err
 				}
 				vb.Joints = append(vb.Joints, [4]byte{
@@ -314,7 +333,7 @@ func (self *Mdb) unmarshal(stream io.ReadWriteSeeker) error {
 					0,
 				})
 
-				if _, err := buffer.ReadBytes(stream, xyzw); err != nil {
+				if _, err := cursor.ReadBytes("xyzw", xyzw); err != nil {
 					return err
 				}
 				vb.Weights = append(vb.Weights, [4]float32{
@@ -340,17 +359,17 @@ func New() *Mdb {
 	}
 }
 
-func FromStreamWithOffset(mdb *Mdb, stream io.ReadWriteSeeker, offset uint32) error {
+func FromStreamWithOffset(mdb *Mdb, stream io.ReadSeeker, offset uint32) error {
 	mdb.Offset = offset
 	return mdb.unmarshal(stream)
 }
 
-func FromStream(mdb *Mdb, stream io.ReadWriteSeeker) error {
+func FromStream(mdb *Mdb, stream io.ReadSeeker) error {
 	return FromStreamWithOffset(mdb, stream, 0)
 }
 
 func FromPathWithOffset(mdb *Mdb, filePath string, offset uint32) error {
-	file, err := os.Open(filePath)
+	file, err := iso9660.Open(filePath)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,67 @@
+package mdb_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/anasrar/chihuahua/internal/testutils"
+	"github.com/anasrar/chihuahua/pkg/mdb"
+	"github.com/stretchr/testify/assert"
+)
+
+// NOTE: model with two bones and one vertex buffer with single triangle
+func fixture() []byte {
+	var buf bytes.Buffer
+
+	// NOTE: header (32) + vertex buffer offset (4) + padding (4)
+	testutils.Write(&buf, mdb.Signature, uint32(40), uint16(2), uint16(1), [18]byte{}, uint16(1))
+	testutils.Write(&buf, uint32(72), uint32(0))
+
+	// NOTE: bones (16), parent -1 is root
+	testutils.Write(&buf, [3]float32{0, 1, 0}, uint16(0), int16(-1))
+	testutils.Write(&buf, [3]float32{0, 2, 0}, uint16(0), int16(0))
+
+	// NOTE: vertex buffer header (24), positions and uvs relative to vertex buffer
+	testutils.Write(&buf, uint32(24), uint32(0), uint32(72), uint32(0), uint32(0), uint16(3), uint16(7))
+
+	// NOTE: positions, flag 0x8000 skip triangle until third vertex
+	testutils.Write(&buf, [3]float32{0, 0, 0}, int32(0x8000))
+	testutils.Write(&buf, [3]float32{1, 0, 0}, int32(0x8000))
+	testutils.Write(&buf, [3]float32{0, 1, 0}, int32(0))
+
+	// NOTE: uvs in 4096 fixed point
+	testutils.Write(&buf, int16(0), int16(0))
+	testutils.Write(&buf, int16(4096), int16(0))
+	testutils.Write(&buf, int16(0), int16(-4096))
+
+	return buf.Bytes()
+}
+
+func TestFromStream(t *testing.T) {
+	m := mdb.New()
+	if err := mdb.FromStream(m, bytes.NewReader(fixture())); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, uint16(2), m.BoneTotal)
+	assert.Equal(t, int16(0), m.Bones[0].Parent)
+	assert.Equal(t, int16(1), m.Bones[1].Parent)
+	assert.Equal(t, [3]float32{0, 2, 0}, m.Bones[1].Translation)
+
+	assert.Equal(t, uint16(1), m.VertexBufferTotal)
+	vb := m.VertexBuffers[0]
+	assert.Equal(t, uint16(7), vb.Material)
+	assert.Equal(t, [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}}, vb.Vertices)
+	assert.Equal(t, [][3]int16{{0, 1, 2}}, vb.Indices)
+	assert.Equal(t, [][2]float32{{0, 1}, {1, 1}, {0, 0}}, vb.Uvs)
+}
+
+func TestFromStreamInvalidParent(t *testing.T) {
+	data := fixture()
+	// NOTE: second bone parent point to bone that does not exist
+	binary.LittleEndian.PutUint16(data[70:], 5)
+
+	m := mdb.New()
+	assert.Error(t, mdb.FromStream(m, bytes.NewReader(data)))
+}
@@ -1,11 +1,10 @@
 package mot
 
 import (
-	"fmt"
 	"io"
-	"os"
 
 	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/iso9660"
 )
 
 const (
@@ -21,34 +20,44 @@ type Mot struct {
 	Records             []*Record `json:"record"`
 }
 
-func (self *Mot) unmarshal(stream io.ReadWriteSeeker) error {
-	if self.Size == 0 {
-		size, _ := buffer.Seek(stream, 0, buffer.SeekEnd)
-		self.Size = uint32(size) - self.Offset
+func (self *Mot) unmarshal(stream io.ReadSeeker) error {
+	cursor := buffer.NewCursorLE(stream, "MOT")
+
+	size, err := cursor.Size()
+	if err != nil {
+		return err
+	}
+
+	if self.Size == 0 && uint64(self.Offset) <= size {
+		self.Size = uint32(size - uint64(self.Offset))
+	}
+
+	if err := cursor.Within("Size", uint64(self.Offset), uint64(self.Size)); err != nil {
+		return err
 	}
 
-	if _, err := buffer.Seek(stream, int64(self.Offset), buffer.SeekStart); err != nil {
+	if _, err := cursor.Move(int64(self.Offset), buffer.SeekStart); err != nil {
 		return err
 	}
 
 	signature := uint32(0)
-	if _, err := buffer.ReadUint32LE(stream, &signature); err != nil {
+	if _, err := cursor.ReadUint32("signature", &signature); err != nil {
 		return err
 	}
 
 	if signature != Signature {
-		return fmt.Errorf("MOT signature not match")
+		return cursor.Mismatch("signature", Signature, signature)
 	}
 
-	if _, err := buffer.ReadUint16LE(stream, &self.FrameTotal); err != nil {
+	if _, err := cursor.ReadUint16("FrameTotal", &self.FrameTotal); err != nil {
 		return err
 	}
 
-	if _, err := buffer.ReadUint8(stream, &self.RecordTotal); err != nil {
+	if _, err := cursor.ReadUint8("RecordTotal", &self.RecordTotal); err != nil {
 		return err
 	}
 
-	if _, err := buffer.ReadUint8(stream, &self.UseInverseKinematic); err != nil {
+	if _, err := cursor.ReadUint8("UseInverseKinematic", &self.UseInverseKinematic); err != nil {
 		return err
 	}
 
@@ -56,26 +65,26 @@ func (self *Mot) unmarshal(stream io.ReadWriteSeeker) error {
 	for range self.RecordTotal {
 		record := NewRecord()
 
-		if _, err := buffer.ReadUint8(stream, &record.Target); err != nil {
+		if _, err := cursor.ReadUint8("record.Target", &record.Target); err != nil {
 			return err
 		}
 
 		record.Target += 1
 
-		if _, err := buffer.ReadUint8(stream, &record.Channel); err != nil {
+		if _, err := cursor.ReadUint8("record.Channel", &record.Channel); err != nil {
 			return err
 		}
 
-		if _, err := buffer.ReadUint16LE(stream, &record.CurveTotal); err != nil {
+		if _, err := cursor.ReadUint16("record.CurveTotal", &record.CurveTotal); err != nil {
 			return err
 		}
 
-		if _, err := buffer.ReadUint32LE(stream, &record.UseGlobalTransform); err != nil {
+		if _, err := cursor.ReadUint32("record.UseGlobalTransform", &record.UseGlobalTransform); err != nil {
 			return err
 		}
 
 		offset := uint32(0)
-		if _, err := buffer.ReadUint32LE(stream, &offset); err != nil {
+		if _, err := cursor.ReadUint32("offset", &offset); err != nil {
 			return err
 		}
 
@@ -99,50 +108,50 @@ func (self *Mot) unmarshal(stream io.ReadWriteSeeker) error {
 			continue
 		}
 
-		if _, err := buffer.Seek(stream, offset, buffer.SeekStart); err != nil {
+		if _, err := cursor.Move(offset, buffer.SeekStart); err != nil {
 			return err
 		}
 
-		if _, err := buffer.ReadUint16LE(stream, &record.Position); err != nil {
+		if _, err := cursor.ReadUint16("record.Position", &record.Position); err != nil {
 			return err
 		}
 
-		if _, err := buffer.ReadUint16LE(stream, &record.PositionDelta); err != nil {
+		if _, err := cursor.ReadUint16("record.PositionDelta", &record.PositionDelta); err != nil {
 			return err
 		}
 
-		if _, err := buffer.ReadUint16LE(stream, &record.Tangent0); err != nil {
+		if _, err := cursor.ReadUint16("record.Tangent0", &record.Tangent0); err != nil {
 			return err
 		}
 
-		if _, err := buffer.ReadUint16LE(stream, &record.TangentDelta0); err != nil {
+		if _, err := cursor.ReadUint16("record.TangentDelta0", &record.TangentDelta0); err != nil {
 			return err
 		}
 
-		if _, err := buffer.ReadUint16LE(stream, &record.Tangent1); err != nil {
+		if _, err := cursor.ReadUint16("record.Tangent1", &record.Tangent1); err != nil {
 			return err
 		}
 
-		if _, err := buffer.ReadUint16LE(stream, &record.TangentDelta1); err != nil {
+		if _, err := cursor.ReadUint16("record.TangentDelta1", &record.TangentDelta1); err != nil {
 			return err
 		}
 
 		for range record.CurveTotal {
 			curve := NewCurve()
 
-			if _, err := buffer.ReadUint8(stream, &curve.FrameDelta); err != nil {
+			if _, err := cursor.ReadUint8("curve.FrameDelta", &curve.FrameDelta); err != nil {
 				return err
 			}
 
-			if _, err := buffer.ReadUint8(stream, &curve.ControlPoint); err != nil {
+			if _, err := cursor.ReadUint8("curve.ControlPoint", &curve.ControlPoint); err != nil {
 				return err
 			}
 
-			if _, err := buffer.ReadUint8(stream, &curve.ControlTangent0); err != nil {
+			if _, err := cursor.ReadUint8("curve.ControlTangent0", &curve.ControlTangent0); err != nil {
 				return err
 			}
 
-			if _, err := buffer.ReadUint8(stream, &curve.ControlTangent1); err != nil {
+			if _, err := cursor.ReadUint8("curve.ControlTangent1", &curve.ControlTangent1); err != nil {
 				return err
 			}
 
@@ -163,8 +172,18 @@ func New() *Mot {
 	}
 }
 
+func FromStreamWithOffsetSize(mot *Mot, stream io.ReadSeeker, offset uint32, size uint32) error {
+	mot.Offset = offset
+	mot.Size = size
+	return mot.unmarshal(stream)
+}
+
+func FromStream(mot *Mot, stream io.ReadSeeker) error {
+	return FromStreamWithOffsetSize(mot, stream, 0, 0)
+}
+
 func FromPathWithOffsetSize(mot *Mot, filePath string, offset uint32, size uint32) error {
-	file, err := os.Open(filePath)
+	file, err := iso9660.Open(filePath)
 	if err != nil {
 		return err
 	}
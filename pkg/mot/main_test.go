@@ -0,0 +1,47 @@
+package mot_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/anasrar/chihuahua/internal/testutils"
+	"github.com/anasrar/chihuahua/pkg/mot"
+	"github.com/stretchr/testify/assert"
+)
+
+// NOTE: motion with one keyframed record and one null record
+func fixture() []byte {
+	var buf bytes.Buffer
+
+	// NOTE: header (8) + records (12)
+	testutils.Write(&buf, mot.Signature, uint16(10), uint8(2), uint8(0))
+	testutils.Write(&buf, uint8(0), uint8(1), uint16(2), uint32(0), uint32(32))
+	testutils.Write(&buf, uint8(1), uint8(2), uint16(0), uint32(0), uint32(0))
+
+	// NOTE: keyframe at 32, position and tangent are half float
+	testutils.Write(&buf, uint16(0x3C00), uint16(0), uint16(0), uint16(0), uint16(0), uint16(0))
+	testutils.Write(&buf, [4]uint8{0, 1, 0, 0}, [4]uint8{10, 2, 0, 0})
+
+	return buf.Bytes()
+}
+
+func TestFromStream(t *testing.T) {
+	m := mot.New()
+	if err := mot.FromStream(m, bytes.NewReader(fixture())); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, uint16(10), m.FrameTotal)
+	assert.Equal(t, uint8(2), m.RecordTotal)
+
+	record := m.Records[0]
+	assert.False(t, record.IsNull)
+	assert.Equal(t, uint8(1), record.Target)
+	assert.Equal(t, uint8(1), record.Channel)
+	assert.Equal(t, uint16(0x3C00), record.Position)
+	assert.Equal(t, 2, len(record.Curves))
+	assert.Equal(t, uint8(10), record.Curves[1].FrameDelta)
+	assert.Equal(t, uint8(2), record.Curves[1].ControlPoint)
+
+	assert.True(t, m.Records[1].IsNull)
+}
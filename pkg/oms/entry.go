@@ -1,13 +1,57 @@
 package oms
 
+import (
+	"encoding/binary"
+	"math"
+)
+
+const (
+	EntrySize      uint32 = 64
+	NameLength            = 8
+	HeaderReserved        = 56
+)
+
+// NOTE: name and translation is what roomviewer use to place object model since the first OMS reader. fx is the name
+// from the original TODO, rotation, scale, and flag is only guessed from float and int layout and not verified in game
 type Entry struct {
 	Name        string     `json:"name"`
+	Fx          string     `json:"fx"` // NOTE: unverified, empty on most entry
 	Translation [3]float32 `json:"translation"`
+	Rotation    [3]float32 `json:"rotation"` // NOTE: unverified, value range look like radian
+	Scale       [3]float32 `json:"scale"`    // NOTE: unverified, 1 on most entry
+	Flag        uint32     `json:"flag"`     // NOTE: unverified
+	Unknown     [8]byte    `json:"unknown"`
+	Size        uint32     `json:"size"` // NOTE: bytes stored in file, last object in some room stop after translation
 }
 
 func NewEntry(name string, translation [3]float32) *Entry {
 	return &Entry{
 		Name:        name,
+		Fx:          "",
 		Translation: translation,
+		Rotation:    [3]float32{0, 0, 0},
+		Scale:       [3]float32{1, 1, 1},
+		Flag:        0,
+		Unknown:     [8]byte{},
+		Size:        EntrySize,
+	}
+}
+
+func (self *Entry) bytes() []byte {
+	b := make([]byte, EntrySize)
+	copy(b[0:], fixedString(self.Name))
+	copy(b[8:], fixedString(self.Fx))
+	for i, v := range self.Translation {
+		binary.LittleEndian.PutUint32(b[16+i*4:], math.Float32bits(v))
+	}
+	for i, v := range self.Rotation {
+		binary.LittleEndian.PutUint32(b[28+i*4:], math.Float32bits(v))
 	}
+	for i, v := range self.Scale {
+		binary.LittleEndian.PutUint32(b[40+i*4:], math.Float32bits(v))
+	}
+	binary.LittleEndian.PutUint32(b[52:], self.Flag)
+	copy(b[56:], self.Unknown[:])
+
+	return b
 }
@@ -0,0 +1,22 @@
+package oms_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/anasrar/chihuahua/pkg/oms"
+)
+
+func FuzzFromStream(f *testing.F) {
+	seed := binary.LittleEndian.AppendUint32(nil, oms.Signature)
+	f.Add(seed)
+	f.Add(append(seed, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF))
+	f.Add(append(seed, make([]byte, 128)...))
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		d := oms.New()
+		_ = oms.FromStream(d, bytes.NewReader(data))
+	})
+}
@@ -1,11 +1,14 @@
 package oms
 
 import (
-	"fmt"
+	"encoding/binary"
+	"errors"
 	"io"
+	"math"
 	"os"
 
 	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/iso9660"
 )
 
 const (
@@ -13,26 +16,29 @@ const (
 )
 
 type Oms struct {
-	Offset     uint32   `json:"offset"`
-	EntryTotal uint32   `json:"entry_total"`
-	Entries    []*Entry `json:"entries"`
+	Offset     uint32               `json:"offset"`
+	EntryTotal uint32               `json:"entry_total"`
+	Header     [HeaderReserved]byte `json:"header"` // NOTE: unknown, zero on most file
+	Entries    []*Entry             `json:"entries"`
 }
 
-func (self *Oms) unmarshal(stream io.ReadWriteSeeker) error {
-	if _, err := buffer.Seek(stream, int64(self.Offset), buffer.SeekStart); err != nil {
+func (self *Oms) unmarshal(stream io.ReadSeeker) error {
+	cursor := buffer.NewCursorLE(stream, "OMS")
+
+	if _, err := cursor.Move(int64(self.Offset), buffer.SeekStart); err != nil {
 		return err
 	}
 
 	signature := uint32(0)
-	if _, err := buffer.ReadUint32LE(stream, &signature); err != nil {
+	if _, err := cursor.ReadUint32("signature", &signature); err != nil {
 		return err
 	}
 
 	if signature != Signature {
-		return fmt.Errorf("OMS signature not match")
+		return cursor.Mismatch("signature", Signature, signature)
 	}
 
-	if _, err := buffer.ReadUint32LE(stream, &self.EntryTotal); err != nil {
+	if _, err := cursor.ReadUint32("EntryTotal", &self.EntryTotal); err != nil {
 		return err
 	}
 
@@ -40,63 +46,156 @@ func (self *Oms) unmarshal(stream io.ReadWriteSeeker) error {
 		return nil
 	}
 
-	if _, err := buffer.Seek(stream, 56, buffer.SeekCurrent); err != nil {
+	if _, err := cursor.ReadBytes("Header", self.Header[:]); err != nil {
+		return err
+	}
+
+	// NOTE: name (char[8]) + fx (char[8]) + translation (float[3]) + rest (36) for every entry, last rest can be cut off
+	if err := cursor.Require("EntryTotal", uint64(self.EntryTotal-1)*64+28); err != nil {
 		return err
 	}
 
 	for range self.EntryTotal {
-		name := ""
-		if _, err := buffer.ReadString(stream, &name, 8); err != nil {
+		entry := NewEntry("", [3]float32{0, 0, 0})
+
+		if _, err := cursor.ReadString("entry.Name", &entry.Name, NameLength); err != nil {
 			return err
 		}
 
-		// TODO: add this 8 char as fx string
-		if _, err := buffer.Seek(stream, 8, buffer.SeekCurrent); err != nil {
+		if _, err := cursor.ReadString("entry.Fx", &entry.Fx, NameLength); err != nil {
 			return err
 		}
 
-		translationX := float32(0)
-		if _, err := buffer.ReadFloat32LE(stream, &translationX); err != nil {
-			return err
+		for i := range entry.Translation {
+			if _, err := cursor.ReadFloat32("entry.Translation", &entry.Translation[i]); err != nil {
+				return err
+			}
 		}
 
-		translationY := float32(0)
-		if _, err := buffer.ReadFloat32LE(stream, &translationY); err != nil {
+		// NOTE: rotation, scale, flag, and unknown of last object can be missing, read size is kept so write
+		// give back the same bytes
+		rest := [36]byte{}
+		n, err := io.ReadFull(cursor.Stream, rest[:])
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
 			return err
 		}
+		entry.Size = 28 + uint32(n)
 
-		translationZ := float32(0)
-		if _, err := buffer.ReadFloat32LE(stream, &translationZ); err != nil {
-			return err
+		for i := range entry.Rotation {
+			entry.Rotation[i] = math.Float32frombits(binary.LittleEndian.Uint32(rest[i*4:]))
+		}
+		for i := range entry.Scale {
+			entry.Scale[i] = math.Float32frombits(binary.LittleEndian.Uint32(rest[12+i*4:]))
 		}
+		entry.Flag = binary.LittleEndian.Uint32(rest[24:])
+		copy(entry.Unknown[:], rest[28:])
 
-		self.Entries = append(
-			self.Entries,
-			NewEntry(
-				name,
-				[3]float32{translationX, translationY, translationZ},
-			),
-		)
+		self.Entries = append(self.Entries, entry)
+	}
+
+	return nil
+}
 
-		if _, err := buffer.Seek(stream, 36, buffer.SeekCurrent); err != nil {
+func (self *Oms) marshal(stream io.WriteSeeker) error {
+	if _, err := buffer.WriteUint32LE(stream, Signature); err != nil {
+		return err
+	}
+
+	if _, err := buffer.WriteUint32LE(stream, uint32(len(self.Entries))); err != nil {
+		return err
+	}
+
+	self.EntryTotal = uint32(len(self.Entries))
+	if self.EntryTotal == 0 {
+		return nil
+	}
+
+	if _, err := buffer.WriteBytes(stream, self.Header[:]); err != nil {
+		return err
+	}
+
+	for i := range self.Entries {
+		if _, err := buffer.WriteBytes(stream, self.entryBytes(i)); err != nil {
 			return err
 		}
-
 	}
 
 	return nil
 }
 
+// NOTE: name longer than 8 char is cut off, shorter is padded with zero
+func fixedString(str string) []byte {
+	b := make([]byte, NameLength)
+	copy(b, str)
+	return b
+}
+
+func (self *Oms) AddEntry(entry *Entry) {
+	self.Entries = append(self.Entries, entry)
+	self.EntryTotal += 1
+}
+
+func (self *Oms) RemoveEntry(index int) {
+	if index < 0 || index >= len(self.Entries) {
+		return
+	}
+
+	self.Entries = append(self.Entries[:index], self.Entries[index+1:]...)
+	self.EntryTotal -= 1
+}
+
+// NOTE: short last object keep its size unless edit touch the missing bytes, then it is written as full entry
+func (self *Oms) entryBytes(index int) []byte {
+	entry := self.Entries[index]
+	b := entry.bytes()
+
+	if index != len(self.Entries)-1 || entry.Size == 0 || entry.Size >= EntrySize {
+		return b
+	}
+
+	for _, v := range b[entry.Size:] {
+		if v != 0 {
+			return b
+		}
+	}
+
+	return b[:entry.Size]
+}
+
+// NOTE: signature (4) + entry total (4) + header (56) + entries, header is not written without entries
+func (self *Oms) Size() uint32 {
+	if len(self.Entries) == 0 {
+		return 8
+	}
+
+	size := 8 + uint32(HeaderReserved)
+	for i := range self.Entries {
+		size += uint32(len(self.entryBytes(i)))
+	}
+
+	return size
+}
+
 func New() *Oms {
 	return &Oms{
 		Offset:     0,
 		EntryTotal: 0,
+		Header:     [HeaderReserved]byte{},
 		Entries:    []*Entry{},
 	}
 }
 
+func FromStreamWithOffset(oms *Oms, stream io.ReadSeeker, offset uint32) error {
+	oms.Offset = offset
+	return oms.unmarshal(stream)
+}
+
+func FromStream(oms *Oms, stream io.ReadSeeker) error {
+	return FromStreamWithOffset(oms, stream, 0)
+}
+
 func FromPathWithOffset(oms *Oms, filePath string, offset uint32) error {
-	file, err := os.Open(filePath)
+	file, err := iso9660.Open(filePath)
 	if err != nil {
 		return err
 	}
@@ -109,3 +208,17 @@ func FromPathWithOffset(oms *Oms, filePath string, offset uint32) error {
 func FromPath(oms *Oms, filePath string) error {
 	return FromPathWithOffset(oms, filePath, 0)
 }
+
+func ToStream(oms *Oms, stream io.WriteSeeker) error {
+	return oms.marshal(stream)
+}
+
+func ToPath(oms *Oms, filePath string) error {
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return oms.marshal(file)
+}
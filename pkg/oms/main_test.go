@@ -0,0 +1,136 @@
+package oms_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/anasrar/chihuahua/internal/testutils"
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/oms"
+	"github.com/stretchr/testify/assert"
+)
+
+func fixture() []byte {
+	var buf bytes.Buffer
+
+	testutils.Write(&buf, oms.Signature, uint32(2), [56]byte{})
+	testutils.Write(&buf, [8]byte{'l', 'i', 'g', 'h', 't', '0'}, [8]byte{}, [3]float32{1, 2, 3}, [36]byte{})
+	testutils.Write(&buf, [8]byte{'l', 'i', 'g', 'h', 't', '1'}, [8]byte{}, [3]float32{4, 5, 6}, [36]byte{})
+
+	return buf.Bytes()
+}
+
+func TestFromStream(t *testing.T) {
+	o := oms.New()
+	if err := oms.FromStream(o, bytes.NewReader(fixture())); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, uint32(2), o.EntryTotal)
+	assert.Equal(t, "light1\x00\x00", o.Entries[1].Name)
+	assert.Equal(t, [3]float32{1, 2, 3}, o.Entries[0].Translation)
+	assert.Equal(t, [3]float32{4, 5, 6}, o.Entries[1].Translation)
+}
+
+func TestEntry(t *testing.T) {
+	var buf bytes.Buffer
+	testutils.Write(&buf, oms.Signature, uint32(1), bytes.Repeat([]byte{0x11}, 56))
+	testutils.Write(&buf, [8]byte{'d', 'o', 'o', 'r'}, [8]byte{'s', 'm', 'o', 'k', 'e'}, [3]float32{1, 2, 3})
+	testutils.Write(&buf, [3]float32{0, 3.14, 0}, [3]float32{2, 2, 2}, uint32(0x10), [8]byte{0xAB})
+
+	o := oms.New()
+	if err := oms.FromStream(o, bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+
+	entry := o.Entries[0]
+	assert.Equal(t, byte(0x11), o.Header[55])
+	assert.Equal(t, "smoke\x00\x00\x00", entry.Fx)
+	assert.Equal(t, [3]float32{0, 3.14, 0}, entry.Rotation)
+	assert.Equal(t, [3]float32{2, 2, 2}, entry.Scale)
+	assert.Equal(t, uint32(0x10), entry.Flag)
+	assert.Equal(t, byte(0xAB), entry.Unknown[0])
+
+	t.Run("cut off", func(t *testing.T) {
+		o := oms.New()
+		if err := oms.FromStream(o, bytes.NewReader(buf.Bytes()[:64+28])); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, [3]float32{1, 2, 3}, o.Entries[0].Translation)
+		assert.Equal(t, [3]float32{0, 0, 0}, o.Entries[0].Scale)
+	})
+
+	t.Run("cut off write", func(t *testing.T) {
+		// NOTE: second object stop in the middle of rotation
+		var data bytes.Buffer
+		testutils.Write(&data, oms.Signature, uint32(2), [56]byte{})
+		testutils.Write(&data, [8]byte{'d', 'o', 'o', 'r'}, [8]byte{}, [3]float32{1, 2, 3}, [3]float32{0, 1, 0}, [3]float32{1, 1, 1}, uint32(0), [8]byte{})
+		testutils.Write(&data, [8]byte{'l', 'a', 'm', 'p'}, [8]byte{}, [3]float32{4, 5, 6}, float32(0.5))
+
+		o := oms.New()
+		if err := oms.FromStream(o, bytes.NewReader(data.Bytes())); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, uint32(32), o.Entries[1].Size)
+		assert.Equal(t, float32(0.5), o.Entries[1].Rotation[0])
+
+		memory := buffer.NewMemory(nil)
+		if err := oms.ToStream(o, memory); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, data.Bytes(), memory.Bytes())
+		assert.Equal(t, uint32(data.Len()), o.Size())
+
+		o.AddEntry(oms.NewEntry("crate", [3]float32{7, 8, 9}))
+		memory = buffer.NewMemory(nil)
+		if err := oms.ToStream(o, memory); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 8+oms.HeaderReserved+3*int(oms.EntrySize), len(memory.Bytes()))
+		assert.Equal(t, data.Bytes()[64+64:], memory.Bytes()[64+64:64+64+32])
+	})
+}
+
+func TestToPath(t *testing.T) {
+	o := oms.New()
+	if err := oms.FromStream(o, bytes.NewReader(fixture())); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(t.TempDir(), "OUTPUT.oms")
+	if err := oms.ToPath(o, output); err != nil {
+		t.Fatal(err)
+	}
+
+	packed, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, fixture(), packed)
+	assert.Equal(t, uint32(len(packed)), o.Size())
+
+	t.Run("edit", func(t *testing.T) {
+		entry := oms.NewEntry("crate", [3]float32{7, 8, 9})
+		entry.Fx = "fire"
+		o.AddEntry(entry)
+		o.RemoveEntry(0)
+
+		if err := oms.ToPath(o, output); err != nil {
+			t.Fatal(err)
+		}
+
+		d := oms.New()
+		if err := oms.FromPath(d, output); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, uint32(2), d.EntryTotal)
+		assert.Equal(t, "light1\x00\x00", d.Entries[0].Name)
+		assert.Equal(t, "crate\x00\x00\x00", d.Entries[1].Name)
+		assert.Equal(t, "fire\x00\x00\x00\x00", d.Entries[1].Fx)
+		assert.Equal(t, [3]float32{1, 1, 1}, d.Entries[1].Scale)
+	})
+}
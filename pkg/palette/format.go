@@ -0,0 +1,58 @@
+package palette
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+type Format uint8
+
+const (
+	FormatUnknown Format = iota
+	FormatAct
+	FormatGpl
+	FormatPal
+)
+
+func (self Format) String() string {
+	switch self {
+	case FormatAct:
+		return "ACT"
+	case FormatGpl:
+		return "GPL"
+	case FormatPal:
+		return "PAL"
+	default:
+		return "Unknown"
+	}
+}
+
+func (self Format) Ext() string {
+	switch self {
+	case FormatAct:
+		return ".act"
+	case FormatGpl:
+		return ".gpl"
+	case FormatPal:
+		return ".pal"
+	default:
+		return ""
+	}
+}
+
+func FormatFromString(str string) Format {
+	switch strings.ToUpper(str) {
+	case "ACT":
+		return FormatAct
+	case "GPL":
+		return FormatGpl
+	case "PAL", "JASC-PAL":
+		return FormatPal
+	default:
+		return FormatUnknown
+	}
+}
+
+func FormatFromPath(p string) Format {
+	return FormatFromString(strings.TrimPrefix(filepath.Ext(p), "."))
+}
@@ -0,0 +1,19 @@
+package palette_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/anasrar/chihuahua/pkg/palette"
+)
+
+func FuzzFromStream(f *testing.F) {
+	f.Add(uint8(palette.FormatAct), make([]byte, palette.ActColorTotal*3+4))
+	f.Add(uint8(palette.FormatGpl), []byte("GIMP Palette\nName: test\nColumns: 16\n#\n255 0 0\tIndex 0\n"))
+	f.Add(uint8(palette.FormatPal), []byte("JASC-PAL\r\n0100\r\n1\r\n255 0 0\r\n"))
+
+	f.Fuzz(func(t *testing.T, format uint8, data []byte) {
+		p := palette.New(palette.Format(format))
+		_ = palette.FromStream(p, bytes.NewReader(data))
+	})
+}
@@ -0,0 +1,406 @@
+package palette
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/iso9660"
+)
+
+const (
+	ActColorTotal  int    = 256
+	ActNoneIndex   uint16 = 0xFFFF
+	GplSignature   string = "GIMP Palette"
+	PalSignature   string = "JASC-PAL"
+	PalVersion     string = "0100"
+	GplColumnTotal int    = 16
+)
+
+type Palette struct {
+	Format      Format        `json:"format"`
+	Name        string        `json:"name"`
+	Transparent int           `json:"transparent"` // NOTE: index of fully transparent color, -1 when none (ACT only)
+	Colors      []*color.RGBA `json:"colors"`
+}
+
+func New(format Format) *Palette {
+	return &Palette{
+		Format:      format,
+		Name:        "",
+		Transparent: -1,
+		Colors:      []*color.RGBA{},
+	}
+}
+
+func FromColors(format Format, name string, colors []*color.RGBA) *Palette {
+	p := New(format)
+	p.Name = name
+
+	for i, c := range colors {
+		p.Colors = append(p.Colors, &color.RGBA{R: c.R, G: c.G, B: c.B, A: c.A})
+
+		if p.Transparent == -1 && c.A == 0 {
+			p.Transparent = i
+		}
+	}
+
+	return p
+}
+
+// NOTE: palette formats only store RGB, alpha is taken from the original clut
+func (self *Palette) ApplyTo(clut []*color.RGBA) []*color.RGBA {
+	result := []*color.RGBA{}
+
+	for i, c := range clut {
+		next := color.RGBA{R: c.R, G: c.G, B: c.B, A: c.A}
+
+		if i < len(self.Colors) {
+			next.R = self.Colors[i].R
+			next.G = self.Colors[i].G
+			next.B = self.Colors[i].B
+		}
+
+		if self.Format == FormatAct && self.Transparent == i {
+			next.A = 0
+		}
+
+		result = append(result, &next)
+	}
+
+	return result
+}
+
+func (self *Palette) unmarshal(stream io.Reader) error {
+	switch self.Format {
+	case FormatAct:
+		return self.unmarshalAct(stream)
+	case FormatGpl:
+		return self.unmarshalGpl(stream)
+	case FormatPal:
+		return self.unmarshalPal(stream)
+	default:
+		return fmt.Errorf("Palette format not supported")
+	}
+}
+
+func (self *Palette) unmarshalAct(stream io.Reader) error {
+	buf, err := io.ReadAll(stream)
+	if err != nil {
+		return err
+	}
+
+	if len(buf) < ActColorTotal*3 {
+		return &buffer.ParseError{
+			Format: "ACT",
+			Field:  "colors",
+			Offset: uint64(len(buf)),
+			Err:    io.ErrUnexpectedEOF,
+		}
+	}
+
+	total := ActColorTotal
+	self.Transparent = -1
+
+	if len(buf) >= ActColorTotal*3+4 {
+		count := binary.BigEndian.Uint16(buf[ActColorTotal*3:])
+		if count != 0 && int(count) <= ActColorTotal {
+			total = int(count)
+		}
+
+		transparent := binary.BigEndian.Uint16(buf[ActColorTotal*3+2:])
+		if transparent != ActNoneIndex && int(transparent) < total {
+			self.Transparent = int(transparent)
+		}
+	}
+
+	self.Colors = []*color.RGBA{}
+	for i := range total {
+		a := uint8(0xFF)
+		if i == self.Transparent {
+			a = 0
+		}
+
+		self.Colors = append(
+			self.Colors,
+			&color.RGBA{
+				R: buf[i*3+0],
+				G: buf[i*3+1],
+				B: buf[i*3+2],
+				A: a,
+			},
+		)
+	}
+
+	return nil
+}
+
+// NOTE: line scanner that keep track byte offset of current line for parse error
+type lineScanner struct {
+	*bufio.Scanner
+	Format string
+	Line   int
+	Offset uint64
+	next   uint64
+}
+
+func newLineScanner(stream io.Reader, format string) *lineScanner {
+	self := &lineScanner{
+		Scanner: bufio.NewScanner(stream),
+		Format:  format,
+	}
+
+	self.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := bufio.ScanLines(data, atEOF)
+		if token != nil {
+			self.Line += 1
+			self.Offset = self.next
+		}
+		self.next += uint64(advance)
+
+		return advance, token, err
+	})
+
+	return self
+}
+
+func (self *lineScanner) Error(field string, err error) error {
+	return &buffer.ParseError{
+		Format: self.Format,
+		Field:  fmt.Sprintf("line %d %s", self.Line, field),
+		Offset: self.Offset,
+		Err:    err,
+	}
+}
+
+func (self *lineScanner) Mismatch(field string, expected any, found any) error {
+	return &buffer.ParseError{
+		Format:   self.Format,
+		Field:    fmt.Sprintf("line %d %s", self.Line, field),
+		Offset:   self.Offset,
+		Expected: expected,
+		Found:    found,
+	}
+}
+
+func (self *lineScanner) Scan() bool {
+	if self.Scanner.Scan() {
+		return true
+	}
+
+	// NOTE: point to end of stream when there is no more line
+	self.Line += 1
+	self.Offset = self.next
+
+	return false
+}
+
+func (self *Palette) unmarshalGpl(stream io.Reader) error {
+	scanner := newLineScanner(stream, "GPL")
+
+	if !scanner.Scan() {
+		return scanner.Error("signature", io.ErrUnexpectedEOF)
+	}
+
+	if signature := strings.TrimSpace(scanner.Text()); signature != GplSignature {
+		return scanner.Mismatch("signature", GplSignature, signature)
+	}
+
+	self.Colors = []*color.RGBA{}
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		if name, found := strings.CutPrefix(text, "Name:"); found {
+			self.Name = strings.TrimSpace(name)
+			continue
+		}
+
+		if strings.HasPrefix(text, "Columns:") {
+			continue
+		}
+
+		c, err := parseRgb(strings.Fields(text))
+		if err != nil {
+			return scanner.Error("color", err)
+		}
+
+		self.Colors = append(self.Colors, c)
+	}
+
+	return scanner.Err()
+}
+
+func (self *Palette) unmarshalPal(stream io.Reader) error {
+	scanner := newLineScanner(stream, "PAL")
+
+	if !scanner.Scan() {
+		return scanner.Error("signature", io.ErrUnexpectedEOF)
+	}
+
+	if signature := strings.TrimSpace(scanner.Text()); signature != PalSignature {
+		return scanner.Mismatch("signature", PalSignature, signature)
+	}
+
+	if !scanner.Scan() {
+		return scanner.Error("version", io.ErrUnexpectedEOF)
+	}
+
+	if !scanner.Scan() {
+		return scanner.Error("color total", io.ErrUnexpectedEOF)
+	}
+
+	total, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+	if err != nil {
+		return scanner.Error("color total", err)
+	}
+
+	self.Colors = []*color.RGBA{}
+	for len(self.Colors) < total && scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+
+		if text == "" {
+			continue
+		}
+
+		c, err := parseRgb(strings.Fields(text))
+		if err != nil {
+			return scanner.Error("color", err)
+		}
+
+		self.Colors = append(self.Colors, c)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if len(self.Colors) != total {
+		return scanner.Mismatch("color total", total, len(self.Colors))
+	}
+
+	return nil
+}
+
+func parseRgb(fields []string) (*color.RGBA, error) {
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("expected 3 color components, got %d", len(fields))
+	}
+
+	rgb := [3]uint8{}
+	for i := range rgb {
+		v, err := strconv.ParseUint(fields[i], 10, 8)
+		if err != nil {
+			return nil, err
+		}
+		rgb[i] = uint8(v)
+	}
+
+	return &color.RGBA{R: rgb[0], G: rgb[1], B: rgb[2], A: 0xFF}, nil
+}
+
+func (self *Palette) marshal(stream io.Writer) error {
+	switch self.Format {
+	case FormatAct:
+		return self.marshalAct(stream)
+	case FormatGpl:
+		return self.marshalGpl(stream)
+	case FormatPal:
+		return self.marshalPal(stream)
+	default:
+		return fmt.Errorf("Palette format not supported")
+	}
+}
+
+func (self *Palette) marshalAct(stream io.Writer) error {
+	total := len(self.Colors)
+	if total > ActColorTotal {
+		return fmt.Errorf("ACT colors exceeds the maximum allowable limit of %d", ActColorTotal)
+	}
+
+	buf := make([]byte, ActColorTotal*3+4)
+	for i, c := range self.Colors {
+		buf[i*3+0] = c.R
+		buf[i*3+1] = c.G
+		buf[i*3+2] = c.B
+	}
+
+	transparent := ActNoneIndex
+	if self.Transparent >= 0 && self.Transparent < total {
+		transparent = uint16(self.Transparent)
+	}
+
+	binary.BigEndian.PutUint16(buf[ActColorTotal*3:], uint16(total))
+	binary.BigEndian.PutUint16(buf[ActColorTotal*3+2:], transparent)
+
+	_, err := stream.Write(buf)
+	return err
+}
+
+func (self *Palette) marshalGpl(stream io.Writer) error {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "%s\n", GplSignature)
+	fmt.Fprintf(&buf, "Name: %s\n", self.Name)
+	fmt.Fprintf(&buf, "Columns: %d\n", GplColumnTotal)
+	fmt.Fprintf(&buf, "#\n")
+	for i, c := range self.Colors {
+		fmt.Fprintf(&buf, "%3d %3d %3d\tIndex %d\n", c.R, c.G, c.B, i)
+	}
+
+	_, err := stream.Write(buf.Bytes())
+	return err
+}
+
+func (self *Palette) marshalPal(stream io.Writer) error {
+	var buf bytes.Buffer
+
+	// NOTE: Paint Shop Pro writes CRLF line endings
+	fmt.Fprintf(&buf, "%s\r\n", PalSignature)
+	fmt.Fprintf(&buf, "%s\r\n", PalVersion)
+	fmt.Fprintf(&buf, "%d\r\n", len(self.Colors))
+	for _, c := range self.Colors {
+		fmt.Fprintf(&buf, "%d %d %d\r\n", c.R, c.G, c.B)
+	}
+
+	_, err := stream.Write(buf.Bytes())
+	return err
+}
+
+func FromStream(p *Palette, stream io.Reader) error {
+	return p.unmarshal(stream)
+}
+
+func FromPath(p *Palette, filePath string) error {
+	file, err := iso9660.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return p.unmarshal(file)
+}
+
+func ToStream(p *Palette, stream io.Writer) error {
+	return p.marshal(stream)
+}
+
+func ToPath(p *Palette, filePath string) error {
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return p.marshal(file)
+}
@@ -0,0 +1,56 @@
+package palette_test
+
+import (
+	"bytes"
+	"image/color"
+	"testing"
+
+	"github.com/anasrar/chihuahua/pkg/palette"
+	"github.com/stretchr/testify/assert"
+)
+
+func colors(total int) []*color.RGBA {
+	result := []*color.RGBA{}
+	for i := range total {
+		result = append(result, &color.RGBA{R: uint8(i), G: uint8(255 - i), B: uint8(i * 3), A: 0xFF})
+	}
+	result[1].A = 0
+
+	return result
+}
+
+func Test(t *testing.T) {
+	for _, format := range []palette.Format{palette.FormatAct, palette.FormatGpl, palette.FormatPal} {
+		t.Run(format.String(), func(t *testing.T) {
+			source := colors(16)
+
+			var buf bytes.Buffer
+			if err := palette.ToStream(palette.FromColors(format, "test", source), &buf); err != nil {
+				t.Fatal(err)
+			}
+
+			p := palette.New(format)
+			if err := palette.FromStream(p, &buf); err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, len(source), len(p.Colors))
+			for i, c := range p.Colors {
+				assert.Equal(t, source[i].R, c.R)
+				assert.Equal(t, source[i].G, c.G)
+				assert.Equal(t, source[i].B, c.B)
+			}
+
+			clut := p.ApplyTo(source)
+			assert.Equal(t, uint8(0), clut[1].A)
+			assert.Equal(t, uint8(0xFF), clut[2].A)
+		})
+	}
+
+	t.Run("FormatFromPath", func(t *testing.T) {
+		assert.Equal(t, palette.FormatAct, palette.FormatFromPath("a/b.ACT"))
+		assert.Equal(t, palette.FormatGpl, palette.FormatFromPath("b.gpl"))
+		assert.Equal(t, palette.FormatPal, palette.FormatFromPath("b.pal"))
+		assert.Equal(t, palette.FormatUnknown, palette.FormatFromPath("b.png"))
+	})
+}
@@ -0,0 +1,57 @@
+package rlig
+
+import (
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/AllenDang/cimgui-go/imgui"
+
+	"github.com/anasrar/chihuahua/pkg/buffer"
+)
+
+var (
+	errorLock    sync.Mutex
+	errorMessage = ""
+	errorOpen    = false
+)
+
+// NOTE: parse error show format, field, and offset on separate line.
+// Safe to call from goroutine (export), popup is opened on next ErrorPopup call
+func ShowError(err error) {
+	log.Println(err)
+
+	message := err.Error()
+
+	var parseErr *buffer.ParseError
+	if errors.As(err, &parseErr) {
+		message = parseErr.Details()
+	}
+
+	errorLock.Lock()
+	defer errorLock.Unlock()
+
+	errorMessage = message
+	errorOpen = true
+}
+
+// NOTE: called from render loop
+func ErrorPopup() {
+	errorLock.Lock()
+	open := errorOpen
+	message := errorMessage
+	errorOpen = false
+	errorLock.Unlock()
+
+	if open {
+		imgui.OpenPopupStr("Error")
+	}
+
+	if imgui.BeginPopupModalV("Error", nil, imgui.WindowFlagsAlwaysAutoResize) {
+		imgui.TextUnformatted(message)
+		if imgui.Button("OK") {
+			imgui.CloseCurrentPopup()
+		}
+		imgui.EndPopup()
+	}
+}
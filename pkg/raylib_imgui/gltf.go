@@ -0,0 +1,54 @@
+package rlig
+
+import (
+	"github.com/AllenDang/cimgui-go/imgui"
+
+	"github.com/anasrar/chihuahua/pkg/texture"
+	"github.com/anasrar/chihuahua/pkg/tim2"
+)
+
+var ddsFormats = []tim2.DdsFormat{tim2.DdsFormatRGBA8, tim2.DdsFormatBC1, tim2.DdsFormatBC3}
+
+// NOTE: glTF texture image combo, DDS format combo and mipmaps checkbox is shown for DDS, end with SameLine
+func GltfOption(option *texture.GltfOption) {
+	imgui.PushIDStr("GltfImage")
+	if imgui.BeginComboV("", option.Image.String(), imgui.ComboFlagsWidthFitPreview) {
+		for _, image := range texture.GltfImages {
+			selected := image == option.Image
+			if imgui.SelectableBoolV(image.String(), selected, imgui.SelectableFlagsNone, imgui.NewVec2(0, 0)) {
+				option.Image = image
+			}
+
+			if selected {
+				imgui.SetItemDefaultFocus()
+			}
+		}
+
+		imgui.EndCombo()
+	}
+	imgui.PopID()
+	imgui.SameLineV(0, 4)
+
+	if option.Image == texture.GltfImageDds {
+		imgui.PushIDStr("DdsFormat")
+		if imgui.BeginComboV("", option.DdsFormat.String(), imgui.ComboFlagsWidthFitPreview) {
+			for _, format := range ddsFormats {
+				selected := format == option.DdsFormat
+				if imgui.SelectableBoolV(format.String(), selected, imgui.SelectableFlagsNone, imgui.NewVec2(0, 0)) {
+					option.DdsFormat = format
+				}
+
+				if selected {
+					imgui.SetItemDefaultFocus()
+				}
+			}
+
+			imgui.EndCombo()
+		}
+		imgui.PopID()
+		imgui.SameLineV(0, 4)
+
+		imgui.Checkbox("Mipmaps", &option.Mipmaps)
+		imgui.SameLineV(0, 4)
+	}
+}
@@ -1,12 +1,14 @@
 package scr
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
-	"image/png"
+	"io"
 	"os"
 	"path/filepath"
 
+	"github.com/anasrar/chihuahua/pkg/iso9660"
+	"github.com/anasrar/chihuahua/pkg/texture"
 	"github.com/anasrar/chihuahua/pkg/tim3"
 	"github.com/anasrar/chihuahua/pkg/tm3"
 	"github.com/anasrar/chihuahua/pkg/utils"
@@ -14,41 +16,107 @@ import (
 	"github.com/qmuntal/gltf/modeler"
 )
 
+// NOTE: bindings is the same as ToGltf. Option is copied with glTF directory as Dir, nil is embedded PNG.
+// glTF is still written when ToGltf return partial export error, the error is returned after it is written
 func ConvertToGlft(
 	scrPath string,
 	tm3Path string,
-	textureShift int,
+	bindings map[uint16]int,
+	option *texture.GltfOption,
 ) error {
+	var tm *tm3.Tm3
+	var tm3Stream io.ReadSeeker
+	if tm3Path != "" {
+		file, err := iso9660.Open(tm3Path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		tm = tm3.New()
+		if err := tm3.FromStream(tm, file); err != nil {
+			return err
+		}
+		tm3Stream = file
+	}
+
+	s := New()
+	if err := FromPath(s, scrPath); err != nil {
+		return err
+	}
+
+	output := filepath.Join(
+		utils.ParentDirectory(scrPath),
+		fmt.Sprintf(
+			"GLTF_%s",
+			utils.Basename(scrPath),
+		),
+	)
+
+	if err := os.MkdirAll(output, os.ModePerm); err != nil {
+		return err
+	}
+
+	o := texture.NewGltfOption(output)
+	if option != nil {
+		copied := *option
+		copied.Dir = output
+		o = &copied
+	}
+
+	doc, err := ToGltf(s, tm, tm3Stream, bindings, o)
+	if doc == nil {
+		return err
+	}
+
+	if err := gltf.Save(doc, filepath.Join(output, fmt.Sprintf("%s.gltf", utils.BasenameWithoutExt(scrPath)))); err != nil {
+		return err
+	}
+
+	return err
+}
+
+// NOTE: tm3 and tm3Stream are optional (nil), used for textures.
+// bindings map material to TM3 entry index, resolved with ResolveMaterials (texture base 0) when nil.
+// option select how texture is stored, nil is embedded PNG.
+// Same as ResolveMaterials, uncovered material and texture that can not be converted do not stop export, material is
+// exported without texture and every problem is returned as error together with document. Document is nil when
+// export fail
+func ToGltf(
+	s *Scr,
+	tm *tm3.Tm3,
+	tm3Stream io.ReadSeeker,
+	bindings map[uint16]int,
+	option *texture.GltfOption,
+) (*gltf.Document, error) {
 	doc := gltf.NewDocument()
+	textured := map[int]int{}
 	materials := map[uint16]int{}
 	zero := float64(0)
 	one := float64(1)
+	errs := []error{}
 
-	if tm3Path != "" {
-		tm := tm3.New()
-		if err := tm3.FromPath(tm, tm3Path); err != nil {
-			return err
-		}
-
+	if tm != nil {
 		for i, entry := range tm.Entries {
 			tim := tim3.New()
-			if err := tim3.FromPathWithOffset(tim, tm3Path, entry.Offset); err != nil {
-				return err
+			if err := tim3.FromStreamWithOffset(tim, tm3Stream, entry.Offset); err != nil {
+				errs = append(errs, fmt.Errorf("Texture %d: %w", i, err))
+				continue
 			}
 
-			var buf bytes.Buffer
 			picture := tim.Pictures[0]
-			if err := png.Encode(&buf, tim3.PictureToImage(picture)); err != nil {
-				return err
+			nrgba, err := tim3.PictureToImage(picture)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("Texture %d: %w", i, err))
+				continue
 			}
 
-			index, _ := modeler.WriteImage(doc, fmt.Sprintf("%s_%03d", entry.Name, i), "image/png", &buf)
-			doc.Textures = append(doc.Textures, &gltf.Texture{
-				Source: gltf.Index(index),
-			})
+			index, err := texture.WriteGltfTexture(doc, fmt.Sprintf("%s_%03d", utils.FilterUnprintableString(entry.Name), i), nrgba, option)
+			if err != nil {
+				return nil, err
+			}
 
-			k := len(doc.Materials)
-			materials[uint16(i)] = k
+			textured[i] = len(doc.Materials)
 			doc.Materials = append(doc.Materials,
 				&gltf.Material{
 					PBRMetallicRoughness: &gltf.PBRMetallicRoughness{
@@ -64,21 +132,18 @@ func ConvertToGlft(
 		}
 	}
 
-	s := New()
-	if err := FromPath(s, scrPath); err != nil {
-		return err
+	if bindings == nil && tm != nil {
+		resolved, err := s.ResolveMaterials(len(tm.Entries), 0)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		bindings = resolved
 	}
 
-	output := filepath.Join(
-		utils.ParentDirectory(scrPath),
-		fmt.Sprintf(
-			"GLTF_%s",
-			utils.Basename(scrPath),
-		),
-	)
-
-	if err := os.MkdirAll(output, os.ModePerm); err != nil {
-		return err
+	for material, index := range bindings {
+		if k, ok := textured[index]; ok {
+			materials[material] = k
+		}
 	}
 
 	nodes := 0
@@ -157,10 +222,10 @@ func ConvertToGlft(
 		primitives := []*gltf.Primitive{}
 
 		for _, vb := range node.Mdb.VertexBuffers {
-			materialIndex, ok := materials[vb.Material+uint16(textureShift)]
+			materialIndex, ok := materials[vb.Material]
 			if !ok {
 				k := len(doc.Materials)
-				materials[vb.Material+uint16(textureShift)] = k
+				materials[vb.Material] = k
 				materialIndex = k
 				doc.Materials = append(doc.Materials,
 					&gltf.Material{
@@ -235,9 +300,5 @@ func ConvertToGlft(
 		nodes++
 	}
 
-	if err := gltf.Save(doc, filepath.Join(output, fmt.Sprintf("%s.gltf", utils.BasenameWithoutExt(scrPath)))); err != nil {
-		return err
-	}
-
-	return nil
+	return doc, errors.Join(errs...)
 }
@@ -1,11 +1,10 @@
 package scr
 
 import (
-	"fmt"
 	"io"
-	"os"
 
 	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/iso9660"
 	"github.com/anasrar/chihuahua/pkg/mdb"
 )
 
@@ -19,36 +18,42 @@ type Scr struct {
 	Nodes     []*Node `json:"nodes"`
 }
 
-func (self *Scr) unmarshal(stream io.ReadWriteSeeker) error {
-	if _, err := buffer.Seek(stream, int64(self.Offset), buffer.SeekStart); err != nil {
+func (self *Scr) unmarshal(stream io.ReadSeeker) error {
+	cursor := buffer.NewCursorLE(stream, "SCR")
+
+	if _, err := cursor.Move(int64(self.Offset), buffer.SeekStart); err != nil {
 		return err
 	}
 
 	signature := uint32(0)
-	if _, err := buffer.ReadUint32LE(stream, &signature); err != nil {
+	if _, err := cursor.ReadUint32("signature", &signature); err != nil {
 		return err
 	}
 
 	if signature != Signature {
-		return fmt.Errorf("SCR signature not match")
+		return cursor.Mismatch("signature", Signature, signature)
+	}
+
+	if _, err := cursor.Move(4, buffer.SeekCurrent); err != nil {
+		return err
 	}
 
-	if _, err := buffer.Seek(stream, 4, buffer.SeekCurrent); err != nil {
+	if _, err := cursor.ReadUint32("NodeTotal", &self.NodeTotal); err != nil {
 		return err
 	}
 
-	if _, err := buffer.ReadUint32LE(stream, &self.NodeTotal); err != nil {
+	if _, err := cursor.Move(4, buffer.SeekCurrent); err != nil {
 		return err
 	}
 
-	if _, err := buffer.Seek(stream, 4, buffer.SeekCurrent); err != nil {
+	if err := cursor.Require("NodeTotal", uint64(self.NodeTotal)*4); err != nil {
 		return err
 	}
 
 	nodeOffsets := []int64{}
 	offset := uint32(0)
 	for range self.NodeTotal {
-		if _, err := buffer.ReadUint32LE(stream, &offset); err != nil {
+		if _, err := cursor.ReadUint32("offset", &offset); err != nil {
 			return err
 		}
 		nodeOffsets = append(nodeOffsets, int64(self.Offset+offset))
@@ -56,65 +61,65 @@ func (self *Scr) unmarshal(stream io.ReadWriteSeeker) error {
 
 	mdbOffset := int32(0)
 	for _, offset := range nodeOffsets {
-		if _, err := buffer.Seek(stream, offset, buffer.SeekStart); err != nil {
+		if _, err := cursor.Move(offset, buffer.SeekStart); err != nil {
 			return err
 		}
 
-		if _, err := buffer.ReadInt32LE(stream, &mdbOffset); err != nil {
+		if _, err := cursor.ReadInt32("mdbOffset", &mdbOffset); err != nil {
 			return err
 		}
 
-		if _, err := buffer.Seek(stream, 4, buffer.SeekCurrent); err != nil {
+		if _, err := cursor.Move(4, buffer.SeekCurrent); err != nil {
 			return err
 		}
 
 		name := ""
-		if _, err := buffer.ReadString(stream, &name, 8); err != nil {
+		if _, err := cursor.ReadString("name", &name, 8); err != nil {
 			return err
 		}
 
 		scaleX := float32(0)
-		if _, err := buffer.ReadFloat32LE(stream, &scaleX); err != nil {
+		if _, err := cursor.ReadFloat32("scaleX", &scaleX); err != nil {
 			return err
 		}
 
 		scaleY := float32(0)
-		if _, err := buffer.ReadFloat32LE(stream, &scaleY); err != nil {
+		if _, err := cursor.ReadFloat32("scaleY", &scaleY); err != nil {
 			return err
 		}
 
 		scaleZ := float32(0)
-		if _, err := buffer.ReadFloat32LE(stream, &scaleZ); err != nil {
+		if _, err := cursor.ReadFloat32("scaleZ", &scaleZ); err != nil {
 			return err
 		}
 
 		rotationX := float32(0)
-		if _, err := buffer.ReadFloat32LE(stream, &rotationX); err != nil {
+		if _, err := cursor.ReadFloat32("rotationX", &rotationX); err != nil {
 			return err
 		}
 
 		rotationY := float32(0)
-		if _, err := buffer.ReadFloat32LE(stream, &rotationY); err != nil {
+		if _, err := cursor.ReadFloat32("rotationY", &rotationY); err != nil {
 			return err
 		}
 
 		rotationZ := float32(0)
-		if _, err := buffer.ReadFloat32LE(stream, &rotationZ); err != nil {
+		if _, err := cursor.ReadFloat32("rotationZ", &rotationZ); err != nil {
 			return err
 		}
 
 		translationX := float32(0)
-		if _, err := buffer.ReadFloat32LE(stream, &translationX); err != nil {
+		if _, err := cursor.ReadFloat32("translationX", &translationX); err != nil {
 			return err
 		}
 
 		translationY := float32(0)
-		if _, err := buffer.ReadFloat32LE(stream, &translationY); err != nil {
+		if _, err := cursor.ReadFloat32("translationY", &translationY); err != nil {
 			return err
 		}
 
 		translationZ := float32(0)
-		if _, err := buffer.ReadFloat32LE(stream, &translationZ); err != nil {
+		if _, err := cursor.ReadFloat32("translationZ", &translationZ); err != nil {
 			return err
 		}
 
@@ -146,17 +151,17 @@ func New() *Scr {
 	}
 }
 
-func FromStreamWithOffset(scr *Scr, stream io.ReadWriteSeeker, offset uint32) error {
+func FromStreamWithOffset(scr *Scr, stream io.ReadSeeker, offset uint32) error {
 	scr.Offset = offset
 	return scr.unmarshal(stream)
 }
 
-func FromStream(scr *Scr, stream io.ReadWriteSeeker) error {
+func FromStream(scr *Scr, stream io.ReadSeeker) error {
 	return FromStreamWithOffset(scr, stream, 0)
 }
 
 func FromPathWithOffset(scr *Scr, filePath string, offset uint32) error {
-	file, err := os.Open(filePath)
+	file, err := iso9660.Open(filePath)
 	if err != nil {
 		return err
 	}
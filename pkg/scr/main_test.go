@@ -1,19 +1,220 @@
 package scr_test
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
 	"testing"
 
+	"github.com/anasrar/chihuahua/internal/testutils"
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/dat"
+	"github.com/anasrar/chihuahua/pkg/mdb"
 	"github.com/anasrar/chihuahua/pkg/scr"
+	"github.com/anasrar/chihuahua/pkg/tim3"
+	"github.com/anasrar/chihuahua/pkg/tm3"
+	"github.com/qmuntal/gltf"
 	"github.com/stretchr/testify/assert"
 )
 
 func Test(t *testing.T) {
 	t.Run("pl00.dat", func(t *testing.T) {
 		s := scr.New()
-		if err := scr.FromPathWithOffset(s, "../../samples/pl00.dat", 154272); err != nil {
+		if err := scr.FromPathWithOffset(s, testutils.Sample(t, "pl00.dat"), 154272); err != nil {
 			t.Fatal(err)
 		}
 
 		assert.Equal(t, uint32(0x1a), s.NodeTotal)
 	})
 }
+
+// NOTE: scr with one node that point to empty model
+func fixture() []byte {
+	var buf bytes.Buffer
+
+	// NOTE: header (16) + node offset (4)
+	testutils.Write(&buf, scr.Signature, uint32(0), uint32(1), uint32(0), uint32(20))
+
+	// NOTE: node (52), model right after node
+	testutils.Write(&buf, int32(52), uint32(0), [8]byte{'n', 'o', 'd', 'e', '0'})
+	testutils.Write(&buf, [3]float32{1, 1, 1}, [3]float32{0, 0.5, 0}, [3]float32{1, 2, 3})
+
+	// NOTE: model header (32) without bone and vertex buffer
+	testutils.Write(&buf, mdb.Signature, uint32(32), uint16(0), uint16(0), [18]byte{}, uint16(1))
+
+	return buf.Bytes()
+}
+
+func TestFromStream(t *testing.T) {
+	s := scr.New()
+	if err := scr.FromStream(s, bytes.NewReader(fixture())); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, uint32(1), s.NodeTotal)
+	assert.Equal(t, 1, len(s.Nodes))
+
+	node := s.Nodes[0]
+	assert.Equal(t, "node0\x00\x00\x00", node.Name)
+	assert.Equal(t, [3]float32{1, 1, 1}, node.Scale)
+	assert.Equal(t, [3]float32{0, 0.5, 0}, node.Rotation)
+	assert.Equal(t, [3]float32{1, 2, 3}, node.Translation)
+	assert.Equal(t, uint32(72), node.Mdb.Offset)
+	assert.Equal(t, 0, len(node.Mdb.VertexBuffers))
+}
+
+func materials(values ...uint16) *scr.Scr {
+	m := &mdb.Mdb{}
+	for _, value := range values {
+		m.VertexBuffers = append(m.VertexBuffers, &mdb.VertexBuffer{Material: value})
+	}
+
+	s := scr.New()
+	s.Nodes = append(s.Nodes, scr.NewNode(m, "node0", [3]float32{1, 1, 1}, [3]float32{}, [3]float32{}))
+	return s
+}
+
+func TestResolveMaterials(t *testing.T) {
+	t.Run("zero base", func(t *testing.T) {
+		bindings, err := materials(2, 0, 2).ResolveMaterials(3, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, map[uint16]int{0: 0, 2: 2}, bindings)
+	})
+
+	t.Run("dat base", func(t *testing.T) {
+		bindings, err := materials(4, 6).ResolveMaterials(3, 4)
+		assert.NoError(t, err)
+		assert.Equal(t, map[uint16]int{4: 0, 6: 2}, bindings)
+	})
+
+	t.Run("not covered", func(t *testing.T) {
+		// NOTE: material 10 and 11 would fit two texture from base 10, only the given base is used
+		bindings, err := materials(11, 10).ResolveMaterials(2, 0)
+		assert.EqualError(t, err, "Material [10 11] not covered by 2 texture from base 0")
+		assert.Equal(t, map[uint16]int{}, bindings)
+	})
+
+	t.Run("partial", func(t *testing.T) {
+		bindings, err := materials(0, 1, 9).ResolveMaterials(2, 0)
+		assert.EqualError(t, err, "Material [9] not covered by 2 texture from base 0")
+		assert.Equal(t, map[uint16]int{0: 0, 1: 1}, bindings)
+	})
+
+	t.Run("without texture", func(t *testing.T) {
+		bindings, err := materials(0, 1).ResolveMaterials(0, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, map[uint16]int{}, bindings)
+	})
+}
+
+func tm3Bytes(t *testing.T, textureTotal int) []byte {
+	tm := tm3.New()
+	tm.Alignment = 16
+	for i := range textureTotal {
+		tm.AddEntryFromBytesWithName(bytes.Repeat([]byte{byte(i + 1)}, 16), fmt.Sprintf("tex%d", i))
+	}
+
+	memory := buffer.NewMemory(nil)
+	if err := tm.PackToStream(context.Background(), memory, testutils.Noop, testutils.Noop); err != nil {
+		t.Fatal(err)
+	}
+
+	return memory.Bytes()
+}
+
+func TestTextureBase(t *testing.T) {
+	// NOTE: TM3 with 2 texture, TM3 with 3 texture, then MD that use texture from both
+	d := dat.New()
+	d.Alignment = 16
+	d.AddEntryFromBytesWithType(tm3Bytes(t, 2), "TM3\x00")
+	d.AddEntryFromBytesWithType(tm3Bytes(t, 3), "TM3\x00")
+	d.AddEntryFromBytesWithType(fixture(), "MD\x00\x00")
+
+	memory := buffer.NewMemory(nil)
+	if err := d.PackToStream(context.Background(), memory, testutils.Noop, testutils.Noop); err != nil {
+		t.Fatal(err)
+	}
+
+	packed := dat.New()
+	stream := bytes.NewReader(memory.Bytes())
+	if err := dat.FromStream(packed, stream); err != nil {
+		t.Fatal(err)
+	}
+
+	for index, expected := range []int{0, 2, 5} {
+		base, err := scr.TextureBase(packed, stream, index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, expected, base, "entry %d", index)
+	}
+
+	// NOTE: material 2 and 4 is first and last texture of second TM3
+	bindings, err := materials(2, 4).ResolveMaterials(3, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, map[uint16]int{2: 0, 4: 2}, bindings)
+}
+
+func TestToGltf(t *testing.T) {
+	texture := buffer.NewMemory(nil)
+	if err := tim3.ImagePalettedToFile(testutils.Paletted(8, 8, 16), 4, texture); err != nil {
+		t.Fatal(err)
+	}
+
+	pack := func(entries ...[]byte) (*tm3.Tm3, io.ReadSeeker) {
+		tm := tm3.New()
+		for i, entry := range entries {
+			tm.AddEntryFromBytesWithName(entry, fmt.Sprintf("tex%d", i))
+		}
+
+		memory := buffer.NewMemory(nil)
+		if err := tm.PackToStream(context.Background(), memory, testutils.Noop, testutils.Noop); err != nil {
+			t.Fatal(err)
+		}
+
+		stream := bytes.NewReader(memory.Bytes())
+		packed := tm3.New()
+		if err := tm3.FromStream(packed, stream); err != nil {
+			t.Fatal(err)
+		}
+
+		return packed, stream
+	}
+
+	textured := func(doc *gltf.Document) int {
+		total := 0
+		for _, material := range doc.Materials {
+			if material.PBRMetallicRoughness.BaseColorTexture != nil {
+				total++
+			}
+		}
+		return total
+	}
+
+	t.Run("uncovered", func(t *testing.T) {
+		tm, stream := pack(texture.Bytes(), texture.Bytes())
+
+		doc, err := scr.ToGltf(materials(0, 1, 5), tm, stream, nil, nil)
+		assert.EqualError(t, err, "Material [5] not covered by 2 texture from base 0")
+		if assert.NotNil(t, doc) {
+			assert.Len(t, doc.Materials, 3)
+			assert.Equal(t, 2, textured(doc))
+		}
+
+		doc, err = scr.ToGltf(materials(0, 1, 5), tm, stream, map[uint16]int{5: 1}, nil)
+		assert.Nil(t, err)
+		assert.Len(t, doc.Materials, 4)
+	})
+
+	t.Run("invalid texture", func(t *testing.T) {
+		tm, stream := pack(texture.Bytes(), bytes.Repeat([]byte{0xFF}, 64))
+
+		doc, err := scr.ToGltf(materials(0, 1), tm, stream, nil, nil)
+		assert.ErrorContains(t, err, "Texture 1: ")
+		if assert.NotNil(t, doc) {
+			assert.Len(t, doc.Materials, 2)
+			assert.Equal(t, 1, textured(doc))
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package scr
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/anasrar/chihuahua/pkg/dat"
+	"github.com/anasrar/chihuahua/pkg/tm3"
+	"github.com/anasrar/chihuahua/pkg/utils"
+)
+
+// NOTE: unique vertex buffer material in ascending order
+func (self *Scr) Materials() []uint16 {
+	result := []uint16{}
+	for _, node := range self.Nodes {
+		for _, vb := range node.Mdb.VertexBuffers {
+			result = append(result, vb.Material)
+		}
+	}
+
+	slices.Sort(result)
+	return slices.Compact(result)
+}
+
+// NOTE: material is texture slot shared by every TM3 loaded with the model, TM3 entry index is material minus
+// textureBase (texture total of TM3 stored before this TM3 in same DAT, see TextureBase). Material outside of TM3 is
+// not in the map and returned as error, the map still has every covered material so model can be shown with missing
+// texture. Without texture (textureTotal zero) nothing is resolved and it is not an error
+func (self *Scr) ResolveMaterials(textureTotal int, textureBase int) (map[uint16]int, error) {
+	result := map[uint16]int{}
+
+	materials := self.Materials()
+	if len(materials) == 0 || textureTotal == 0 {
+		return result, nil
+	}
+
+	uncovered := []uint16{}
+	for _, material := range materials {
+		index := int(material) - textureBase
+		if index < 0 || index >= textureTotal {
+			uncovered = append(uncovered, material)
+			continue
+		}
+
+		result[material] = index
+	}
+
+	if len(uncovered) != 0 {
+		return result, fmt.Errorf(
+			"Material %v not covered by %d texture from base %d",
+			uncovered,
+			textureTotal,
+			textureBase,
+		)
+	}
+
+	return result, nil
+}
+
+// NOTE: texture total of every TM3 entry before entry index in same DAT, MD and TM3 pair share one texture slot range
+func TextureBase(d *dat.Dat, stream io.ReadSeeker, entryIndex int) (int, error) {
+	base := 0
+	for i, entry := range d.Entries {
+		if i >= entryIndex {
+			break
+		}
+
+		if entry.IsNull || utils.FilterUnprintableString(entry.Type) != "TM3" {
+			continue
+		}
+
+		tm := tm3.New()
+		if err := tm3.FromStreamWithOffsetSize(tm, stream, entry.Offset, entry.Size); err != nil {
+			return 0, err
+		}
+
+		base += int(tm.EntryTotal)
+	}
+
+	return base, nil
+}
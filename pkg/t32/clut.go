@@ -0,0 +1,55 @@
+package t32
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/tim2"
+)
+
+const (
+	ClutColors int = 256
+)
+
+func WriteClutToStreamWithOffset(stream io.ReadWriteSeeker, offset uint32, colors []*color.RGBA) error {
+	if len(colors) != ClutColors {
+		return fmt.Errorf("CLUT colors is not match, expected %d, got %d", ClutColors, len(colors))
+	}
+
+	if _, err := buffer.Seek(stream, int64(offset)+12, buffer.SeekStart); err != nil {
+		return err
+	}
+
+	clutOffset := uint32(0)
+	if _, err := buffer.ReadUint32LE(stream, &clutOffset); err != nil {
+		return err
+	}
+
+	// NOTE: image header (224) + image data (clutOffset - 256) + palette header (256)
+	if _, err := buffer.Seek(stream, int64(offset)+224+int64(clutOffset), buffer.SeekStart); err != nil {
+		return err
+	}
+
+	if _, err := buffer.WriteBytes(stream, tim2.ClutToBytes(colors)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func WriteClutWithOffset(filePath string, offset uint32, colors []*color.RGBA) error {
+	file, err := os.OpenFile(filePath, os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return WriteClutToStreamWithOffset(file, offset, colors)
+}
+
+func WriteClut(filePath string, colors []*color.RGBA) error {
+	return WriteClutWithOffset(filePath, 0, colors)
+}
@@ -0,0 +1,46 @@
+package t32_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/anasrar/chihuahua/internal/testutils"
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/t32"
+	"github.com/anasrar/chihuahua/pkg/tim2"
+	"github.com/stretchr/testify/assert"
+)
+
+// NOTE: CLUT is stored twiddled after palette header, image data is not touched
+func TestWriteClut(t *testing.T) {
+	built := buffer.NewMemory(nil)
+	if err := t32.ImagePalettedToStream(bytes.NewReader(template(128)), testutils.Paletted(128, 128, 256), built); err != nil {
+		t.Fatal(err)
+	}
+	data := built.Bytes()
+
+	offset := uint32(16)
+	memory := buffer.NewMemory(append(make([]byte, offset), data...))
+	colors := testutils.Clut(t32.ClutColors)
+	if err := t32.WriteClutToStreamWithOffset(memory, offset, colors); err != nil {
+		t.Fatal(err)
+	}
+
+	written := memory.Bytes()
+	assert.Equal(t, int(offset)+len(data), len(written))
+
+	d := t32.New()
+	if err := t32.FromStreamWithOffset(d, bytes.NewReader(written), offset); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, colors, d.ClutData)
+
+	clut := written[len(written)-t32.ClutColors*4:]
+	for index, slot := range map[int]int{0: 0, 8: 16, 16: 8, 24: 24, 255: 255} {
+		c := colors[index]
+		assert.Equal(t, []byte{c.R, c.G, c.B, tim2.AlphaToGs(c.A)}, clut[slot*4:slot*4+4], "color %d", index)
+	}
+	assert.Equal(t, data[:len(data)-len(clut)], written[offset:len(written)-len(clut)])
+
+	assert.Error(t, t32.WriteClutToStreamWithOffset(memory, offset, colors[1:]))
+}
@@ -0,0 +1,27 @@
+package t32_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/anasrar/chihuahua/pkg/t32"
+)
+
+func FuzzFromStream(f *testing.F) {
+	// NOTE: image header (224) + image data (128x64) + palette header (256) + clut (256 * 4)
+	seed := make([]byte, 224+128*64+256+256*4)
+	binary.LittleEndian.PutUint32(seed[12:], 128*64+256)
+	f.Add(seed)
+	f.Add(seed[:224])
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		d := t32.New()
+		if err := t32.FromStream(d, bytes.NewReader(data)); err != nil {
+			return
+		}
+
+		_, _ = t32.T32ToImage(d)
+	})
+}
@@ -4,54 +4,96 @@ import (
 	"fmt"
 	"image"
 	"image/color"
-	"os"
+	"io"
 
 	"github.com/anasrar/chihuahua/pkg/buffer"
 	graphicsynthesizer "github.com/anasrar/chihuahua/pkg/graphic_synthesizer"
+	"github.com/anasrar/chihuahua/pkg/iso9660"
+	"github.com/anasrar/chihuahua/pkg/tim2"
 )
 
-func T32ToImage(t32 *T32) *image.NRGBA {
+// NOTE: palette index for every pixel, every 128x64 block is unswizzled and index is validated
+func indices(t32 *T32) ([]byte, error) {
 	width := int(t32.ImageWidth)
 	height := int(t32.ImageHeight)
-	dataSize := len(t32.ImageData)
-	data := make([]byte, dataSize)
-	copy(data, t32.ImageData)
+	dataSize := width * height
 
-	indices := []byte{}
+	// NOTE: image data is stored in 128x64 swizzled block
+	if width != 128 || dataSize%(128*64) != 0 {
+		return nil, fmt.Errorf("Image size %dx%d is not supported", width, height)
+	}
+
+	if len(t32.ImageData) < dataSize {
+		return nil, fmt.Errorf("Image data size is not match, expected at least %d, got %d", dataSize, len(t32.ImageData))
+	}
+
+	data := t32.ImageData[:dataSize]
+
+	indices := make([]byte, dataSize)
 	for i := 0; i < dataSize; i += 128 * 64 {
-		chunk := data[i : i+8192]
-		chunk = graphicsynthesizer.Unswizzle8(chunk, 128, 64)
-		indices = append(indices, chunk...)
+		graphicsynthesizer.Unswizzle8To(indices[i:i+8192], data[i:i+8192], 128, 64)
 	}
 
-	raw := []uint8{}
-	for _, index := range indices {
-		c := t32.ClutData[index]
-		raw = append(raw, c.R)
-		raw = append(raw, c.G)
-		raw = append(raw, c.B)
-		raw = append(raw, c.A)
+	for i, index := range indices {
+		if int(index) >= len(t32.ClutData) {
+			return nil, fmt.Errorf("Palette index %d at pixel %d exceeds %d colors", index, i, len(t32.ClutData))
+		}
 	}
-	img := image.NewNRGBA(image.Rect(0, 0, width, height))
-	copy(img.Pix, raw)
 
-	return img
+	return indices, nil
 }
 
-func ImagePalettedToFile(t32Path string, img *image.Paletted, output *os.File) error {
-	colorTotal := len(img.Palette)
+func T32ToImage(t32 *T32) (*image.NRGBA, error) {
+	indices, err := indices(t32)
+	if err != nil {
+		return nil, err
+	}
 
-	if colorTotal > 256 {
-		return fmt.Errorf("PNG colors exceeds the maximum allowable limit of 256")
+	img := image.NewNRGBA(image.Rect(0, 0, int(t32.ImageWidth), int(t32.ImageHeight)))
+	for i, index := range indices {
+		c := t32.ClutData[index]
+		p := img.Pix[i*4 : i*4+4 : i*4+4]
+		p[0] = c.R
+		p[1] = c.G
+		p[2] = c.B
+		p[3] = c.A
+	}
+
+	return img, nil
+}
+
+// NOTE: indices and CLUT order is kept, so PNG can be converted back without re-indexing
+func T32ToPaletted(t32 *T32) (*image.Paletted, error) {
+	indices, err := indices(t32)
+	if err != nil {
+		return nil, err
 	}
 
-	t32File, err := os.Open(t32Path)
+	img := image.NewPaletted(image.Rect(0, 0, int(t32.ImageWidth), int(t32.ImageHeight)), tim2.ClutToPalette(t32.ClutData))
+	copy(img.Pix, indices)
+
+	return img, nil
+}
+
+func ImagePalettedToFile(t32Path string, img *image.Paletted, output io.WriteSeeker) error {
+	t32File, err := iso9660.Open(t32Path)
 	if err != nil {
 		return err
 	}
 	defer t32File.Close()
 
-	if _, err := buffer.Seek(t32File, 12, buffer.SeekCurrent); err != nil {
+	return ImagePalettedToStream(t32File, img, output)
+}
+
+// NOTE: original T32 stream used as template for image header and palette header
+func ImagePalettedToStream(t32File io.ReadSeeker, img *image.Paletted, output io.WriteSeeker) error {
+	colorTotal := len(img.Palette)
+
+	if colorTotal > 256 {
+		return fmt.Errorf("PNG colors exceeds the maximum allowable limit of 256")
+	}
+
+	if _, err := buffer.Seek(t32File, 12, buffer.SeekStart); err != nil {
 		return err
 	}
 
@@ -80,22 +122,23 @@ func ImagePalettedToFile(t32Path string, img *image.Paletted, output *os.File) e
 		return err
 	}
 
-	imageData := []byte{}
-
 	imgWidth := img.Rect.Max.X
 	imgHeight := img.Rect.Max.Y
 
+	imageData := make([]byte, 0, imgWidth*imgHeight)
+	tile := make([]uint8, 128*64)
+	swizzled := make([]uint8, 128*64)
+
 	for y := 0; y < imgHeight; y += 64 {
 		for x := 0; x < imgWidth; x += 128 {
-			indices := []uint8{}
-
 			for ty := 0; ty < 64; ty++ {
 				for tx := 0; tx < 128; tx++ {
-					indices = append(indices, img.ColorIndexAt(x+tx, y+ty))
+					tile[ty*128+tx] = img.ColorIndexAt(x+tx, y+ty)
 				}
 			}
 
-			imageData = append(imageData, graphicsynthesizer.Swizzle8(indices, 128, 64)...)
+			graphicsynthesizer.Swizzle8To(swizzled, tile, 128, 64)
+			imageData = append(imageData, swizzled...)
 		}
 	}
 
@@ -128,10 +171,9 @@ func ImagePalettedToFile(t32Path string, img *image.Paletted, output *os.File) e
 		return err
 	}
 
-	colors := []*color.RGBA{}
+	colors := make([]*color.RGBA, 0, 256)
 	for _, c := range img.Palette {
-		c32, _ := c.(color.RGBA)
-		colors = append(colors, &c32)
+		colors = append(colors, tim2.ColorToClut(c))
 	}
 
 	// NOTE: fill colors to 256
@@ -142,7 +184,7 @@ func ImagePalettedToFile(t32Path string, img *image.Paletted, output *os.File) e
 		}
 	}
 
-	twiddle := []*color.RGBA{}
+	twiddle := make([]*color.RGBA, 0, 256)
 	for i := 0; i < 256; i += 32 {
 		twiddle = append(twiddle, colors[i+0:i+8]...)
 		twiddle = append(twiddle, colors[i+16:i+24]...)
@@ -150,11 +192,14 @@ func ImagePalettedToFile(t32Path string, img *image.Paletted, output *os.File) e
 		twiddle = append(twiddle, colors[i+24:i+32]...)
 	}
 
+	clutData := make([]byte, 0, len(twiddle)*4)
 	for _, c := range twiddle {
 		a := uint8(float32(c.A) / 255 * 0x80)
-		if _, err := buffer.WriteBytes(output, []byte{c.R, c.G, c.B, a}); err != nil {
-			return err
-		}
+		clutData = append(clutData, c.R, c.G, c.B, a)
+	}
+
+	if _, err := buffer.WriteBytes(output, clutData); err != nil {
+		return err
 	}
 
 	return nil
@@ -0,0 +1,86 @@
+package t32_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"testing"
+
+	"github.com/anasrar/chihuahua/internal/testutils"
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/t32"
+	"github.com/stretchr/testify/assert"
+)
+
+// NOTE: T32 template only need clut offset in image header, image data and clut are replaced
+func template(height int) []byte {
+	imageDataSize := 128 * height
+	data := make([]byte, 224+imageDataSize+256+256*4)
+	binary.LittleEndian.PutUint32(data[12:], uint32(imageDataSize+256))
+
+	return data
+}
+
+func TestRoundTrip(t *testing.T) {
+	img := testutils.Paletted(128, 128, 256)
+
+	memory := buffer.NewMemory(nil)
+	if err := t32.ImagePalettedToStream(bytes.NewReader(template(128)), img, memory); err != nil {
+		t.Fatal(err)
+	}
+
+	d := t32.New()
+	if err := t32.FromStream(d, bytes.NewReader(memory.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, uint16(128), d.ImageWidth)
+	assert.Equal(t, uint16(128), d.ImageHeight)
+
+	result, err := t32.T32ToImage(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for y := range 128 {
+		for x := range 128 {
+			r, g, b, a := img.At(x, y).RGBA()
+			if !assert.Equal(t, color.NRGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: uint8(a >> 8)}, result.NRGBAAt(x, y), "pixel %d,%d", x, y) {
+				return
+			}
+		}
+	}
+}
+
+func BenchmarkT32ToImage(b *testing.B) {
+	img := testutils.Paletted(128, 512, 256)
+
+	source := template(512)
+	memory := buffer.NewMemory(nil)
+	if err := t32.ImagePalettedToStream(bytes.NewReader(source), img, memory); err != nil {
+		b.Fatal(err)
+	}
+
+	d := t32.New()
+	if err := t32.FromStream(d, bytes.NewReader(memory.Bytes())); err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("T32ToImage", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			if _, err := t32.T32ToImage(d); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("ImagePalettedToStream", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			if err := t32.ImagePalettedToStream(bytes.NewReader(source), img, buffer.NewMemory(nil)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -3,9 +3,9 @@ package t32
 import (
 	"image/color"
 	"io"
-	"os"
 
 	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/iso9660"
 )
 
 type T32 struct {
@@ -26,27 +26,38 @@ func New() *T32 {
 	}
 }
 
-func (self *T32) unmarshal(stream io.ReadWriteSeeker) error {
-	if _, err := buffer.Seek(stream, int64(self.Offset), buffer.SeekStart); err != nil {
+func (self *T32) unmarshal(stream io.ReadSeeker) error {
+	cursor := buffer.NewCursorLE(stream, "T32")
+
+	if _, err := cursor.Move(int64(self.Offset), buffer.SeekStart); err != nil {
 		return err
 	}
 
-	if _, err := buffer.Seek(stream, 12, buffer.SeekCurrent); err != nil {
+	if _, err := cursor.Move(12, buffer.SeekCurrent); err != nil {
 		return err
 	}
 
 	clutOffset := uint32(0)
-	if _, err := buffer.ReadUint32LE(stream, &clutOffset); err != nil {
+	if _, err := cursor.ReadUint32("clutOffset", &clutOffset); err != nil {
 		return err
 	}
 
-	if _, err := buffer.Seek(stream, 208, buffer.SeekCurrent); err != nil {
+	if _, err := cursor.Move(208, buffer.SeekCurrent); err != nil {
 		return err
 	}
 
+	// NOTE: image data is stored in 128x64 swizzled block
+	if clutOffset < 256 || (clutOffset-256)%(128*64) != 0 {
+		return cursor.Invalid("clutOffset", "image data size %d is not multiple of %d", int64(clutOffset)-256, 128*64)
+	}
+
 	imageDataSize := clutOffset - 256
+	if err := cursor.Require("clutOffset", uint64(imageDataSize)); err != nil {
+		return err
+	}
+
 	imageData := make([]byte, imageDataSize)
-	if _, err := buffer.ReadBytes(stream, imageData); err != nil {
+	if _, err := cursor.ReadBytes("imageData", imageData); err != nil {
 		return err
 	}
 	self.ImageData = imageData
@@ -54,13 +65,13 @@ func (self *T32) unmarshal(stream io.ReadWriteSeeker) error {
 	self.ImageWidth = 128
 	self.ImageHeight = uint16(imageDataSize / 128)
 
-	if _, err := buffer.Seek(stream, 256, buffer.SeekCurrent); err != nil {
+	if _, err := cursor.Move(256, buffer.SeekCurrent); err != nil {
 		return err
 	}
 
 	rgba := make([]byte, 4)
 	for range 256 {
-		if _, err := buffer.ReadBytes(stream, rgba); err != nil {
+		if _, err := cursor.ReadBytes("rgba", rgba); err != nil {
 			return err
 		}
 
@@ -88,8 +99,17 @@ func (self *T32) unmarshal(stream io.ReadWriteSeeker) error {
 	return nil
 }
 
+func FromStreamWithOffset(t32 *T32, stream io.ReadSeeker, offset uint32) error {
+	t32.Offset = offset
+	return t32.unmarshal(stream)
+}
+
+func FromStream(t32 *T32, stream io.ReadSeeker) error {
+	return FromStreamWithOffset(t32, stream, 0)
+}
+
 func FromPathWithOffset(t32 *T32, filePath string, offset uint32) error {
-	file, err := os.Open(filePath)
+	file, err := iso9660.Open(filePath)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,362 @@
+package texture
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/dat"
+	"github.com/anasrar/chihuahua/pkg/t32"
+	"github.com/anasrar/chihuahua/pkg/tim2"
+	"github.com/anasrar/chihuahua/pkg/tim3"
+	"github.com/anasrar/chihuahua/pkg/tm3"
+	"github.com/anasrar/chihuahua/pkg/utils"
+)
+
+// NOTE: DAT entry type that is generic DAT container, see README file format
+var ContainerTypes = []string{"SCP", "AKT", "CMP", "EFF"}
+
+type dumper struct {
+	ctx      context.Context
+	root     string
+	output   string
+	manifest *Manifest
+	onDone   func(record *Record)
+}
+
+// NOTE: location of texture inside container file
+type location struct {
+	container string
+	nested    []int
+	names     []string // NOTE: path segment for every nested index
+	entry     int
+	name      string // NOTE: path segment for TM3 entry
+}
+
+func (self *location) with(index int, t string) *location {
+	return &location{
+		container: self.container,
+		nested:    append(slices.Clone(self.nested), index),
+		names:     append(slices.Clone(self.names), fmt.Sprintf("%03d_%s", index, strings.ToLower(t))),
+		entry:     -1,
+		name:      "",
+	}
+}
+
+func (self *location) withEntry(index int, name string) *location {
+	return &location{
+		container: self.container,
+		nested:    self.nested,
+		names:     self.names,
+		entry:     index,
+		name:      fmt.Sprintf("%03d_%s", index, name),
+	}
+}
+
+// NOTE: deterministic PNG path relative to output, container path then one directory for every nested DAT
+func (self *dumper) pngPath(loc *location, suffix string) string {
+	rel, err := filepath.Rel(self.root, loc.container)
+	if err != nil || rel == "." {
+		rel = filepath.Base(loc.container)
+	}
+
+	segments := slices.Clone(loc.names)
+	if loc.name != "" {
+		segments = append(segments, loc.name)
+	}
+
+	// NOTE: keep extension so a.tim2 and a.tim3 do not collide
+	if len(segments) == 0 {
+		return rel + suffix + ".png"
+	}
+
+	dir := filepath.Join(append([]string{rel}, segments[:len(segments)-1]...)...)
+	return filepath.Join(dir, segments[len(segments)-1]+suffix+".png")
+}
+
+func (self *dumper) skip(loc *location, err error) {
+	self.manifest.Skips = append(self.manifest.Skips, &Skip{
+		Container: loc.container,
+		Nested:    loc.nested,
+		Entry:     loc.entry,
+		Error:     err.Error(),
+	})
+}
+
+func (self *dumper) write(loc *location, format string, variant *tim2.Variant, bpp uint, img *image.Paletted) error {
+	suffix := ""
+	record := &Record{
+		Png:       "",
+		Container: loc.container,
+		Nested:    loc.nested,
+		Entry:     loc.entry,
+		Format:    format,
+		Picture:   0,
+		Bank:      0,
+		Bpp:       bpp,
+		Width:     uint16(img.Rect.Dx()),
+		Height:    uint16(img.Rect.Dy()),
+		Hash:      "",
+	}
+
+	if variant != nil {
+		suffix = variant.Suffix()
+		record.Picture = variant.PictureIndex
+		record.Bank = variant.Bank
+	}
+
+	record.Png = filepath.ToSlash(self.pngPath(loc, suffix))
+	target := filepath.Join(self.output, record.Png)
+	if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return err
+	}
+
+	buf := bytes.Buffer{}
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(target, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256(buf.Bytes())
+	record.Hash = hex.EncodeToString(hash[:])
+
+	self.manifest.Records = append(self.manifest.Records, record)
+	if self.onDone != nil {
+		self.onDone(record)
+	}
+
+	return nil
+}
+
+func (self *dumper) pictures(loc *location, format string, pictures []*tim2.Picture, toImage func(picture *tim2.Picture) (*image.Paletted, error)) error {
+	variants, err := tim2.Variants(pictures)
+	if err != nil {
+		self.skip(loc, err)
+		return nil
+	}
+
+	for _, variant := range variants {
+		bpp, err := tim3.Bpp(variant.Picture)
+		if err != nil {
+			self.skip(loc, err)
+			continue
+		}
+
+		img, err := toImage(variant.Picture)
+		if err != nil {
+			self.skip(loc, err)
+			continue
+		}
+
+		if err := self.write(loc, format, variant, bpp, img); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (self *dumper) tim2(stream io.ReadSeeker, loc *location, offset uint32) error {
+	tim := tim2.New()
+	if err := tim2.FromStreamWithOffset(tim, stream, offset); err != nil {
+		self.skip(loc, err)
+		return nil
+	}
+
+	return self.pictures(loc, FormatTim2, tim.Pictures, tim2.PictureToPaletted)
+}
+
+func (self *dumper) tim3(stream io.ReadSeeker, loc *location, offset uint32) error {
+	tim := tim3.New()
+	if err := tim3.FromStreamWithOffset(tim, stream, offset); err != nil {
+		self.skip(loc, err)
+		return nil
+	}
+
+	return self.pictures(loc, FormatTim3, tim.Pictures, tim3.PictureToPaletted)
+}
+
+func (self *dumper) t32(stream io.ReadSeeker, loc *location, offset uint32) error {
+	t := t32.New()
+	if err := t32.FromStreamWithOffset(t, stream, offset); err != nil {
+		self.skip(loc, err)
+		return nil
+	}
+
+	img, err := t32.T32ToPaletted(t)
+	if err != nil {
+		self.skip(loc, err)
+		return nil
+	}
+
+	return self.write(loc, FormatT32, nil, 8, img)
+}
+
+func (self *dumper) tm3(stream io.ReadSeeker, loc *location, offset uint32, size uint32) error {
+	tm := tm3.New()
+	if err := tm3.FromStreamWithOffsetSize(tm, stream, offset, size); err != nil {
+		self.skip(loc, err)
+		return nil
+	}
+
+	for i, entry := range tm.Entries {
+		if err := self.tim3(stream, loc.withEntry(i, utils.FilterUnprintableString(entry.Name)), entry.Offset); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (self *dumper) dat(stream io.ReadSeeker, loc *location, offset uint32, size uint32) error {
+	d := dat.New()
+	if err := dat.FromStreamWithOffsetSize(d, stream, offset, size); err != nil {
+		self.skip(loc, err)
+		return nil
+	}
+
+	for i, entry := range d.Entries {
+		if entry.IsNull {
+			continue
+		}
+
+		select {
+		case <-self.ctx.Done():
+			return fmt.Errorf("Canceled")
+		default:
+		}
+
+		t := utils.FilterUnprintableString(entry.Type)
+		if err := self.detect(stream, loc.with(i, t), t, entry.Offset, entry.Size); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NOTE: TIM2, TIM3, and TM3 is detected by signature, T32 and DAT have no signature and use type (DAT entry type or file extension)
+func (self *dumper) detect(stream io.ReadSeeker, loc *location, t string, offset uint32, size uint32) error {
+	signature := uint32(0)
+	if _, err := buffer.Seek(stream, int64(offset), buffer.SeekStart); err != nil {
+		return err
+	}
+	if _, err := buffer.ReadUint32LE(stream, &signature); err != nil {
+		return nil
+	}
+
+	switch signature {
+	case tim2.Signature:
+		return self.tim2(stream, loc, offset)
+	case tim3.Signature:
+		return self.tim3(stream, loc, offset)
+	case tm3.Signature:
+		return self.tm3(stream, loc, offset, size)
+	}
+
+	switch {
+	case t == "T32":
+		return self.t32(stream, loc, offset)
+	case t == "DAT" || slices.Contains(ContainerTypes, t):
+		return self.dat(stream, loc, offset, size)
+	}
+
+	return nil
+}
+
+func (self *dumper) file(filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	loc := &location{
+		container: filePath,
+		nested:    []int{},
+		names:     []string{},
+		entry:     -1,
+		name:      "",
+	}
+
+	return self.detect(file, loc, strings.ToUpper(strings.TrimPrefix(filepath.Ext(filePath), ".")), 0, 0)
+}
+
+// NOTE: dump every TIM2, TIM3, TM3, and T32 in file or directory (DAT tree included) as PNG with manifest.
+// onDone (can be nil) is called after each PNG is written
+func Dump(
+	ctx context.Context,
+	source string,
+	output string,
+	onDone func(record *Record),
+) (*Manifest, error) {
+	root, err := filepath.Abs(source)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &dumper{
+		ctx:      ctx,
+		root:     root,
+		output:   output,
+		manifest: New(),
+		onDone:   onDone,
+	}
+
+	d.manifest.Root = root
+
+	if !info.IsDir() {
+		d.root = filepath.Dir(root)
+		d.manifest.Root = d.root
+		return d.manifest, d.file(root)
+	}
+
+	// NOTE: WalkDir walk in lexical order so output is deterministic
+	err = filepath.WalkDir(root, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			// NOTE: skip output when it is inside source
+			if abs, err := filepath.Abs(output); err == nil && abs == filePath {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// NOTE: skip socket, pipe, and device
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("Canceled")
+		default:
+		}
+
+		return d.file(filePath)
+	})
+
+	return d.manifest, err
+}
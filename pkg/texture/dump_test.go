@@ -0,0 +1,352 @@
+package texture_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/anasrar/chihuahua/internal/testutils"
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/dat"
+	"github.com/anasrar/chihuahua/pkg/t32"
+	"github.com/anasrar/chihuahua/pkg/texture"
+	"github.com/anasrar/chihuahua/pkg/tim2"
+	"github.com/anasrar/chihuahua/pkg/tim3"
+	"github.com/anasrar/chihuahua/pkg/tm3"
+	"github.com/stretchr/testify/assert"
+)
+
+func paletted(green uint8) *image.Paletted {
+	palette := color.Palette{}
+	for i := range 16 {
+		palette = append(palette, color.RGBA{R: uint8(i * 16), G: green, B: 0x00, A: 0xFF})
+	}
+
+	img := image.NewPaletted(image.Rect(0, 0, 8, 4), palette)
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i % 16)
+	}
+
+	return img
+}
+
+func fixture() []byte {
+	img := paletted(0x00)
+
+	memory := buffer.NewMemory(nil)
+	if err := tim2.ImagePalettedToFile(img, 4, memory); err != nil {
+		panic(err)
+	}
+
+	return memory.Bytes()
+}
+
+func pack(d *dat.Dat) []byte {
+	memory := buffer.NewMemory(nil)
+	if err := d.PackToStream(context.Background(), memory, testutils.Noop, testutils.Noop); err != nil {
+		panic(err)
+	}
+
+	return memory.Bytes()
+}
+
+// NOTE: room DAT with TIM2 entry and SCP entry that contain TIM2, inside temporary directory
+func room(t *testing.T) string {
+	nested := dat.New()
+	nested.AddEntryFromBytesWithType([]byte{0, 1, 2, 3}, "SCR\x00")
+	nested.AddEntryFromBytesWithType(fixture(), "TM2\x00")
+
+	room := dat.New()
+	room.AddEntryFromBytesWithType(fixture(), "ENV\x00")
+	room.AddNullEntry()
+	room.AddEntryFromBytesWithType(pack(nested), "SCP\x00")
+
+	source := t.TempDir()
+	if err := os.WriteFile(filepath.Join(source, "r000.dat"), pack(room), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return source
+}
+
+func TestDump(t *testing.T) {
+	source := room(t)
+
+	output := filepath.Join(source, "png")
+	done := 0
+	manifest, err := texture.Dump(context.Background(), source, output, func(record *texture.Record) { done++ })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, done)
+	assert.Empty(t, manifest.Skips)
+	if assert.Len(t, manifest.Records, 2) {
+		assert.Equal(t, "r000.dat/000_env.png", manifest.Records[0].Png)
+		assert.Equal(t, []int{0}, manifest.Records[0].Nested)
+		assert.Equal(t, "r000.dat/002_scp/001_tm2.png", manifest.Records[1].Png)
+		assert.Equal(t, []int{2, 1}, manifest.Records[1].Nested)
+		assert.Equal(t, texture.FormatTim2, manifest.Records[1].Format)
+		assert.Equal(t, uint(4), manifest.Records[1].Bpp)
+		assert.Equal(t, uint16(8), manifest.Records[1].Width)
+	}
+
+	for _, record := range manifest.Records {
+		assert.FileExists(t, filepath.Join(output, record.Png))
+	}
+
+	t.Run("manifest", func(t *testing.T) {
+		target := filepath.Join(output, texture.ManifestName)
+		if err := texture.ToPath(manifest, target); err != nil {
+			t.Fatal(err)
+		}
+
+		m := texture.New()
+		if err := texture.FromPath(m, target); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, manifest.Records, m.Records)
+	})
+}
+
+func TestInject(t *testing.T) {
+	source := room(t)
+	dir := filepath.Join(source, "png")
+	manifest, err := texture.Dump(context.Background(), source, dir, func(record *texture.Record) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	memory := buffer.NewMemory(nil)
+	if err := png.Encode(memory, paletted(0xFF)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, manifest.Records[1].Png), memory.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	output := t.TempDir()
+	result, err := texture.Inject(context.Background(), manifest, dir, output, false, func(record *texture.Record) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Empty(t, result.Skips)
+	if assert.Len(t, result.Records, 1) {
+		assert.Equal(t, manifest.Records[1], result.Records[0])
+	}
+
+	injected, err := texture.Dump(context.Background(), output, t.TempDir(), func(record *texture.Record) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.Len(t, injected.Records, 2) {
+		assert.Equal(t, manifest.Records[0].Hash, injected.Records[0].Hash)
+		assert.NotEqual(t, manifest.Records[1].Hash, injected.Records[1].Hash)
+	}
+
+	t.Run("output", func(t *testing.T) {
+		_, err := texture.Inject(context.Background(), manifest, dir, "", false, func(record *texture.Record) {})
+		assert.Error(t, err)
+
+		_, err = texture.Inject(context.Background(), manifest, dir, t.TempDir(), true, func(record *texture.Record) {})
+		assert.Error(t, err)
+	})
+
+	t.Run("in place", func(t *testing.T) {
+		result, err := texture.Inject(context.Background(), manifest, dir, "", true, func(record *texture.Record) {})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Len(t, result.Records, 1)
+
+		injected, err := texture.Dump(context.Background(), source, t.TempDir(), func(record *texture.Record) {})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if assert.Len(t, injected.Records, 2) {
+			assert.Equal(t, manifest.Records[0].Hash, injected.Records[0].Hash)
+			assert.NotEqual(t, manifest.Records[1].Hash, injected.Records[1].Hash)
+		}
+	})
+
+	t.Run("not indexed", func(t *testing.T) {
+		memory := buffer.NewMemory(nil)
+		if err := png.Encode(memory, image.NewNRGBA(image.Rect(0, 0, 8, 4))); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, manifest.Records[0].Png), memory.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := texture.Inject(context.Background(), manifest, dir, t.TempDir(), false, func(record *texture.Record) {})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Len(t, result.Records, 1)
+		assert.Len(t, result.Skips, 1)
+	})
+}
+
+// NOTE: DAT with TM3 (two TIM3), T32, and TIM2 with two pictures (second has two CLUT banks), inside temporary directory
+func formats(t *testing.T) string {
+	tm := tm3.New()
+	for _, name := range []string{"a", "b"} {
+		memory := buffer.NewMemory(nil)
+		if err := tim3.ImagePalettedToFile(paletted(0x00), 4, memory); err != nil {
+			t.Fatal(err)
+		}
+		tm.AddEntryFromBytesWithName(memory.Bytes(), name)
+	}
+
+	packed := buffer.NewMemory(nil)
+	if err := tm.PackToStream(context.Background(), packed, testutils.Noop, testutils.Noop); err != nil {
+		t.Fatal(err)
+	}
+
+	// NOTE: T32 template only need clut offset in image header, see t32 image test
+	template := make([]byte, 224+128*64+256+256*4)
+	binary.LittleEndian.PutUint32(template[12:], 128*64+256)
+	texture32 := buffer.NewMemory(nil)
+	if err := t32.ImagePalettedToStream(bytes.NewReader(template), testutils.Paletted(128, 64, 256), texture32); err != nil {
+		t.Fatal(err)
+	}
+
+	room := dat.New()
+	room.AddEntryFromBytesWithType(packed.Bytes(), "TM3\x00")
+	room.AddEntryFromBytesWithType(texture32.Bytes(), "T32\x00")
+	room.AddEntryFromBytesWithType(testutils.Banked(tim2.Signature), "TM2\x00")
+
+	source := t.TempDir()
+	if err := os.WriteFile(filepath.Join(source, "r001.dat"), pack(room), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return source
+}
+
+func TestInjectFormats(t *testing.T) {
+	source := formats(t)
+	dir := filepath.Join(source, "png")
+	manifest, err := texture.Dump(context.Background(), source, dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Empty(t, manifest.Skips)
+	pngs := []string{}
+	for _, record := range manifest.Records {
+		pngs = append(pngs, record.Png)
+	}
+	assert.Equal(t, []string{
+		"r001.dat/000_tm3/000_a.png",
+		"r001.dat/000_tm3/001_b.png",
+		"r001.dat/001_t32.png",
+		"r001.dat/002_tm2_p00.png",
+		"r001.dat/002_tm2_p01_b00.png",
+		"r001.dat/002_tm2_p01_b01.png",
+	}, pngs)
+
+	reversed := func(img *image.Paletted) *image.Paletted {
+		for i := range img.Pix {
+			img.Pix[i] = uint8(len(img.Palette)-1) - img.Pix[i]
+		}
+		return img
+	}
+
+	// NOTE: bank share image data with other bank, recolor only so first bank stay the same
+	recolored := func(name string) *image.Paletted {
+		file, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+
+		decoded, err := png.Decode(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		img := decoded.(*image.Paletted)
+		for i := range img.Palette {
+			img.Palette[i] = color.RGBA{R: uint8(i * 16), G: 0x20, B: 0x30, A: 0xFF}
+		}
+		return img
+	}
+
+	// NOTE: TM3 entry is rebuilt with new size, T32 and second bank of second picture are written in place
+	edits := map[int]*image.Paletted{
+		1: reversed(testutils.Paletted(16, 8, 16)),
+		2: reversed(testutils.Paletted(128, 64, 256)),
+		5: recolored(manifest.Records[5].Png),
+	}
+	hashes := map[int]string{}
+	for i, img := range edits {
+		memory := buffer.NewMemory(nil)
+		if err := png.Encode(memory, img); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, manifest.Records[i].Png), memory.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		hash := sha256.Sum256(memory.Bytes())
+		hashes[i] = hex.EncodeToString(hash[:])
+	}
+
+	output := t.TempDir()
+	result, err := texture.Inject(context.Background(), manifest, dir, output, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Empty(t, result.Skips)
+	assert.Len(t, result.Records, len(edits))
+
+	injected, err := texture.Dump(context.Background(), output, t.TempDir(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.Len(t, injected.Records, len(manifest.Records)) {
+		for i, record := range manifest.Records {
+			hash, ok := hashes[i]
+			if !ok {
+				hash = record.Hash
+			}
+			assert.Equal(t, hash, injected.Records[i].Hash, record.Png)
+		}
+	}
+
+	t.Run("size", func(t *testing.T) {
+		memory := buffer.NewMemory(nil)
+		if err := png.Encode(memory, testutils.Paletted(16, 16, 16)); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, manifest.Records[4].Png), memory.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := texture.Inject(context.Background(), manifest, dir, t.TempDir(), false, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Len(t, result.Records, len(edits))
+		if assert.Len(t, result.Skips, 1) {
+			assert.Contains(t, result.Skips[0].Error, "Image size 16x16 not match with picture 8x8")
+		}
+	})
+}
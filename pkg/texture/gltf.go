@@ -0,0 +1,127 @@
+package texture
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/tim2"
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/modeler"
+)
+
+const (
+	ExtensionDds = "MSFT_texture_dds"
+)
+
+type GltfImage uint8
+
+const (
+	GltfImagePng    GltfImage = 0x00 // NOTE: PNG embedded in buffer
+	GltfImagePngUri GltfImage = 0x01 // NOTE: external PNG file
+	GltfImageDds    GltfImage = 0x02 // NOTE: external DDS with MSFT_texture_dds, external PNG as fallback
+)
+
+var GltfImages = []GltfImage{GltfImagePng, GltfImagePngUri, GltfImageDds}
+
+func (self GltfImage) String() string {
+	switch self {
+	case GltfImagePng:
+		return "PNG (Embedded)"
+	case GltfImagePngUri:
+		return "PNG (External)"
+	case GltfImageDds:
+		return "DDS (External)"
+	default:
+		return "Unknown"
+	}
+}
+
+// NOTE: how texture is stored in glTF, Dir is glTF directory and only used by external image
+type GltfOption struct {
+	Image     GltfImage
+	DdsFormat tim2.DdsFormat
+	Mipmaps   bool
+	Dir       string
+}
+
+func NewGltfOption(dir string) *GltfOption {
+	return &GltfOption{
+		Image:     GltfImagePng,
+		DdsFormat: tim2.DdsFormatRGBA8,
+		Mipmaps:   true,
+		Dir:       dir,
+	}
+}
+
+func writeUri(doc *gltf.Document, dir string, uri string, data []byte) (int, error) {
+	if err := os.WriteFile(filepath.Join(dir, uri), data, 0644); err != nil {
+		return 0, err
+	}
+
+	doc.Images = append(doc.Images, &gltf.Image{
+		Name: uri,
+		URI:  uri,
+	})
+
+	return len(doc.Images) - 1, nil
+}
+
+// NOTE: add image and texture, return texture index. Nil option is embedded PNG.
+// KTX2 is only allowed in glTF through KHR_texture_basisu (Basis Universal), so uncompressed KTX2 is not an option
+func WriteGltfTexture(doc *gltf.Document, name string, img *image.NRGBA, option *GltfOption) (int, error) {
+	if option == nil {
+		option = NewGltfOption("")
+	}
+
+	buf := bytes.Buffer{}
+	if err := png.Encode(&buf, img); err != nil {
+		return 0, err
+	}
+
+	texture := &gltf.Texture{}
+
+	switch option.Image {
+	case GltfImagePng:
+		index, err := modeler.WriteImage(doc, name, "image/png", &buf)
+		if err != nil {
+			return 0, err
+		}
+		texture.Source = gltf.Index(index)
+	case GltfImagePngUri, GltfImageDds:
+		index, err := writeUri(doc, option.Dir, fmt.Sprintf("%s.png", name), buf.Bytes())
+		if err != nil {
+			return 0, err
+		}
+		texture.Source = gltf.Index(index)
+	default:
+		return 0, fmt.Errorf("glTF image %d is not supported", option.Image)
+	}
+
+	switch option.Image {
+	case GltfImageDds:
+		memory := buffer.NewMemory(nil)
+		if err := tim2.ImageToDds(img, option.DdsFormat, option.Mipmaps, memory); err != nil {
+			return 0, err
+		}
+
+		index, err := writeUri(doc, option.Dir, fmt.Sprintf("%s.dds", name), memory.Bytes())
+		if err != nil {
+			return 0, err
+		}
+
+		texture.Extensions = gltf.Extensions{ExtensionDds: map[string]int{"source": index}}
+		if !slices.Contains(doc.ExtensionsUsed, ExtensionDds) {
+			doc.ExtensionsUsed = append(doc.ExtensionsUsed, ExtensionDds)
+		}
+	}
+
+	doc.Textures = append(doc.Textures, texture)
+
+	return len(doc.Textures) - 1, nil
+}
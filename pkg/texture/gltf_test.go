@@ -0,0 +1,49 @@
+package texture_test
+
+import (
+	"image"
+	"path/filepath"
+	"testing"
+
+	"github.com/anasrar/chihuahua/pkg/texture"
+	"github.com/qmuntal/gltf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteGltfTexture(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+
+	t.Run("embedded", func(t *testing.T) {
+		doc := gltf.NewDocument()
+		index, err := texture.WriteGltfTexture(doc, "a", img, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, 0, index)
+		assert.NotNil(t, doc.Images[0].BufferView)
+	})
+
+	for _, image := range []texture.GltfImage{texture.GltfImagePngUri, texture.GltfImageDds} {
+		t.Run(image.String(), func(t *testing.T) {
+			dir := t.TempDir()
+			option := texture.NewGltfOption(dir)
+			option.Image = image
+
+			doc := gltf.NewDocument()
+			index, err := texture.WriteGltfTexture(doc, "a", img, option)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, "a.png", doc.Images[*doc.Textures[index].Source].URI)
+			assert.FileExists(t, filepath.Join(dir, "a.png"))
+
+			if image == texture.GltfImageDds {
+				assert.Equal(t, []string{texture.ExtensionDds}, doc.ExtensionsUsed)
+				assert.Equal(t, map[string]int{"source": 1}, doc.Textures[index].Extensions[texture.ExtensionDds])
+				assert.FileExists(t, filepath.Join(dir, "a.dds"))
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package texture
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/dat"
+	"github.com/anasrar/chihuahua/pkg/t32"
+	"github.com/anasrar/chihuahua/pkg/tim2"
+	"github.com/anasrar/chihuahua/pkg/tim3"
+	"github.com/anasrar/chihuahua/pkg/tm3"
+)
+
+type injector struct {
+	manifest *Manifest
+	dir      string
+	output   string
+	inPlace  bool
+	copied   map[string]bool
+}
+
+func loadPng(buf []byte) (*image.Paletted, error) {
+	img, err := png.Decode(bytes.NewReader(buf))
+	if err != nil {
+		return nil, err
+	}
+
+	imgPaletted, ok := img.(*image.Paletted)
+	if !ok {
+		return nil, fmt.Errorf("PNG is not in indexed mode")
+	}
+
+	return imgPaletted, nil
+}
+
+// NOTE: container file that is rebuilt, copy of container inside output unless in place
+func (self *injector) target(container string) (string, error) {
+	if self.inPlace {
+		return container, nil
+	}
+
+	rel, err := filepath.Rel(self.manifest.Root, container)
+	if err != nil {
+		return "", err
+	}
+
+	target := filepath.Join(self.output, rel)
+	if self.copied[target] {
+		return target, nil
+	}
+
+	buf, err := os.ReadFile(container)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(target, buf, 0644); err != nil {
+		return "", err
+	}
+
+	self.copied[target] = true
+
+	return target, nil
+}
+
+// NOTE: texture with single picture and single CLUT bank is rebuilt so image size can change. Picture and CLUT bank of
+// other texture is written in place, so other pictures and banks are kept and image size must stay the same
+func (self *injector) pictures(
+	record *Record,
+	target string,
+	offset uint32,
+	pictures []*tim2.Picture,
+	rebuild func() ([]byte, error),
+	write func(stream io.ReadWriteSeeker) error,
+) ([]byte, error) {
+	if len(pictures) == 1 && pictures[0].ClutBankTotal() == 1 {
+		if record.Picture != 0 || record.Bank != 0 {
+			return nil, fmt.Errorf("Picture %d bank %d not found, texture has single picture", record.Picture, record.Bank)
+		}
+
+		return rebuild()
+	}
+
+	file, err := os.Open(target)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// NOTE: file header (16) then every picture
+	size := uint32(16)
+	for _, picture := range pictures {
+		size += picture.TotalSize
+	}
+
+	buf := make([]byte, size)
+	if _, err := file.ReadAt(buf, int64(offset)); err != nil {
+		return nil, err
+	}
+
+	memory := buffer.NewMemory(buf)
+	if err := write(memory); err != nil {
+		return nil, err
+	}
+
+	return memory.Bytes(), nil
+}
+
+func (self *injector) tim3(record *Record, target string, img *image.Paletted, offset uint32) ([]byte, error) {
+	tim := tim3.New()
+	if err := tim3.FromPathWithOffset(tim, target, offset); err != nil {
+		return nil, err
+	}
+
+	return self.pictures(
+		record,
+		target,
+		offset,
+		tim.Pictures,
+		func() ([]byte, error) {
+			return tim3.ImagePalettedToBytesWithTim(img, tim)
+		},
+		func(stream io.ReadWriteSeeker) error {
+			return tim3.WritePictureToStreamWithOffset(stream, 0, tim.Pictures, record.Picture, record.Bank, img)
+		},
+	)
+}
+
+func (self *injector) tim2(record *Record, target string, img *image.Paletted, offset uint32) ([]byte, error) {
+	tim := tim2.New()
+	if err := tim2.FromPathWithOffset(tim, target, offset); err != nil {
+		return nil, err
+	}
+
+	return self.pictures(
+		record,
+		target,
+		offset,
+		tim.Pictures,
+		func() ([]byte, error) {
+			return tim2.ImagePalettedToBytesWithTim(img, tim)
+		},
+		func(stream io.ReadWriteSeeker) error {
+			return tim2.WritePictureToStreamWithOffset(stream, 0, tim.Pictures, record.Picture, record.Bank, img)
+		},
+	)
+}
+
+func (self *injector) t32(target string, img *image.Paletted, offset uint32) ([]byte, error) {
+	file, err := os.Open(target)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	memory := buffer.NewMemory(nil)
+	section := io.NewSectionReader(file, int64(offset), info.Size()-int64(offset))
+	if err := t32.ImagePalettedToStream(section, img, memory); err != nil {
+		return nil, err
+	}
+
+	return memory.Bytes(), nil
+}
+
+// NOTE: rebuild texture then every container from the innermost TM3 or DAT to the file on disk, same as tm3replace
+func (self *injector) inject(record *Record, img *image.Paletted) error {
+	target, err := self.target(record.Container)
+	if err != nil {
+		return err
+	}
+
+	data, err := dat.ReplaceNested(target, record.Nested, func(offset uint32, size uint32) ([]byte, error) {
+		switch {
+		case record.Entry >= 0:
+			tm := tm3.New()
+			if err := tm3.FromPathWithOffsetSize(tm, target, offset, size); err != nil {
+				return nil, err
+			}
+
+			if record.Entry >= len(tm.Entries) {
+				return nil, fmt.Errorf("Entry index %d out of range, total %d", record.Entry, len(tm.Entries))
+			}
+
+			data, err := self.tim3(record, target, img, tm.Entries[record.Entry].Offset)
+			if err != nil {
+				return nil, err
+			}
+
+			return tm.Replace(record.Entry, data)
+		case record.Format == FormatTim3:
+			return self.tim3(record, target, img, offset)
+		case record.Format == FormatTim2:
+			return self.tim2(record, target, img, offset)
+		case record.Format == FormatT32:
+			return self.t32(target, img, offset)
+		default:
+			return nil, fmt.Errorf("Format %s not supported", record.Format)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(target, data, 0644)
+}
+
+// NOTE: inject every edited PNG (hash differ from dump) back to its container, dir is manifest directory.
+// Container is copied to output (relative to manifest root) first, original game container is only overwritten when
+// inPlace is set and output is empty. Returned manifest contain injected record and skipped record with reason,
+// onDone (can be nil) is called after each record is injected
+func Inject(
+	ctx context.Context,
+	manifest *Manifest,
+	dir string,
+	output string,
+	inPlace bool,
+	onDone func(record *Record),
+) (*Manifest, error) {
+	if inPlace && output != "" {
+		return nil, fmt.Errorf("Output can not be used with in place")
+	}
+
+	if !inPlace && output == "" {
+		return nil, fmt.Errorf("Output is empty, overwrite container in place must be explicit")
+	}
+
+	result := New()
+	result.Root = manifest.Root
+
+	i := &injector{
+		manifest: manifest,
+		dir:      dir,
+		output:   output,
+		inPlace:  inPlace,
+		copied:   map[string]bool{},
+	}
+
+	for _, record := range manifest.Records {
+		select {
+		case <-ctx.Done():
+			return result, fmt.Errorf("Canceled")
+		default:
+		}
+
+		buf, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(record.Png)))
+		if err != nil {
+			return result, err
+		}
+
+		hash := sha256.Sum256(buf)
+		if record.Hash == hex.EncodeToString(hash[:]) {
+			continue
+		}
+
+		img, err := loadPng(buf)
+		if err == nil {
+			err = i.inject(record, img)
+		}
+
+		if err != nil {
+			result.Skips = append(result.Skips, &Skip{
+				Container: record.Container,
+				Nested:    record.Nested,
+				Entry:     record.Entry,
+				Error:     fmt.Sprintf("%s: %s", record.Png, err),
+			})
+			continue
+		}
+
+		result.Records = append(result.Records, record)
+		if onDone != nil {
+			onDone(record)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,80 @@
+package texture
+
+import (
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	ManifestName = "manifest.json"
+
+	FormatTim2 = "TIM2"
+	FormatTim3 = "TIM3"
+	FormatT32  = "T32"
+)
+
+// NOTE: one PNG and where it come from, nested and entry use the same index as tm3replace
+type Record struct {
+	Png       string `json:"png"`       // NOTE: relative to manifest directory
+	Container string `json:"container"` // NOTE: file on disk
+	Nested    []int  `json:"nested"`    // NOTE: DAT entry indices from container to texture or TM3
+	Entry     int    `json:"entry"`     // NOTE: TM3 entry index, -1 when texture is not inside TM3
+	Format    string `json:"format"`
+	Picture   int    `json:"picture"`
+	Bank      int    `json:"bank"`
+	Bpp       uint   `json:"bpp"`
+	Width     uint16 `json:"width"`
+	Height    uint16 `json:"height"`
+	Hash      string `json:"hash"` // NOTE: SHA-256 of dumped PNG, PNG with the same hash is not edited
+}
+
+// NOTE: texture that can not be decoded, kept so dump never hide data silently
+type Skip struct {
+	Container string `json:"container"`
+	Nested    []int  `json:"nested"`
+	Entry     int    `json:"entry"`
+	Error     string `json:"error"`
+}
+
+type Manifest struct {
+	Root    string    `json:"root"` // NOTE: source directory, container is relative to root when injected to output
+	Records []*Record `json:"records"`
+	Skips   []*Skip   `json:"skips"`
+}
+
+func NestedString(nested []int) string {
+	parts := []string{}
+	for _, index := range nested {
+		parts = append(parts, strconv.Itoa(index))
+	}
+
+	return strings.Join(parts, "/")
+}
+
+func New() *Manifest {
+	return &Manifest{
+		Root:    "",
+		Records: []*Record{},
+		Skips:   []*Skip{},
+	}
+}
+
+func FromPath(manifest *Manifest, filePath string) error {
+	buf, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(buf, manifest)
+}
+
+func ToPath(manifest *Manifest, filePath string) error {
+	buf, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filePath, buf, 0644)
+}
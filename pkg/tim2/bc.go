@@ -0,0 +1,180 @@
+package tim2
+
+import (
+	"encoding/binary"
+	"image"
+)
+
+// NOTE: 4x4 block of NRGBA pixels, edge outside image repeat last row or column
+func block(img *image.NRGBA, bx int, by int) [16][4]uint8 {
+	result := [16][4]uint8{}
+	width := img.Rect.Dx()
+	height := img.Rect.Dy()
+	for y := range 4 {
+		for x := range 4 {
+			sx := min(bx+x, width-1)
+			sy := min(by+y, height-1)
+			i := img.PixOffset(img.Rect.Min.X+sx, img.Rect.Min.Y+sy)
+			copy(result[y*4+x][:], img.Pix[i:i+4])
+		}
+	}
+
+	return result
+}
+
+func to565(c [4]uint8) uint16 {
+	return uint16(c[0]>>3)<<11 | uint16(c[1]>>2)<<5 | uint16(c[2]>>3)
+}
+
+func from565(v uint16) [3]int {
+	r := int(v>>11) & 0x1F
+	g := int(v>>5) & 0x3F
+	b := int(v) & 0x1F
+	return [3]int{r<<3 | r>>2, g<<2 | g>>4, b<<3 | b>>2}
+}
+
+func distance(a [3]int, b [4]uint8) int {
+	dr := a[0] - int(b[0])
+	dg := a[1] - int(b[1])
+	db := a[2] - int(b[2])
+	return dr*dr + dg*dg + db*db
+}
+
+// NOTE: BC1 color block with bounding box endpoints, punchThrough use 3 colors mode where index 3 is transparent (alpha < 128)
+func encodeColorBlock(pixels [16][4]uint8, punchThrough bool) []byte {
+	lo := [4]uint8{0xFF, 0xFF, 0xFF, 0}
+	hi := [4]uint8{0, 0, 0, 0}
+	opaque := 0
+	for _, p := range pixels {
+		if punchThrough && p[3] < 128 {
+			continue
+		}
+		opaque++
+		for c := range 3 {
+			lo[c] = min(lo[c], p[c])
+			hi[c] = max(hi[c], p[c])
+		}
+	}
+
+	if opaque == 0 {
+		lo = [4]uint8{}
+		hi = [4]uint8{}
+	}
+
+	c0 := to565(hi)
+	c1 := to565(lo)
+	transparent := punchThrough && opaque != 16
+
+	// NOTE: c0 > c1 select 4 colors mode, c0 <= c1 select 3 colors mode
+	if transparent {
+		if c0 > c1 {
+			c0, c1 = c1, c0
+		}
+	} else {
+		if c0 < c1 {
+			c0, c1 = c1, c0
+		}
+	}
+
+	e0 := from565(c0)
+	e1 := from565(c1)
+	palette := [][3]int{e0, e1}
+	if c0 > c1 {
+		palette = append(palette,
+			[3]int{(2*e0[0] + e1[0]) / 3, (2*e0[1] + e1[1]) / 3, (2*e0[2] + e1[2]) / 3},
+			[3]int{(e0[0] + 2*e1[0]) / 3, (e0[1] + 2*e1[1]) / 3, (e0[2] + 2*e1[2]) / 3},
+		)
+	} else {
+		palette = append(palette,
+			[3]int{(e0[0] + e1[0]) / 2, (e0[1] + e1[1]) / 2, (e0[2] + e1[2]) / 2},
+		)
+	}
+
+	indices := uint32(0)
+	for i, p := range pixels {
+		best := 0
+		if transparent && p[3] < 128 {
+			best = 3
+		} else {
+			bestDistance := -1
+			for k, c := range palette {
+				if d := distance(c, p); bestDistance < 0 || d < bestDistance {
+					best = k
+					bestDistance = d
+				}
+			}
+		}
+
+		indices |= uint32(best) << (i * 2)
+	}
+
+	result := make([]byte, 8)
+	binary.LittleEndian.PutUint16(result[0:], c0)
+	binary.LittleEndian.PutUint16(result[2:], c1)
+	binary.LittleEndian.PutUint32(result[4:], indices)
+
+	return result
+}
+
+// NOTE: BC3 alpha block with min and max endpoints in 8 alphas mode
+func encodeAlphaBlock(pixels [16][4]uint8) []byte {
+	a0 := uint8(0)
+	a1 := uint8(0xFF)
+	for _, p := range pixels {
+		a0 = max(a0, p[3])
+		a1 = min(a1, p[3])
+	}
+
+	result := make([]byte, 8)
+	result[0] = a0
+	result[1] = a1
+
+	if a0 == a1 {
+		return result
+	}
+
+	palette := [8]int{int(a0), int(a1)}
+	for i := 1; i < 7; i++ {
+		palette[i+1] = ((7-i)*int(a0) + i*int(a1)) / 7
+	}
+
+	indices := uint64(0)
+	for i, p := range pixels {
+		best := 0
+		bestDistance := -1
+		for k, a := range palette {
+			d := a - int(p[3])
+			if d*d < bestDistance || bestDistance < 0 {
+				best = k
+				bestDistance = d * d
+			}
+		}
+
+		indices |= uint64(best) << (i * 3)
+	}
+
+	for i := range 6 {
+		result[2+i] = uint8(indices >> (i * 8))
+	}
+
+	return result
+}
+
+// NOTE: compress image as BC1 (DXT1) or BC3 (DXT5), width and height do not need to be multiple of 4
+func compress(img *image.NRGBA, format DdsFormat) []byte {
+	result := []byte{}
+	for y := 0; y < img.Rect.Dy(); y += 4 {
+		for x := 0; x < img.Rect.Dx(); x += 4 {
+			pixels := block(img, x, y)
+			switch format {
+			case DdsFormatBC1:
+				result = append(result, encodeColorBlock(pixels, true)...)
+			case DdsFormatBC3:
+				result = append(result, encodeAlphaBlock(pixels)...)
+				result = append(result, encodeColorBlock(pixels, false)...)
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,214 @@
+package tim2
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"os"
+
+	"github.com/anasrar/chihuahua/pkg/buffer"
+)
+
+// NOTE: swap second and third 8 colors on every 32 colors, twiddle twice give back the original order
+func TwiddleClut(colors []*color.RGBA) []*color.RGBA {
+	if len(colors) < 32 {
+		return colors
+	}
+
+	twiddle := []*color.RGBA{}
+	for i := 0; i+32 <= len(colors); i += 32 {
+		twiddle = append(twiddle, colors[i+0:i+8]...)
+		twiddle = append(twiddle, colors[i+16:i+24]...)
+		twiddle = append(twiddle, colors[i+8:i+16]...)
+		twiddle = append(twiddle, colors[i+24:i+32]...)
+	}
+
+	return twiddle
+}
+
+// NOTE: CLUT color is straight alpha (same as NRGBA), keep CLUT order so index in image data stay the same
+func ClutToPalette(colors []*color.RGBA) color.Palette {
+	palette := color.Palette{}
+	for _, c := range colors {
+		palette = append(palette, color.NRGBA{R: c.R, G: c.G, B: c.B, A: c.A})
+	}
+
+	return palette
+}
+
+// NOTE: PNG decoder give color.RGBA for opaque palette and color.NRGBA for palette with transparency
+func ColorToClut(c color.Color) *color.RGBA {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return &color.RGBA{R: n.R, G: n.G, B: n.B, A: n.A}
+}
+
+// NOTE: PS2 alpha range is 0x00 - 0x80
+func AlphaToGs(a uint8) uint8 {
+	return uint8(math.Round(float64(a) / 0xFF * 0x80))
+}
+
+func ClutToBytes(colors []*color.RGBA) []byte {
+	result := []byte{}
+	for _, c := range TwiddleClut(colors) {
+		result = append(result, c.R, c.G, c.B, AlphaToGs(c.A))
+	}
+
+	return result
+}
+
+// NOTE: stored size of picture found by seekPicture
+type pictureHeader struct {
+	position   uint64
+	clutSize   uint32
+	imageSize  uint32
+	headerSize uint16
+	clutColors uint16
+}
+
+// NOTE: TIM3 has the same header and picture layout as TIM2, only signature is different
+func seekPicture(stream io.ReadSeeker, offset uint32, signature uint32, pictureIndex int) (*pictureHeader, error) {
+	if _, err := buffer.Seek(stream, int64(offset), buffer.SeekStart); err != nil {
+		return nil, err
+	}
+
+	found := uint32(0)
+	if _, err := buffer.ReadUint32LE(stream, &found); err != nil {
+		return nil, err
+	}
+
+	if found != signature {
+		return nil, fmt.Errorf("Signature 0x%08X not match, expected 0x%08X", found, signature)
+	}
+
+	if _, err := buffer.Seek(stream, 2, buffer.SeekCurrent); err != nil {
+		return nil, err
+	}
+
+	pictureTotal := uint16(0)
+	if _, err := buffer.ReadUint16LE(stream, &pictureTotal); err != nil {
+		return nil, err
+	}
+
+	if pictureIndex < 0 || pictureIndex >= int(pictureTotal) {
+		return nil, fmt.Errorf("Picture index %d out of range, total %d", pictureIndex, pictureTotal)
+	}
+
+	position, err := buffer.Seek(stream, 8, buffer.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+
+	for range pictureIndex {
+		totalSize := uint32(0)
+		if _, err := buffer.ReadUint32LE(stream, &totalSize); err != nil {
+			return nil, err
+		}
+
+		if position, err = buffer.Seek(stream, int64(position)+int64(totalSize), buffer.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+
+	header := &pictureHeader{position: position}
+
+	// NOTE: Picture.total_size
+	if _, err := buffer.Seek(stream, 4, buffer.SeekCurrent); err != nil {
+		return nil, err
+	}
+
+	if _, err := buffer.ReadUint32LE(stream, &header.clutSize); err != nil {
+		return nil, err
+	}
+
+	if _, err := buffer.ReadUint32LE(stream, &header.imageSize); err != nil {
+		return nil, err
+	}
+
+	if _, err := buffer.ReadUint16LE(stream, &header.headerSize); err != nil {
+		return nil, err
+	}
+
+	if _, err := buffer.ReadUint16LE(stream, &header.clutColors); err != nil {
+		return nil, err
+	}
+
+	return header, nil
+}
+
+func WriteClutToStreamWithSignature(
+	stream io.ReadWriteSeeker,
+	offset uint32,
+	signature uint32,
+	pictureIndex int,
+	colors []*color.RGBA,
+) error {
+	header, err := seekPicture(stream, offset, signature, pictureIndex)
+	if err != nil {
+		return err
+	}
+
+	if header.clutSize != uint32(header.clutColors)*4 {
+		return fmt.Errorf("Only 32 bit CLUT supported")
+	}
+
+	if len(colors) != int(header.clutColors) {
+		return fmt.Errorf("CLUT colors is not match, expected %d, got %d", header.clutColors, len(colors))
+	}
+
+	if _, err := buffer.Seek(stream, int64(header.position)+int64(header.headerSize)+int64(header.imageSize), buffer.SeekStart); err != nil {
+		return err
+	}
+
+	if _, err := buffer.WriteBytes(stream, ClutToBytes(colors)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// NOTE: data is written at the start of image data, data longer than stored image size is rejected
+func WriteImageDataToStreamWithSignature(
+	stream io.ReadWriteSeeker,
+	offset uint32,
+	signature uint32,
+	pictureIndex int,
+	data []byte,
+) error {
+	header, err := seekPicture(stream, offset, signature, pictureIndex)
+	if err != nil {
+		return err
+	}
+
+	if len(data) > int(header.imageSize) {
+		return fmt.Errorf("Image data size is not match, expected at most %d, got %d", header.imageSize, len(data))
+	}
+
+	if _, err := buffer.Seek(stream, int64(header.position)+int64(header.headerSize), buffer.SeekStart); err != nil {
+		return err
+	}
+
+	if _, err := buffer.WriteBytes(stream, data); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func WriteClutToStreamWithOffset(stream io.ReadWriteSeeker, offset uint32, pictureIndex int, colors []*color.RGBA) error {
+	return WriteClutToStreamWithSignature(stream, offset, Signature, pictureIndex, colors)
+}
+
+func WriteClutWithOffset(filePath string, offset uint32, pictureIndex int, colors []*color.RGBA) error {
+	file, err := os.OpenFile(filePath, os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return WriteClutToStreamWithOffset(file, offset, pictureIndex, colors)
+}
+
+func WriteClut(filePath string, pictureIndex int, colors []*color.RGBA) error {
+	return WriteClutWithOffset(filePath, 0, pictureIndex, colors)
+}
@@ -0,0 +1,33 @@
+package tim2_test
+
+import (
+	"image"
+	"io"
+	"testing"
+
+	"github.com/anasrar/chihuahua/internal/testutils"
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/tim2"
+)
+
+func TestWriteClut(t *testing.T) {
+	toTim := func(img *image.Paletted, bpp uint) ([]byte, error) {
+		memory := buffer.NewMemory(nil)
+		if err := tim2.ImagePalettedToFile(img, bpp, memory); err != nil {
+			return nil, err
+		}
+
+		return memory.Bytes(), nil
+	}
+
+	parse := func(stream io.ReadSeeker, offset uint32) (*tim2.Picture, error) {
+		tim := tim2.New()
+		if err := tim2.FromStreamWithOffset(tim, stream, offset); err != nil {
+			return nil, err
+		}
+
+		return tim.Pictures[0], nil
+	}
+
+	testutils.WriteClutRoundTrip(t, toTim, parse, tim2.WriteClutToStreamWithOffset)
+}
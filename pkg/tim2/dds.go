@@ -0,0 +1,159 @@
+package tim2
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+
+	"github.com/anasrar/chihuahua/pkg/buffer"
+)
+
+const (
+	DdsSignature uint32 = 0x20534444 // NOTE: "DDS "
+
+	ddsHeaderSize      = 124
+	ddsPixelFormatSize = 32
+
+	ddsFlagCaps        = 0x1
+	ddsFlagHeight      = 0x2
+	ddsFlagWidth       = 0x4
+	ddsFlagPitch       = 0x8
+	ddsFlagPixelFormat = 0x1000
+	ddsFlagMipMapCount = 0x20000
+	ddsFlagLinearSize  = 0x80000
+
+	ddsPixelFlagAlphaPixels = 0x1
+	ddsPixelFlagFourCC      = 0x4
+	ddsPixelFlagRGB         = 0x40
+
+	ddsCapsComplex = 0x8
+	ddsCapsTexture = 0x1000
+	ddsCapsMipMap  = 0x400000
+)
+
+type DdsFormat uint8
+
+const (
+	DdsFormatRGBA8 DdsFormat = 0x00 // NOTE: uncompressed 32 bit, byte order R G B A
+	DdsFormatBC1   DdsFormat = 0x01 // NOTE: DXT1 with 1 bit alpha
+	DdsFormatBC3   DdsFormat = 0x02 // NOTE: DXT5 with interpolated alpha
+)
+
+func (self DdsFormat) String() string {
+	switch self {
+	case DdsFormatRGBA8:
+		return "RGBA8"
+	case DdsFormatBC1:
+		return "BC1"
+	case DdsFormatBC3:
+		return "BC3"
+	default:
+		return "Unknown"
+	}
+}
+
+func (self DdsFormat) fourCC() uint32 {
+	switch self {
+	case DdsFormatBC1:
+		return 0x31545844 // NOTE: "DXT1"
+	case DdsFormatBC3:
+		return 0x35545844 // NOTE: "DXT5"
+	default:
+		return 0
+	}
+}
+
+func (self DdsFormat) levelData(img *image.NRGBA) []byte {
+	if self == DdsFormatRGBA8 {
+		data := make([]byte, 0, img.Rect.Dx()*img.Rect.Dy()*4)
+		for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+			i := img.PixOffset(img.Rect.Min.X, y)
+			data = append(data, img.Pix[i:i+img.Rect.Dx()*4]...)
+		}
+		return data
+	}
+
+	return compress(img, self)
+}
+
+// NOTE: legacy DDS header (no DX10 header) so old tools (Noesis, Blender) can read it, levels start from base image
+func MipmapsToDds(levels []*image.NRGBA, format DdsFormat, output io.WriteSeeker) error {
+	if format > DdsFormatBC3 {
+		return fmt.Errorf("DDS format %d is not supported", format)
+	}
+
+	if len(levels) == 0 {
+		return fmt.Errorf("DDS has no level")
+	}
+
+	img := levels[0]
+	width := img.Rect.Dx()
+	height := img.Rect.Dy()
+
+	flags := uint32(ddsFlagCaps | ddsFlagHeight | ddsFlagWidth | ddsFlagPixelFormat)
+	caps := uint32(ddsCapsTexture)
+	pitchOrLinearSize := uint32(0)
+	if format == DdsFormatRGBA8 {
+		flags |= ddsFlagPitch
+		pitchOrLinearSize = uint32(width * 4)
+	} else {
+		flags |= ddsFlagLinearSize
+		pitchOrLinearSize = uint32(len(format.levelData(img)))
+	}
+
+	if len(levels) > 1 {
+		flags |= ddsFlagMipMapCount
+		caps |= ddsCapsComplex | ddsCapsMipMap
+	}
+
+	header := make([]byte, 4+ddsHeaderSize)
+	binary.LittleEndian.PutUint32(header[0:], DdsSignature)
+	binary.LittleEndian.PutUint32(header[4:], ddsHeaderSize)
+	binary.LittleEndian.PutUint32(header[8:], flags)
+	binary.LittleEndian.PutUint32(header[12:], uint32(height))
+	binary.LittleEndian.PutUint32(header[16:], uint32(width))
+	binary.LittleEndian.PutUint32(header[20:], pitchOrLinearSize)
+	binary.LittleEndian.PutUint32(header[28:], uint32(len(levels)))
+
+	// NOTE: DDS_PIXELFORMAT
+	binary.LittleEndian.PutUint32(header[76:], ddsPixelFormatSize)
+	if format == DdsFormatRGBA8 {
+		binary.LittleEndian.PutUint32(header[80:], ddsPixelFlagRGB|ddsPixelFlagAlphaPixels)
+		binary.LittleEndian.PutUint32(header[88:], 32)
+		binary.LittleEndian.PutUint32(header[92:], 0x000000FF)
+		binary.LittleEndian.PutUint32(header[96:], 0x0000FF00)
+		binary.LittleEndian.PutUint32(header[100:], 0x00FF0000)
+		binary.LittleEndian.PutUint32(header[104:], 0xFF000000)
+	} else {
+		binary.LittleEndian.PutUint32(header[80:], ddsPixelFlagFourCC)
+		binary.LittleEndian.PutUint32(header[84:], format.fourCC())
+	}
+
+	binary.LittleEndian.PutUint32(header[108:], caps)
+
+	if _, err := buffer.WriteBytes(output, header); err != nil {
+		return err
+	}
+
+	for _, level := range levels {
+		if _, err := buffer.WriteBytes(output, format.levelData(level)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func ImageToDds(img *image.NRGBA, format DdsFormat, mipmaps bool, output io.WriteSeeker) error {
+	return MipmapsToDds(Mipmaps(img, mipmaps), format, output)
+}
+
+func PictureToDds(picture *Picture, format DdsFormat, mipmaps bool, output io.WriteSeeker) error {
+	levels, err := PictureMipmaps(picture, mipmaps, PictureToImage)
+	if err != nil {
+		return err
+	}
+
+	return MipmapsToDds(levels, format, output)
+}
@@ -0,0 +1,166 @@
+package tim2_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/tim2"
+	"github.com/stretchr/testify/assert"
+)
+
+func solid(width int, height int, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+
+	return img
+}
+
+func TestMipmaps(t *testing.T) {
+	levels := tim2.Mipmaps(solid(8, 2, color.NRGBA{R: 0x10, G: 0x20, B: 0x30, A: 0xFF}), true)
+
+	assert.Len(t, levels, 4)
+	assert.Equal(t, image.Rect(0, 0, 1, 1), levels[3].Rect)
+	assert.Equal(t, color.NRGBA{R: 0x10, G: 0x20, B: 0x30, A: 0xFF}, levels[3].NRGBAAt(0, 0))
+}
+
+// NOTE: 4x4 8 bit picture with 3 stored mip level, every level use its own palette index, level data is 16 byte aligned
+func mipmapped() []byte {
+	buf := make([]byte, 16+80+48+64)
+	binary.LittleEndian.PutUint32(buf[0:], tim2.Signature)
+	buf[4] = 4
+	binary.LittleEndian.PutUint16(buf[6:], 1)
+
+	picture := buf[16:]
+	binary.LittleEndian.PutUint32(picture[0:], uint32(len(picture)))
+	binary.LittleEndian.PutUint32(picture[4:], 64)
+	binary.LittleEndian.PutUint32(picture[8:], 48)
+	binary.LittleEndian.PutUint16(picture[12:], 80)
+	binary.LittleEndian.PutUint16(picture[14:], 16)
+	picture[17] = 3
+	picture[18] = 3
+	picture[19] = uint8(tim2.ImageType8BitTexture)
+	binary.LittleEndian.PutUint16(picture[20:], 4)
+	binary.LittleEndian.PutUint16(picture[22:], 4)
+	for i := range 3 {
+		binary.LittleEndian.PutUint32(picture[64+i*4:], 16)
+	}
+
+	data := picture[80:]
+	copy(data[16:], bytes.Repeat([]byte{1}, 4))
+	data[32] = 2
+
+	clut := picture[80+48:]
+	for i := range 16 {
+		copy(clut[i*4:], []byte{uint8(i * 0x10), 0x00, 0x00, 0x80})
+	}
+
+	return buf
+}
+
+func TestPictureMipmaps(t *testing.T) {
+	tim := tim2.New()
+	if err := tim2.FromStream(tim, bytes.NewReader(mipmapped())); err != nil {
+		t.Fatal(err)
+	}
+
+	picture := tim.Pictures[0]
+	assert.Equal(t, []uint32{16, 16, 16}, picture.MipMapSizes)
+	assert.Equal(t, 3, picture.MipMapLevelTotal())
+
+	levels, err := tim2.PictureMipmaps(picture, true, tim2.PictureToImage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// NOTE: stored level is decoded, not generated from base level
+	assert.Len(t, levels, 3)
+	for i, level := range levels {
+		size := 4 >> i
+		assert.Equal(t, image.Rect(0, 0, size, size), level.Rect)
+		assert.Equal(t, color.NRGBA{R: uint8(i * 0x10), A: 0xFF}, level.NRGBAAt(size-1, size-1))
+	}
+
+	levels, err = tim2.PictureMipmaps(picture, false, tim2.PictureToImage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, levels, 1)
+
+	if _, err := picture.MipMapLevel(3); err == nil {
+		t.Fatal("expected error for level outside of stored level")
+	}
+
+	memory := buffer.NewMemory(nil)
+	if err := tim2.PictureToDds(picture, tim2.DdsFormatRGBA8, true, memory); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := memory.Bytes()
+	assert.Equal(t, uint32(3), binary.LittleEndian.Uint32(buf[28:]))
+	assert.Equal(t, 128+(16+4+1)*4, len(buf))
+}
+
+func TestImageToDds(t *testing.T) {
+	img := solid(8, 8, color.NRGBA{R: 0xFF, G: 0x00, B: 0x00, A: 0xFF})
+
+	for _, tc := range []struct {
+		format tim2.DdsFormat
+		size   int
+	}{
+		// NOTE: 8x8, 4x4, 2x2, 1x1
+		{format: tim2.DdsFormatRGBA8, size: (64 + 16 + 4 + 1) * 4},
+		{format: tim2.DdsFormatBC1, size: (4 + 1 + 1 + 1) * 8},
+		{format: tim2.DdsFormatBC3, size: (4 + 1 + 1 + 1) * 16},
+	} {
+		t.Run(tc.format.String(), func(t *testing.T) {
+			memory := buffer.NewMemory(nil)
+			if err := tim2.ImageToDds(img, tc.format, true, memory); err != nil {
+				t.Fatal(err)
+			}
+
+			buf := memory.Bytes()
+			assert.Equal(t, tim2.DdsSignature, binary.LittleEndian.Uint32(buf[0:]))
+			assert.Equal(t, uint32(8), binary.LittleEndian.Uint32(buf[12:]))
+			assert.Equal(t, uint32(4), binary.LittleEndian.Uint32(buf[28:]))
+			assert.Equal(t, 128+tc.size, len(buf))
+		})
+	}
+
+	t.Run("BC1 endpoint", func(t *testing.T) {
+		memory := buffer.NewMemory(nil)
+		if err := tim2.ImageToDds(img, tim2.DdsFormatBC1, false, memory); err != nil {
+			t.Fatal(err)
+		}
+
+		// NOTE: red in RGB565 and every index select color 0
+		buf := memory.Bytes()
+		assert.Equal(t, uint16(0xF800), binary.LittleEndian.Uint16(buf[128:]))
+		assert.Equal(t, uint32(0), binary.LittleEndian.Uint32(buf[132:]))
+	})
+}
+
+func TestImageToKtx2(t *testing.T) {
+	memory := buffer.NewMemory(nil)
+	if err := tim2.ImageToKtx2(solid(4, 2, color.NRGBA{R: 0x01, G: 0x02, B: 0x03, A: 0x04}), true, memory); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := memory.Bytes()
+	assert.Equal(t, tim2.Ktx2Identifier, buf[:12])
+	assert.Equal(t, uint32(3), binary.LittleEndian.Uint32(buf[40:]))
+
+	// NOTE: level 0 is stored last
+	offset := binary.LittleEndian.Uint64(buf[80:])
+	size := binary.LittleEndian.Uint64(buf[88:])
+	assert.Equal(t, uint64(4*2*4), size)
+	assert.Equal(t, uint64(len(buf)), offset+size)
+	assert.Equal(t, []byte{0x01, 0x02, 0x03, 0x04}, buf[offset:offset+4])
+}
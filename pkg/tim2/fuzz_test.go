@@ -0,0 +1,39 @@
+package tim2_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/tim2"
+)
+
+func FuzzFromStream(f *testing.F) {
+	for _, bpp := range []uint{4, 8} {
+		img := image.NewPaletted(image.Rect(0, 0, 16, 16), color.Palette{
+			color.RGBA{R: 0xFF, G: 0x00, B: 0x00, A: 0xFF},
+			color.RGBA{R: 0x00, G: 0xFF, B: 0x00, A: 0xFF},
+		})
+
+		memory := buffer.NewMemory(nil)
+		if err := tim2.ImagePalettedToFile(img, bpp, memory); err != nil {
+			f.Fatal(err)
+		}
+
+		f.Add(memory.Bytes())
+	}
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		tim := tim2.New()
+		if err := tim2.FromStream(tim, bytes.NewReader(data)); err != nil {
+			return
+		}
+
+		for _, picture := range tim.Pictures {
+			_, _ = tim2.PictureToImage(picture)
+		}
+	})
+}
@@ -4,46 +4,65 @@ import (
 	"fmt"
 	"image"
 	"image/color"
+	"io"
 	"math"
-	"os"
 
 	"github.com/anasrar/chihuahua/pkg/buffer"
 )
 
-func PictureToImage(picture *Picture) *image.NRGBA {
-	width := int(picture.ImageWidth)
-	height := int(picture.ImageHeight)
-	data := make([]byte, len(picture.ImageData))
-	copy(data, picture.ImageData)
-	indices := []byte{}
+// NOTE: palette index for every pixel, 4 bit texture is unpacked
+func indices(picture *Picture) ([]byte, error) {
+	size, err := picture.IndexedDataSize()
+	if err != nil {
+		return nil, err
+	}
+	data := picture.ImageData[:size]
+	indices := make([]byte, int(picture.ImageWidth)*int(picture.ImageHeight))
 
 	switch picture.ImageType {
 	case ImageType4BitTexture:
-		for _, v := range data {
-			low := v & 0xF
-			high := (v >> 4) & 0xF
-			indices = append(indices, low)
-			indices = append(indices, high)
-		}
+		UnpackIndices4(indices, data)
 	case ImageType8BitTexture:
-		indices = append(indices, data...)
+		copy(indices, data)
+	}
+
+	return indices, nil
+}
+
+// NOTE: two pixel per byte with low nibble first, dst has one byte for every pixel
+func UnpackIndices4(dst []byte, src []byte) {
+	for i := range dst {
+		dst[i] = (src[i>>1] >> ((i & 1) << 2)) & 0xF
 	}
+}
 
-	raw := []uint8{}
-	for _, index := range indices {
-		c := picture.ClutData[index]
-		raw = append(raw, c.R)
-		raw = append(raw, c.G)
-		raw = append(raw, c.B)
-		raw = append(raw, c.A)
+func PictureToImage(picture *Picture) (*image.NRGBA, error) {
+	width := int(picture.ImageWidth)
+	height := int(picture.ImageHeight)
+	indices, err := indices(picture)
+	if err != nil {
+		return nil, err
 	}
+
 	img := image.NewNRGBA(image.Rect(0, 0, width, height))
-	copy(img.Pix, raw)
+	if err := picture.IndicesToPixelsTo(img.Pix, indices); err != nil {
+		return nil, err
+	}
+
+	return img, nil
+}
+
+// NOTE: indices and CLUT order is kept, so PNG can be converted back without re-indexing
+func PictureToPaletted(picture *Picture) (*image.Paletted, error) {
+	indices, err := indices(picture)
+	if err != nil {
+		return nil, err
+	}
 
-	return img
+	return picture.IndicesToPaletted(indices)
 }
 
-func ImagePalettedToFile(img *image.Paletted, bpp uint, output *os.File) error {
+func ImagePalettedToFile(img *image.Paletted, bpp uint, output io.WriteSeeker) error {
 	colorTotal := len(img.Palette)
 
 	if colorTotal > 256 {
@@ -59,20 +78,18 @@ func ImagePalettedToFile(img *image.Paletted, bpp uint, output *os.File) error {
 	indices := img.Pix
 
 	if bpp == 4 {
-		data := []uint8{}
-		for i := 0; i < len(indices); i += 2 {
-			high := indices[i+1]
-			low := indices[i]
-			value := (high << 4) | low
-			data = append(data, value)
+		data := make([]uint8, len(indices)/2)
+		for i := range data {
+			high := indices[i*2+1]
+			low := indices[i*2]
+			data[i] = (high << 4) | low
 		}
 		indices = data
 	}
 
-	colors := []*color.RGBA{}
+	colors := make([]*color.RGBA, 0, 256)
 	for _, c := range img.Palette {
-		c32, _ := c.(color.RGBA)
-		colors = append(colors, &c32)
+		colors = append(colors, ColorToClut(c))
 	}
 
 	// NOTE: fill colors to 16 or 256
@@ -86,7 +103,7 @@ func ImagePalettedToFile(img *image.Paletted, bpp uint, output *os.File) error {
 		}
 	}
 
-	twiddle := []*color.RGBA{}
+	twiddle := make([]*color.RGBA, 0, 256)
 	if bpp == 8 {
 		for i := 0; i < 256; i += 32 {
 			twiddle = append(twiddle, colors[i+0:i+8]...)
@@ -267,21 +284,19 @@ func ImagePalettedToFile(img *image.Paletted, bpp uint, output *os.File) error {
 	}
 
 	// NOTE: Picture.clut_data
-	switch bpp {
-	case 4:
-		for _, c := range colors {
-			a := uint8(float32(c.A) / 255 * 0x80)
-			if _, err := buffer.WriteBytes(output, []byte{c.R, c.G, c.B, a}); err != nil {
-				return err
-			}
-		}
-	case 8:
-		for _, c := range twiddle {
-			a := uint8(float32(c.A) / 255 * 0x80)
-			if _, err := buffer.WriteBytes(output, []byte{c.R, c.G, c.B, a}); err != nil {
-				return err
-			}
-		}
+	clut := colors
+	if bpp == 8 {
+		clut = twiddle
+	}
+
+	clutData := make([]byte, 0, len(clut)*4)
+	for _, c := range clut {
+		a := uint8(float32(c.A) / 255 * 0x80)
+		clutData = append(clutData, c.R, c.G, c.B, a)
+	}
+
+	if _, err := buffer.WriteBytes(output, clutData); err != nil {
+		return err
 	}
 
 	return nil
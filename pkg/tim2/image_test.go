@@ -0,0 +1,187 @@
+package tim2_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/anasrar/chihuahua/internal/testutils"
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/tim2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPictureToImage(t *testing.T) {
+	picture := &tim2.Picture{
+		ImageType:   tim2.ImageType8BitTexture,
+		ImageWidth:  2,
+		ImageHeight: 2,
+		ImageData:   []byte{0, 1, 1, 0},
+		ClutData: []*color.RGBA{
+			{R: 0xFF, G: 0x00, B: 0x00, A: 0xFF},
+			{R: 0x00, G: 0xFF, B: 0x00, A: 0xFF},
+		},
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		img, err := tim2.PictureToImage(picture)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, color.NRGBA{R: 0x00, G: 0xFF, B: 0x00, A: 0xFF}, img.NRGBAAt(1, 0))
+	})
+
+	t.Run("palette index out of range", func(t *testing.T) {
+		invalid := *picture
+		invalid.ImageData = []byte{0, 1, 2, 0}
+
+		_, err := tim2.PictureToImage(&invalid)
+		assert.Error(t, err)
+	})
+
+	t.Run("image data too short", func(t *testing.T) {
+		invalid := *picture
+		invalid.ImageData = []byte{0, 1}
+
+		_, err := tim2.PictureToImage(&invalid)
+		assert.Error(t, err)
+	})
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		bpp        uint
+		colorTotal int
+	}{
+		{"4 bpp", 4, 16},
+		{"8 bpp", 8, 256},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			img := testutils.Paletted(32, 16, tc.colorTotal)
+
+			memory := buffer.NewMemory(nil)
+			if err := tim2.ImagePalettedToFile(img, tc.bpp, memory); err != nil {
+				t.Fatal(err)
+			}
+
+			tim := tim2.New()
+			if err := tim2.FromStream(tim, bytes.NewReader(memory.Bytes())); err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, uint16(1), tim.PictureTotal)
+			picture := tim.Pictures[0]
+			assert.Equal(t, uint16(32), picture.ImageWidth)
+			assert.Equal(t, uint16(16), picture.ImageHeight)
+
+			result, err := tim2.PictureToImage(picture)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for y := range 16 {
+				for x := range 32 {
+					r, g, b, a := img.At(x, y).RGBA()
+					assert.Equal(t, color.NRGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: uint8(a >> 8)}, result.NRGBAAt(x, y))
+				}
+			}
+		})
+	}
+}
+
+func TestVariants(t *testing.T) {
+	clut := []*color.RGBA{}
+	for i := range 32 {
+		clut = append(clut, &color.RGBA{R: uint8(i), A: 0xFF})
+	}
+
+	banked := &tim2.Picture{
+		ImageType:   tim2.ImageType4BitTexture,
+		ImageWidth:  2,
+		ImageHeight: 1,
+		ImageData:   []byte{0x10},
+		ClutColors:  32,
+		ClutData:    clut,
+	}
+	single := &tim2.Picture{
+		ImageType:   tim2.ImageType8BitTexture,
+		ImageWidth:  1,
+		ImageHeight: 1,
+		ImageData:   []byte{0},
+		ClutColors:  32,
+		ClutData:    clut,
+	}
+
+	variants, err := tim2.Variants([]*tim2.Picture{single, banked})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 3, len(variants))
+	assert.Equal(t, "_p00", variants[0].Suffix())
+	assert.Equal(t, "_p01_b00", variants[1].Suffix())
+	assert.Equal(t, "_p01_b01", variants[2].Suffix())
+	assert.Same(t, single, variants[0].Picture)
+
+	img, err := tim2.PictureToImage(variants[2].Picture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint8(16), img.NRGBAAt(0, 0).R)
+	assert.Equal(t, uint8(17), img.NRGBAAt(1, 0).R)
+
+	_, err = banked.ClutBank(2)
+	assert.Error(t, err)
+}
+
+func TestPalettedRoundTrip(t *testing.T) {
+	toTim := func(img *image.Paletted, bpp uint) (*tim2.Picture, error) {
+		memory := buffer.NewMemory(nil)
+		if err := tim2.ImagePalettedToFile(img, bpp, memory); err != nil {
+			return nil, err
+		}
+
+		tim := tim2.New()
+		if err := tim2.FromStream(tim, bytes.NewReader(memory.Bytes())); err != nil {
+			return nil, err
+		}
+
+		return tim.Pictures[0], nil
+	}
+
+	testutils.PalettedRoundTrip(t, 32, toTim, tim2.PictureToPaletted)
+}
+
+func BenchmarkPictureToImage(b *testing.B) {
+	memory := buffer.NewMemory(nil)
+	if err := tim2.ImagePalettedToFile(testutils.Paletted(256, 256, 256), 8, memory); err != nil {
+		b.Fatal(err)
+	}
+
+	tim := tim2.New()
+	if err := tim2.FromStream(tim, bytes.NewReader(memory.Bytes())); err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("PictureToImage", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			if _, err := tim2.PictureToImage(tim.Pictures[0]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("ImagePalettedToFile", func(b *testing.B) {
+		img := testutils.Paletted(256, 256, 16)
+		b.ReportAllocs()
+		for range b.N {
+			if err := tim2.ImagePalettedToFile(img, 4, buffer.NewMemory(nil)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
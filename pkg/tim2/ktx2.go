@@ -0,0 +1,123 @@
+package tim2
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+
+	"github.com/anasrar/chihuahua/pkg/buffer"
+)
+
+const (
+	ktx2HeaderSize     = 80 // NOTE: identifier (12), header (36), and index (32)
+	ktx2LevelIndexSize = 24
+	ktx2DfdSize        = 4 + 24 + 16*4 // NOTE: total size, basic descriptor block header, and 4 samples
+
+	ktx2FormatRGBA8Srgb = 43 // NOTE: VK_FORMAT_R8G8B8A8_SRGB
+)
+
+var Ktx2Identifier = []byte{0xAB, 0x4B, 0x54, 0x58, 0x20, 0x32, 0x30, 0xBB, 0x0D, 0x0A, 0x1A, 0x0A}
+
+// NOTE: data format descriptor for RGBA8 sRGB with straight alpha
+func ktx2Dfd() []byte {
+	dfd := make([]byte, ktx2DfdSize)
+	binary.LittleEndian.PutUint32(dfd[0:], ktx2DfdSize)
+
+	// NOTE: vendor id and descriptor type are 0 (Khronos basic), version 2
+	binary.LittleEndian.PutUint32(dfd[4:], 0)
+	binary.LittleEndian.PutUint32(dfd[8:], 2|uint32(ktx2DfdSize-4)<<16)
+
+	// NOTE: color model RGBSDA, primaries BT709, transfer sRGB, flags straight alpha
+	dfd[12] = 1
+	dfd[13] = 1
+	dfd[14] = 2
+	dfd[15] = 0
+
+	// NOTE: texel block dimension is 1x1x1x1 (stored minus one), 4 bytes in plane 0
+	dfd[20] = 4
+
+	for i, channel := range []uint8{0, 1, 2, 15} {
+		sample := dfd[28+i*16:]
+		binary.LittleEndian.PutUint16(sample[0:], uint16(i*8))
+		sample[2] = 7 // NOTE: bit length minus one
+
+		// NOTE: alpha is always linear
+		sample[3] = channel
+		if channel == 15 {
+			sample[3] |= 0x10
+		}
+
+		binary.LittleEndian.PutUint32(sample[8:], 0)
+		binary.LittleEndian.PutUint32(sample[12:], 0xFF)
+	}
+
+	return dfd
+}
+
+// NOTE: uncompressed KTX2 (VK_FORMAT_R8G8B8A8_SRGB) without supercompression and key value data, levels start from
+// base image
+func MipmapsToKtx2(levels []*image.NRGBA, output io.WriteSeeker) error {
+	if len(levels) == 0 {
+		return fmt.Errorf("KTX2 has no level")
+	}
+
+	img := levels[0]
+	data := [][]byte{}
+	for _, level := range levels {
+		data = append(data, DdsFormatRGBA8.levelData(level))
+	}
+
+	header := make([]byte, ktx2HeaderSize+ktx2LevelIndexSize*len(levels))
+	copy(header, Ktx2Identifier)
+	binary.LittleEndian.PutUint32(header[12:], ktx2FormatRGBA8Srgb)
+	binary.LittleEndian.PutUint32(header[16:], 1) // NOTE: type size
+	binary.LittleEndian.PutUint32(header[20:], uint32(img.Rect.Dx()))
+	binary.LittleEndian.PutUint32(header[24:], uint32(img.Rect.Dy()))
+	binary.LittleEndian.PutUint32(header[36:], 1) // NOTE: face count
+	binary.LittleEndian.PutUint32(header[40:], uint32(len(levels)))
+
+	dfdOffset := uint32(len(header))
+	binary.LittleEndian.PutUint32(header[48:], dfdOffset)
+	binary.LittleEndian.PutUint32(header[52:], ktx2DfdSize)
+
+	// NOTE: level index start from base level, level data is stored from smallest level
+	offset := uint64(dfdOffset + ktx2DfdSize)
+	for i := len(levels) - 1; i >= 0; i-- {
+		index := header[ktx2HeaderSize+i*ktx2LevelIndexSize:]
+		size := uint64(len(data[i]))
+		binary.LittleEndian.PutUint64(index[0:], offset)
+		binary.LittleEndian.PutUint64(index[8:], size)
+		binary.LittleEndian.PutUint64(index[16:], size)
+		offset += size
+	}
+
+	if _, err := buffer.WriteBytes(output, header); err != nil {
+		return err
+	}
+
+	if _, err := buffer.WriteBytes(output, ktx2Dfd()); err != nil {
+		return err
+	}
+
+	for i := len(levels) - 1; i >= 0; i-- {
+		if _, err := buffer.WriteBytes(output, data[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func ImageToKtx2(img *image.NRGBA, mipmaps bool, output io.WriteSeeker) error {
+	return MipmapsToKtx2(Mipmaps(img, mipmaps), output)
+}
+
+func PictureToKtx2(picture *Picture, mipmaps bool, output io.WriteSeeker) error {
+	levels, err := PictureMipmaps(picture, mipmaps, PictureToImage)
+	if err != nil {
+		return err
+	}
+
+	return MipmapsToKtx2(levels, output)
+}
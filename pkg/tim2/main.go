@@ -1,12 +1,11 @@
 package tim2
 
 import (
-	"fmt"
 	"image/color"
 	"io"
-	"os"
 
 	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/iso9660"
 )
 
 const (
@@ -31,39 +30,45 @@ func New() *Tim2 {
 	}
 }
 
-func (self *Tim2) unmarshal(stream io.ReadWriteSeeker) error {
-	if _, err := buffer.Seek(stream, int64(self.Offset), buffer.SeekStart); err != nil {
+func (self *Tim2) unmarshal(stream io.ReadSeeker) error {
+	cursor := buffer.NewCursorLE(stream, "TIM2")
+
+	if _, err := cursor.Move(int64(self.Offset), buffer.SeekStart); err != nil {
 		return err
 	}
 
 	signature := uint32(0)
-	if _, err := buffer.ReadUint32LE(stream, &signature); err != nil {
+	if _, err := cursor.ReadUint32("signature", &signature); err != nil {
 		return err
 	}
 
 	if signature != Signature {
-		return fmt.Errorf("TIM2 signature not match")
+		return cursor.Mismatch("signature", Signature, signature)
 	}
 
 	version := uint8(0)
-	if _, err := buffer.ReadUint8(stream, &version); err != nil {
+	if _, err := cursor.ReadUint8("version", &version); err != nil {
 		return err
 	}
 	self.FormatVersion = FormatVersion(version)
 
 	id := uint8(0)
-	if _, err := buffer.ReadUint8(stream, &id); err != nil {
+	if _, err := cursor.ReadUint8("id", &id); err != nil {
 		return err
 	}
 	self.FormatId = FormatId(id)
 
 	pictureTotal := uint16(0)
-	if _, err := buffer.ReadUint16LE(stream, &pictureTotal); err != nil {
+	if _, err := cursor.ReadUint16("pictureTotal", &pictureTotal); err != nil {
 		return err
 	}
 	self.PictureTotal = pictureTotal
 
-	if _, err := buffer.Seek(stream, 8, buffer.SeekCurrent); err != nil {
+	if self.PictureTotal == 0 {
+		return cursor.Invalid("pictureTotal", "texture has no picture")
+	}
+
+	if _, err := cursor.Move(8, buffer.SeekCurrent); err != nil {
 		return err
 	}
 
@@ -72,79 +77,121 @@ func (self *Tim2) unmarshal(stream io.ReadWriteSeeker) error {
 			ClutData: []*color.RGBA{},
 		}
 
-		if _, err := buffer.ReadUint32LE(stream, &picture.TotalSize); err != nil {
+		if _, err := cursor.ReadUint32("picture.TotalSize", &picture.TotalSize); err != nil {
 			return err
 		}
 
-		if _, err := buffer.ReadUint32LE(stream, &picture.ClutSize); err != nil {
+		if _, err := cursor.ReadUint32("picture.ClutSize", &picture.ClutSize); err != nil {
 			return err
 		}
 
-		if _, err := buffer.ReadUint32LE(stream, &picture.ImageSize); err != nil {
+		if _, err := cursor.ReadUint32("picture.ImageSize", &picture.ImageSize); err != nil {
 			return err
 		}
 
-		if _, err := buffer.ReadUint16LE(stream, &picture.HeaderSize); err != nil {
+		if _, err := cursor.ReadUint16("picture.HeaderSize", &picture.HeaderSize); err != nil {
 			return err
 		}
 
-		if _, err := buffer.ReadUint16LE(stream, &picture.ClutColors); err != nil {
+		if _, err := cursor.ReadUint16("picture.ClutColors", &picture.ClutColors); err != nil {
 			return err
 		}
 
-		if _, err := buffer.ReadUint8(stream, &picture.PictureFormat); err != nil {
+		if _, err := cursor.ReadUint8("picture.PictureFormat", &picture.PictureFormat); err != nil {
 			return err
 		}
 
-		if _, err := buffer.ReadUint8(stream, &picture.MipMapTextures); err != nil {
+		if _, err := cursor.ReadUint8("picture.MipMapTextures", &picture.MipMapTextures); err != nil {
 			return err
 		}
 
 		clut := uint8(0)
-		if _, err := buffer.ReadUint8(stream, &clut); err != nil {
+		if _, err := cursor.ReadUint8("picture.ClutType", &clut); err != nil {
 			return err
 		}
 		picture.ClutType = ClutType(clut)
 
 		imageType := uint8(0)
-		if _, err := buffer.ReadUint8(stream, &imageType); err != nil {
+		if _, err := cursor.ReadUint8("picture.ImageType", &imageType); err != nil {
 			return err
 		}
 		picture.ImageType = ImageType(imageType)
 
-		if _, err := buffer.ReadUint16LE(stream, &picture.ImageWidth); err != nil {
+		if _, err := cursor.ReadUint16("picture.ImageWidth", &picture.ImageWidth); err != nil {
 			return err
 		}
 
-		if _, err := buffer.ReadUint16LE(stream, &picture.ImageHeight); err != nil {
+		if _, err := cursor.ReadUint16("picture.ImageHeight", &picture.ImageHeight); err != nil {
 			return err
 		}
 
-		if _, err := buffer.ReadUint64LE(stream, &picture.GsTex0); err != nil {
+		if _, err := cursor.ReadUint64("picture.GsTex0", &picture.GsTex0); err != nil {
 			return err
 		}
 
-		if _, err := buffer.ReadUint64LE(stream, &picture.GsTex1); err != nil {
+		if _, err := cursor.ReadUint64("picture.GsTex1", &picture.GsTex1); err != nil {
 			return err
 		}
 
-		if _, err := buffer.ReadUint32LE(stream, &picture.GsRegs); err != nil {
+		if _, err := cursor.ReadUint32("picture.GsRegs", &picture.GsRegs); err != nil {
 			return err
 		}
 
-		if _, err := buffer.ReadUint32LE(stream, &picture.GsTexClut); err != nil {
+		if _, err := cursor.ReadUint32("picture.GsTexClut", &picture.GsTexClut); err != nil {
+			return err
+		}
+
+		// NOTE: mipmap header follow picture header when there is more than one level, the rest of header is skipped
+		headerSize := int64(PictureHeaderSize)
+		if picture.MipMapTextures > 1 {
+			if _, err := cursor.ReadUint64("picture.GsMiptbp1", &picture.GsMiptbp1); err != nil {
+				return err
+			}
+
+			if _, err := cursor.ReadUint64("picture.GsMiptbp2", &picture.GsMiptbp2); err != nil {
+				return err
+			}
+
+			picture.MipMapSizes = make([]uint32, picture.MipMapTextures)
+			for i := range picture.MipMapSizes {
+				if _, err := cursor.ReadUint32("picture.MipMapSizes", &picture.MipMapSizes[i]); err != nil {
+					return err
+				}
+			}
+
+			headerSize += 16 + int64(picture.MipMapTextures)*4
+		}
+
+		if int64(picture.HeaderSize) < headerSize {
+			return cursor.Invalid("picture.HeaderSize", "%d bytes is smaller than %d bytes header", picture.HeaderSize, headerSize)
+		}
+
+		if _, err := cursor.Move(int64(picture.HeaderSize)-headerSize, buffer.SeekCurrent); err != nil {
+			return err
+		}
+
+		if err := cursor.Require("picture.ImageSize", uint64(picture.ImageSize)); err != nil {
 			return err
 		}
 
 		buf := make([]byte, picture.ImageSize)
-		if _, err := buffer.ReadBytes(stream, buf); err != nil {
+		if _, err := cursor.ReadBytes("picture.ImageData", buf); err != nil {
 			return err
 		}
 		picture.ImageData = buf
 
+		if err := cursor.Require("picture.ClutColors", uint64(picture.ClutColors)*4); err != nil {
+			return err
+		}
+
+		// NOTE: clut with 32 colors or more is stored in 32 colors block
+		if picture.ClutColors >= 32 && picture.ClutColors%32 != 0 {
+			return cursor.Invalid("picture.ClutColors", "%d colors is not multiple of 32", picture.ClutColors)
+		}
+
 		rgba := make([]byte, 4)
 		for range picture.ClutColors {
-			if _, err := buffer.ReadBytes(stream, rgba); err != nil {
+			if _, err := cursor.ReadBytes("picture.ClutData", rgba); err != nil {
 				return err
 			}
 
@@ -178,8 +225,17 @@ func (self *Tim2) unmarshal(stream io.ReadWriteSeeker) error {
 	return nil
 }
 
+func FromStreamWithOffset(tim *Tim2, stream io.ReadSeeker, offset uint32) error {
+	tim.Offset = offset
+	return tim.unmarshal(stream)
+}
+
+func FromStream(tim *Tim2, stream io.ReadSeeker) error {
+	return FromStreamWithOffset(tim, stream, 0)
+}
+
 func FromPathWithOffset(tim *Tim2, filePath string, offset uint32) error {
-	file, err := os.Open(filePath)
+	file, err := iso9660.Open(filePath)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,116 @@
+package tim2
+
+import (
+	"fmt"
+	"image"
+)
+
+// NOTE: halve image with 2x2 box filter, odd edge reuse last row or column
+func downsample(img *image.NRGBA) *image.NRGBA {
+	width := img.Rect.Dx()
+	height := img.Rect.Dy()
+	halfWidth := max(width/2, 1)
+	halfHeight := max(height/2, 1)
+
+	result := image.NewNRGBA(image.Rect(0, 0, halfWidth, halfHeight))
+	for y := range halfHeight {
+		for x := range halfWidth {
+			sum := [4]int{}
+			for _, offset := range [4][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+				sx := min(x*2+offset[0], width-1)
+				sy := min(y*2+offset[1], height-1)
+				i := img.PixOffset(img.Rect.Min.X+sx, img.Rect.Min.Y+sy)
+				for c := range 4 {
+					sum[c] += int(img.Pix[i+c])
+				}
+			}
+
+			i := result.PixOffset(x, y)
+			for c := range 4 {
+				result.Pix[i+c] = uint8((sum[c] + 2) / 4)
+			}
+		}
+	}
+
+	return result
+}
+
+// NOTE: mip chain from base image down to 1x1, only base image when mipmaps is false
+func Mipmaps(img *image.NRGBA, mipmaps bool) []*image.NRGBA {
+	levels := []*image.NRGBA{img}
+	if !mipmaps {
+		return levels
+	}
+
+	for level := img; level.Rect.Dx() > 1 || level.Rect.Dy() > 1; {
+		level = downsample(level)
+		levels = append(levels, level)
+	}
+
+	return levels
+}
+
+// NOTE: picture without mipmap header has one level
+func (self *Picture) MipMapLevelTotal() int {
+	return max(len(self.MipMapSizes), 1)
+}
+
+// NOTE: shallow copy of picture with stored mip level, level 0 is picture itself. Every level is half size of previous
+// level and its data follow previous level data
+func (self *Picture) MipMapLevel(level int) (*Picture, error) {
+	total := self.MipMapLevelTotal()
+	if level < 0 || level >= total {
+		return nil, fmt.Errorf("Mip level %d is outside of %d levels", level, total)
+	}
+
+	if level == 0 {
+		return self, nil
+	}
+
+	offset := 0
+	for _, size := range self.MipMapSizes[:level] {
+		offset += int(size)
+	}
+
+	size := int(self.MipMapSizes[level])
+	if offset+size > len(self.ImageData) {
+		return nil, fmt.Errorf("Mip level %d data exceeds image data, expected at least %d, got %d", level, offset+size, len(self.ImageData))
+	}
+
+	picture := *self
+	picture.ImageWidth = max(self.ImageWidth>>level, 1)
+	picture.ImageHeight = max(self.ImageHeight>>level, 1)
+	picture.ImageData = self.ImageData[offset : offset+size]
+
+	return &picture, nil
+}
+
+// NOTE: mip level stored in picture is decoded with toImage (TIM2 and TIM3 has different image data), mip chain is
+// generated from base image when picture has one level. Only base image when mipmaps is false
+func PictureMipmaps(picture *Picture, mipmaps bool, toImage func(picture *Picture) (*image.NRGBA, error)) ([]*image.NRGBA, error) {
+	img, err := toImage(picture)
+	if err != nil {
+		return nil, err
+	}
+
+	if !mipmaps || picture.MipMapLevelTotal() == 1 {
+		return Mipmaps(img, mipmaps), nil
+	}
+
+	levels := []*image.NRGBA{img}
+	for i := 1; i < picture.MipMapLevelTotal(); i++ {
+		level, err := picture.MipMapLevel(i)
+		if err != nil {
+			return nil, err
+		}
+
+		img, err := toImage(level)
+		if err != nil {
+			return nil, err
+		}
+
+		levels = append(levels, img)
+	}
+
+	return levels, nil
+}
@@ -1,6 +1,14 @@
 package tim2
 
-import "image/color"
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+const (
+	PictureHeaderSize = 48 // NOTE: picture header without mipmap header
+)
 
 type Picture struct {
 	TotalSize      uint32    `json:"total_size"` // NOTE: total size is sum of clut size, image size, and picture header size
@@ -14,10 +22,85 @@ type Picture struct {
 	ImageType      ImageType `json:"image_type"`
 	ImageWidth     uint16    `json:"image_width"`
 	ImageHeight    uint16    `json:"image_height"`
-	GsTex0         uint64    `json:"gs_tex0"`     // TODO: destruct bit
-	GsTex1         uint64    `json:"gs_tex1"`     // TODO: destruct bit, NOTE: always 608
-	GsRegs         uint32    `json:"gs_regs"`     // TODO: destruct bit, NOTE: always 0
-	GsTexClut      uint32    `json:"ge_tex_clut"` // TODO: destruct bit, NOTE: always 0
+	GsTex0         uint64    `json:"gs_tex0"`                // TODO: destruct bit
+	GsTex1         uint64    `json:"gs_tex1"`                // TODO: destruct bit, NOTE: always 608
+	GsRegs         uint32    `json:"gs_regs"`                // TODO: destruct bit, NOTE: always 0
+	GsTexClut      uint32    `json:"ge_tex_clut"`            // TODO: destruct bit, NOTE: always 0
+	GsMiptbp1      uint64    `json:"gs_miptbp1,omitempty"`   // TODO: destruct bit, NOTE: only with more than one mip level
+	GsMiptbp2      uint64    `json:"gs_miptbp2,omitempty"`   // TODO: destruct bit, NOTE: only with more than one mip level
+	MipMapSizes    []uint32  `json:"mipmap_sizes,omitempty"` // NOTE: image data size of every mip level, level 0 first
 	ImageData      []byte
 	ClutData       []*color.RGBA
 }
+
+// NOTE: size of image data needed by width and height, only indexed texture is supported
+func (self *Picture) IndexedDataSize() (int, error) {
+	pixels := int(self.ImageWidth) * int(self.ImageHeight)
+
+	size := 0
+	switch self.ImageType {
+	case ImageType4BitTexture:
+		size = (pixels + 1) / 2
+	case ImageType8BitTexture:
+		size = pixels
+	default:
+		return 0, fmt.Errorf("Image type %s is not supported", self.ImageType)
+	}
+
+	if len(self.ImageData) < size {
+		return 0, fmt.Errorf("Image data size is not match, expected at least %d, got %d", size, len(self.ImageData))
+	}
+
+	return size, nil
+}
+
+// NOTE: palette index is validated so hostile file can not index outside clut
+func (self *Picture) colorAt(index uint8, pixel int) (*color.RGBA, error) {
+	if int(index) >= len(self.ClutData) {
+		return nil, fmt.Errorf("Palette index %d at pixel %d exceeds %d colors", index, pixel, len(self.ClutData))
+	}
+
+	return self.ClutData[index], nil
+}
+
+// NOTE: convert palette indices to paletted image with CLUT as palette
+func (self *Picture) IndicesToPaletted(indices []byte) (*image.Paletted, error) {
+	for i, index := range indices {
+		if _, err := self.colorAt(index, i); err != nil {
+			return nil, err
+		}
+	}
+
+	img := image.NewPaletted(image.Rect(0, 0, int(self.ImageWidth), int(self.ImageHeight)), ClutToPalette(self.ClutData))
+	copy(img.Pix, indices)
+
+	return img, nil
+}
+
+// NOTE: convert palette indices to NRGBA pixels
+func (self *Picture) IndicesToPixels(indices []byte) ([]byte, error) {
+	raw := make([]byte, len(indices)*4)
+	if err := self.IndicesToPixelsTo(raw, indices); err != nil {
+		return nil, err
+	}
+
+	return raw, nil
+}
+
+// NOTE: convert palette indices to NRGBA pixels without allocation, pix must have 4 bytes for every index
+func (self *Picture) IndicesToPixelsTo(pix []byte, indices []byte) error {
+	for i, index := range indices {
+		c, err := self.colorAt(index, i)
+		if err != nil {
+			return err
+		}
+
+		p := pix[i*4 : i*4+4 : i*4+4]
+		p[0] = c.R
+		p[1] = c.G
+		p[2] = c.B
+		p[3] = c.A
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package tim2
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"slices"
+
+	"github.com/anasrar/chihuahua/pkg/buffer"
+)
+
+const (
+	// NOTE: TH, TW, PSM, and TBW follow the new image, the rest (TBP0, CBP, CSA, ...) is kept
+	gsTex0ImageMask uint64 = 0xF<<30 | 0xF<<26 | 0x3F<<20 | 0x3F<<14
+)
+
+func Bpp(picture *Picture) (uint, error) {
+	switch picture.ImageType {
+	case ImageType4BitTexture:
+		return 4, nil
+	case ImageType8BitTexture:
+		return 8, nil
+	default:
+		return 0, fmt.Errorf("Image type %s not supported", picture.ImageType)
+	}
+}
+
+// NOTE: buf is single picture made by ImagePalettedToFile (TIM2) or tim3.ImagePalettedToFile (TIM3), both has the
+// same header layout. Format header, CLUT type, and GS registers of original picture is written over it
+func KeepHeader(buf []byte, formatVersion FormatVersion, formatId FormatId, picture *Picture) error {
+	// NOTE: new picture is laid out with 16 byte alignment, bit 0 of format id is 128 byte alignment (TIM3 use 0x06)
+	if formatId&0x01 != 0 {
+		return fmt.Errorf("Format id 0x%02X not supported, only 16 byte alignment", uint8(formatId))
+	}
+
+	// NOTE: new CLUT is 32 bit and stored in CSM1, compound flag is kept
+	if picture.ClutType.Format() != ImageType32BitColor || picture.ClutType.StorageMode() != ClutStorageMode1 {
+		return fmt.Errorf("CLUT type %s not supported", picture.ClutType)
+	}
+
+	// NOTE: FileHeader.format_version and FileHeader.format_id
+	buf[4] = uint8(formatVersion)
+	buf[5] = uint8(formatId)
+
+	// NOTE: Picture.clut_type
+	buf[34] = uint8(picture.ClutType)
+
+	// NOTE: Picture.gs_tex0, Picture.gs_tex1, Picture.gs_regs, Picture.gs_tex_clut
+	gsTex0 := binary.LittleEndian.Uint64(buf[40:])
+	gsTex0 = (picture.GsTex0 &^ gsTex0ImageMask) | (gsTex0 & gsTex0ImageMask)
+	binary.LittleEndian.PutUint64(buf[40:], gsTex0)
+	binary.LittleEndian.PutUint64(buf[48:], picture.GsTex1)
+	binary.LittleEndian.PutUint32(buf[56:], picture.GsRegs)
+	binary.LittleEndian.PutUint32(buf[60:], picture.GsTexClut)
+
+	return nil
+}
+
+// NOTE: convert image with the same bpp, format header, and GS registers as original TIM2
+func ImagePalettedToBytesWithTim(img *image.Paletted, original *Tim2) ([]byte, error) {
+	if len(original.Pictures) != 1 {
+		return nil, fmt.Errorf("Only TIM2 with single picture supported, got %d pictures", len(original.Pictures))
+	}
+
+	picture := original.Pictures[0]
+
+	bpp, err := Bpp(picture)
+	if err != nil {
+		return nil, err
+	}
+
+	memory := buffer.NewMemory(nil)
+	if err := ImagePalettedToFile(img, bpp, memory); err != nil {
+		return nil, err
+	}
+
+	buf := memory.Bytes()
+	if err := KeepHeader(buf, original.FormatVersion, original.FormatId, picture); err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
+
+// NOTE: write image into picture and its CLUT bank in place, pictures is parsed from the same stream. Image must have
+// the same size and bpp as picture, header, other pictures, and other CLUT banks are kept. Every bank share image data,
+// so only recolor (same indices) keep other banks as is
+func WritePictureToStreamWithSignature(
+	stream io.ReadWriteSeeker,
+	offset uint32,
+	signature uint32,
+	pictures []*Picture,
+	pictureIndex int,
+	bank int,
+	img *image.Paletted,
+) error {
+	if pictureIndex < 0 || pictureIndex >= len(pictures) {
+		return fmt.Errorf("Picture index %d out of range, total %d", pictureIndex, len(pictures))
+	}
+
+	picture := pictures[pictureIndex]
+
+	bpp, err := Bpp(picture)
+	if err != nil {
+		return err
+	}
+
+	width := int(picture.ImageWidth)
+	height := int(picture.ImageHeight)
+	if img.Rect.Dx() != width || img.Rect.Dy() != height {
+		return fmt.Errorf("Image size %dx%d not match with picture %dx%d", img.Rect.Dx(), img.Rect.Dy(), width, height)
+	}
+
+	total := picture.ClutBankTotal()
+	if bank < 0 || bank >= total {
+		return fmt.Errorf("Clut bank %d is outside of %d banks", bank, total)
+	}
+
+	colors := len(picture.ClutData) / total
+	if len(img.Palette) > colors {
+		return fmt.Errorf("PNG colors %d exceeds %d CLUT bank colors", len(img.Palette), colors)
+	}
+
+	indices := make([]byte, width*height)
+	for y := range height {
+		start := img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y+y)
+		copy(indices[y*width:(y+1)*width], img.Pix[start:start+width])
+	}
+
+	data := indices
+	if bpp == 4 {
+		// NOTE: two pixel per byte with low nibble first, same as UnpackIndices4
+		data = make([]byte, (len(indices)+1)/2)
+		for i, index := range indices {
+			data[i>>1] |= (index & 0xF) << ((i & 1) << 2)
+		}
+	}
+
+	// NOTE: bank colors not in PNG palette are filled with transparent black, same as ImagePalettedToFile
+	clut := slices.Clone(picture.ClutData)
+	for i := range colors {
+		c := &color.RGBA{R: 0, G: 0, B: 0, A: 0}
+		if i < len(img.Palette) {
+			c = ColorToClut(img.Palette[i])
+		}
+		clut[bank*colors+i] = c
+	}
+
+	if err := WriteImageDataToStreamWithSignature(stream, offset, signature, pictureIndex, data); err != nil {
+		return err
+	}
+
+	return WriteClutToStreamWithSignature(stream, offset, signature, pictureIndex, clut)
+}
+
+func WritePictureToStreamWithOffset(
+	stream io.ReadWriteSeeker,
+	offset uint32,
+	pictures []*Picture,
+	pictureIndex int,
+	bank int,
+	img *image.Paletted,
+) error {
+	return WritePictureToStreamWithSignature(stream, offset, Signature, pictures, pictureIndex, bank, img)
+}
@@ -0,0 +1,133 @@
+package tim2_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"slices"
+	"testing"
+
+	"github.com/anasrar/chihuahua/internal/testutils"
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/tim2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImagePalettedToBytesWithTim(t *testing.T) {
+	memory := buffer.NewMemory(nil)
+	if err := tim2.ImagePalettedToFile(testutils.Paletted(32, 32, 16), 4, memory); err != nil {
+		t.Fatal(err)
+	}
+
+	// NOTE: game texture header, format version, compound CLUT, and GS registers different from default
+	data := memory.Bytes()
+	data[4] = uint8(tim2.FormatVersionPrivateCompatible)
+	data[34] = 0x83
+	cbp := uint64(0x1234) << 37
+	binary.LittleEndian.PutUint64(data[40:], binary.LittleEndian.Uint64(data[40:])|cbp|0x100)
+	binary.LittleEndian.PutUint64(data[48:], 0x260)
+	binary.LittleEndian.PutUint32(data[56:], 0x11)
+	binary.LittleEndian.PutUint32(data[60:], 0x22)
+
+	original := tim2.New()
+	if err := tim2.FromStream(original, bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := tim2.ImagePalettedToBytesWithTim(testutils.Paletted(64, 16, 16), original)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tim := tim2.New()
+	if err := tim2.FromStream(tim, bytes.NewReader(buf)); err != nil {
+		t.Fatal(err)
+	}
+
+	picture := tim.Pictures[0]
+	assert.Equal(t, tim2.FormatVersionPrivateCompatible, tim.FormatVersion)
+	assert.Equal(t, tim2.ImageType4BitTexture, picture.ImageType)
+	assert.Equal(t, tim2.ClutType(0x83), picture.ClutType)
+	assert.Equal(t, uint16(64), picture.ImageWidth)
+	assert.Equal(t, uint16(16), picture.ImageHeight)
+	assert.Equal(t, cbp|0x100, picture.GsTex0&(cbp|0x100))
+	assert.Equal(t, uint64(0x260), picture.GsTex1)
+	assert.Equal(t, uint32(0x11), picture.GsRegs)
+	assert.Equal(t, uint32(0x22), picture.GsTexClut)
+
+	// NOTE: TW and TH follow the new image (64x16)
+	assert.Equal(t, uint64(6), picture.GsTex0>>26&0xF)
+	assert.Equal(t, uint64(4), picture.GsTex0>>30&0xF)
+
+	t.Run("128 byte alignment", func(t *testing.T) {
+		original.FormatId = tim2.FormatId128Alignment
+		defer func() { original.FormatId = tim2.FormatId16Alignment }()
+
+		_, err := tim2.ImagePalettedToBytesWithTim(testutils.Paletted(64, 16, 16), original)
+		assert.Error(t, err)
+	})
+
+	t.Run("CSM2", func(t *testing.T) {
+		original.Pictures[0].ClutType = 0x43
+		_, err := tim2.ImagePalettedToBytesWithTim(testutils.Paletted(64, 16, 16), original)
+		assert.Error(t, err)
+	})
+}
+
+func TestWritePictureToStream(t *testing.T) {
+	data := testutils.Banked(tim2.Signature)
+
+	original := tim2.New()
+	if err := tim2.FromStream(original, bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+
+	img := testutils.Paletted(8, 8, 16)
+	for i := range img.Pix {
+		img.Pix[i] = 15 - img.Pix[i]
+	}
+	for i := range img.Palette {
+		img.Palette[i] = color.RGBA{R: uint8(i * 16), G: 0x20, B: 0x30, A: 0xFF}
+	}
+
+	memory := buffer.NewMemory(slices.Clone(data))
+	if err := tim2.WritePictureToStreamWithOffset(memory, 0, original.Pictures, 1, 1, img); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(data), len(memory.Bytes()))
+
+	tim := tim2.New()
+	if err := tim2.FromStream(tim, bytes.NewReader(memory.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, original.Pictures[0], tim.Pictures[0])
+	assert.Equal(t, original.Pictures[1].ClutData[:16], tim.Pictures[1].ClutData[:16])
+
+	bank, err := tim.Pictures[1].ClutBank(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paletted, err := tim2.PictureToPaletted(bank)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, img.Pix, paletted.Pix)
+	for i, c := range img.Palette {
+		assert.Equal(t, tim2.ColorToClut(c), bank.ClutData[i], "color %d", i)
+	}
+
+	t.Run("size", func(t *testing.T) {
+		assert.Error(t, tim2.WritePictureToStreamWithOffset(memory, 0, original.Pictures, 1, 1, testutils.Paletted(16, 8, 16)))
+	})
+
+	t.Run("colors", func(t *testing.T) {
+		assert.Error(t, tim2.WritePictureToStreamWithOffset(memory, 0, original.Pictures, 1, 0, testutils.Paletted(8, 8, 32)))
+	})
+
+	t.Run("bank", func(t *testing.T) {
+		assert.Error(t, tim2.WritePictureToStreamWithOffset(memory, 0, original.Pictures, 1, 2, img))
+		assert.Error(t, tim2.WritePictureToStreamWithOffset(memory, 0, original.Pictures, 0, 1, img))
+	})
+}
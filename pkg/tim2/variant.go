@@ -0,0 +1,83 @@
+package tim2
+
+import (
+	"fmt"
+)
+
+const (
+	ClutBankColors = 16
+)
+
+// NOTE: 4 bit texture with more than 16 colors clut has alternate bank, GS select bank with CSA
+func (self *Picture) ClutBankTotal() int {
+	if self.ImageType != ImageType4BitTexture || len(self.ClutData) <= ClutBankColors {
+		return 1
+	}
+
+	return len(self.ClutData) / ClutBankColors
+}
+
+// NOTE: shallow copy of picture with 16 colors clut from bank, image data is shared
+func (self *Picture) ClutBank(bank int) (*Picture, error) {
+	total := self.ClutBankTotal()
+	if bank < 0 || bank >= total {
+		return nil, fmt.Errorf("Clut bank %d is outside of %d banks", bank, total)
+	}
+
+	if total == 1 {
+		return self, nil
+	}
+
+	picture := *self
+	picture.ClutColors = ClutBankColors
+	picture.ClutData = self.ClutData[bank*ClutBankColors : (bank+1)*ClutBankColors]
+
+	return &picture, nil
+}
+
+// NOTE: one picture with one clut bank
+type Variant struct {
+	PictureIndex int      `json:"picture_index"`
+	PictureTotal int      `json:"picture_total"`
+	Bank         int      `json:"bank"`
+	BankTotal    int      `json:"bank_total"`
+	Picture      *Picture `json:"-"`
+}
+
+// NOTE: name suffix, empty for single picture without alternate bank
+func (self *Variant) Suffix() string {
+	suffix := ""
+	if self.PictureTotal > 1 {
+		suffix += fmt.Sprintf("_p%02d", self.PictureIndex)
+	}
+
+	if self.BankTotal > 1 {
+		suffix += fmt.Sprintf("_b%02d", self.Bank)
+	}
+
+	return suffix
+}
+
+// NOTE: every picture and every clut bank in picture order then bank order
+func Variants(pictures []*Picture) ([]*Variant, error) {
+	result := []*Variant{}
+	for i, picture := range pictures {
+		total := picture.ClutBankTotal()
+		for bank := range total {
+			p, err := picture.ClutBank(bank)
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(result, &Variant{
+				PictureIndex: i,
+				PictureTotal: len(pictures),
+				Bank:         bank,
+				BankTotal:    total,
+				Picture:      p,
+			})
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,27 @@
+package tim3
+
+import (
+	"image/color"
+	"io"
+	"os"
+
+	"github.com/anasrar/chihuahua/pkg/tim2"
+)
+
+func WriteClutToStreamWithOffset(stream io.ReadWriteSeeker, offset uint32, pictureIndex int, colors []*color.RGBA) error {
+	return tim2.WriteClutToStreamWithSignature(stream, offset, Signature, pictureIndex, colors)
+}
+
+func WriteClutWithOffset(filePath string, offset uint32, pictureIndex int, colors []*color.RGBA) error {
+	file, err := os.OpenFile(filePath, os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return WriteClutToStreamWithOffset(file, offset, pictureIndex, colors)
+}
+
+func WriteClut(filePath string, pictureIndex int, colors []*color.RGBA) error {
+	return WriteClutWithOffset(filePath, 0, pictureIndex, colors)
+}
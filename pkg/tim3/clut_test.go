@@ -0,0 +1,46 @@
+package tim3_test
+
+import (
+	"image"
+	"io"
+	"testing"
+
+	"github.com/anasrar/chihuahua/internal/testutils"
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/tim2"
+	"github.com/anasrar/chihuahua/pkg/tim3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteClut(t *testing.T) {
+	toTim := func(img *image.Paletted, bpp uint) ([]byte, error) {
+		memory := buffer.NewMemory(nil)
+		if err := tim3.ImagePalettedToFile(img, bpp, memory); err != nil {
+			return nil, err
+		}
+
+		return memory.Bytes(), nil
+	}
+
+	parse := func(stream io.ReadSeeker, offset uint32) (*tim2.Picture, error) {
+		tim := tim3.New()
+		if err := tim3.FromStreamWithOffset(tim, stream, offset); err != nil {
+			return nil, err
+		}
+
+		return tim.Pictures[0], nil
+	}
+
+	testutils.WriteClutRoundTrip(t, toTim, parse, tim3.WriteClutToStreamWithOffset)
+
+	t.Run("signature", func(t *testing.T) {
+		data, err := toTim(testutils.Paletted(32, 32, 16), 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		memory := buffer.NewMemory(data)
+		assert.Error(t, tim2.WriteClutToStreamWithOffset(memory, 0, 0, testutils.Clut(16)))
+		assert.Equal(t, data, memory.Bytes())
+	})
+}
@@ -4,21 +4,31 @@ import (
 	"fmt"
 	"image"
 	"image/color"
+	"io"
 	"math"
-	"os"
 
 	"github.com/anasrar/chihuahua/pkg/buffer"
 	graphicsynthesizer "github.com/anasrar/chihuahua/pkg/graphic_synthesizer"
 	"github.com/anasrar/chihuahua/pkg/tim2"
 )
 
-func PictureToImage(picture *tim2.Picture) *image.NRGBA {
+// NOTE: palette index for every pixel, image data is unswizzled and 4 bit texture is unpacked
+func indices(picture *tim2.Picture) ([]byte, error) {
 	width := int(picture.ImageWidth)
 	height := int(picture.ImageHeight)
 	swizzle := width >= 128 && height >= 128
-	data := make([]byte, len(picture.ImageData))
-	copy(data, picture.ImageData)
-	indices := []byte{}
+	size, err := picture.IndexedDataSize()
+	if err != nil {
+		return nil, err
+	}
+
+	// NOTE: swizzle go through GS memory, texture size must be power of two and fit in GS memory
+	if swizzle && (width&(width-1) != 0 || height&(height-1) != 0 || size > len(graphicsynthesizer.GsMem)) {
+		return nil, fmt.Errorf("Swizzled image size %dx%d is not supported", width, height)
+	}
+
+	data := picture.ImageData[:size]
+	indices := make([]byte, width*height)
 
 	switch picture.ImageType {
 	case tim2.ImageType4BitTexture:
@@ -26,35 +36,45 @@ func PictureToImage(picture *tim2.Picture) *image.NRGBA {
 			data = graphicsynthesizer.Unswizzle4(data, width, height)
 		}
 
-		for _, v := range data {
-			low := v & 0xF
-			high := (v >> 4) & 0xF
-			indices = append(indices, low)
-			indices = append(indices, high)
-		}
+		tim2.UnpackIndices4(indices, data)
 	case tim2.ImageType8BitTexture:
 		if swizzle {
-			data = graphicsynthesizer.Unswizzle8(data, width, height)
+			graphicsynthesizer.Unswizzle8To(indices, data, width, height)
+		} else {
+			copy(indices, data)
 		}
-
-		indices = append(indices, data...)
 	}
 
-	raw := []uint8{}
-	for _, index := range indices {
-		c := picture.ClutData[index]
-		raw = append(raw, c.R)
-		raw = append(raw, c.G)
-		raw = append(raw, c.B)
-		raw = append(raw, c.A)
+	return indices, nil
+}
+
+func PictureToImage(picture *tim2.Picture) (*image.NRGBA, error) {
+	width := int(picture.ImageWidth)
+	height := int(picture.ImageHeight)
+	indices, err := indices(picture)
+	if err != nil {
+		return nil, err
 	}
+
 	img := image.NewNRGBA(image.Rect(0, 0, width, height))
-	copy(img.Pix, raw)
+	if err := picture.IndicesToPixelsTo(img.Pix, indices); err != nil {
+		return nil, err
+	}
+
+	return img, nil
+}
+
+// NOTE: indices and CLUT order is kept, so PNG can be converted back without re-indexing
+func PictureToPaletted(picture *tim2.Picture) (*image.Paletted, error) {
+	indices, err := indices(picture)
+	if err != nil {
+		return nil, err
+	}
 
-	return img
+	return picture.IndicesToPaletted(indices)
 }
 
-func ImagePalettedToFile(img *image.Paletted, bpp uint, output *os.File) error {
+func ImagePalettedToFile(img *image.Paletted, bpp uint, output io.WriteSeeker) error {
 	colorTotal := len(img.Palette)
 
 	if colorTotal > 256 {
@@ -69,27 +89,29 @@ func ImagePalettedToFile(img *image.Paletted, bpp uint, output *os.File) error {
 	height := img.Rect.Max.Y
 	indices := img.Pix
 
+	if bpp == 4 {
+		data := make([]uint8, len(indices)/2)
+		for i := range data {
+			high := indices[i*2+1]
+			low := indices[i*2]
+			data[i] = (high << 4) | low
+		}
+		indices = data
+	}
+
 	swizzle := width >= 128 && height >= 128
 	if swizzle {
 		switch bpp {
 		case 4:
-			data := []uint8{}
-			for i := 0; i < len(indices); i += 2 {
-				high := indices[i+1]
-				low := indices[i]
-				value := (high << 4) | low
-				data = append(data, value)
-			}
-			indices = graphicsynthesizer.Swizzle4(data, width, height)
+			indices = graphicsynthesizer.Swizzle4(indices, width, height)
 		case 8:
 			indices = graphicsynthesizer.Swizzle8(indices, width, height)
 		}
 	}
 
-	colors := []*color.RGBA{}
+	colors := make([]*color.RGBA, 0, 256)
 	for _, c := range img.Palette {
-		c32, _ := c.(color.RGBA)
-		colors = append(colors, &c32)
+		colors = append(colors, tim2.ColorToClut(c))
 	}
 
 	// NOTE: fill colors to 16 or 256
@@ -103,7 +125,7 @@ func ImagePalettedToFile(img *image.Paletted, bpp uint, output *os.File) error {
 		}
 	}
 
-	twiddle := []*color.RGBA{}
+	twiddle := make([]*color.RGBA, 0, 256)
 	if bpp == 8 {
 		for i := 0; i < 256; i += 32 {
 			twiddle = append(twiddle, colors[i+0:i+8]...)
@@ -284,21 +306,19 @@ func ImagePalettedToFile(img *image.Paletted, bpp uint, output *os.File) error {
 	}
 
 	// NOTE: Picture.clut_data
-	switch bpp {
-	case 4:
-		for _, c := range colors {
-			a := uint8(float32(c.A) / 255 * 0x80)
-			if _, err := buffer.WriteBytes(output, []byte{c.R, c.G, c.B, a}); err != nil {
-				return err
-			}
-		}
-	case 8:
-		for _, c := range twiddle {
-			a := uint8(float32(c.A) / 255 * 0x80)
-			if _, err := buffer.WriteBytes(output, []byte{c.R, c.G, c.B, a}); err != nil {
-				return err
-			}
-		}
+	clut := colors
+	if bpp == 8 {
+		clut = twiddle
+	}
+
+	clutData := make([]byte, 0, len(clut)*4)
+	for _, c := range clut {
+		a := uint8(float32(c.A) / 255 * 0x80)
+		clutData = append(clutData, c.R, c.G, c.B, a)
+	}
+
+	if _, err := buffer.WriteBytes(output, clutData); err != nil {
+		return err
 	}
 
 	return nil
@@ -0,0 +1,108 @@
+package tim3_test
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/anasrar/chihuahua/internal/testutils"
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/tim2"
+	"github.com/anasrar/chihuahua/pkg/tim3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		size       int
+		bpp        uint
+		colorTotal int
+	}{
+		{"4 bpp", 32, 4, 16},
+		{"8 bpp", 32, 8, 256},
+		{"4 bpp swizzled", 128, 4, 16},
+		{"8 bpp swizzled", 128, 8, 256},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			img := testutils.Paletted(tc.size, tc.size, tc.colorTotal)
+
+			memory := buffer.NewMemory(nil)
+			if err := tim3.ImagePalettedToFile(img, tc.bpp, memory); err != nil {
+				t.Fatal(err)
+			}
+
+			tim := tim3.New()
+			if err := tim3.FromStream(tim, bytes.NewReader(memory.Bytes())); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := tim3.PictureToImage(tim.Pictures[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for y := range tc.size {
+				for x := range tc.size {
+					r, g, b, a := img.At(x, y).RGBA()
+					if !assert.Equal(t, color.NRGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: uint8(a >> 8)}, result.NRGBAAt(x, y), "pixel %d,%d", x, y) {
+						return
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPalettedRoundTrip(t *testing.T) {
+	toTim := func(img *image.Paletted, bpp uint) (*tim2.Picture, error) {
+		memory := buffer.NewMemory(nil)
+		if err := tim3.ImagePalettedToFile(img, bpp, memory); err != nil {
+			return nil, err
+		}
+
+		tim := tim3.New()
+		if err := tim3.FromStream(tim, bytes.NewReader(memory.Bytes())); err != nil {
+			return nil, err
+		}
+
+		return tim.Pictures[0], nil
+	}
+
+	testutils.PalettedRoundTrip(t, 128, toTim, tim3.PictureToPaletted)
+}
+
+func BenchmarkPictureToImage(b *testing.B) {
+	for _, bpp := range []uint{4, 8} {
+		memory := buffer.NewMemory(nil)
+		if err := tim3.ImagePalettedToFile(testutils.Paletted(256, 256, 1<<bpp), bpp, memory); err != nil {
+			b.Fatal(err)
+		}
+
+		tim := tim3.New()
+		if err := tim3.FromStream(tim, bytes.NewReader(memory.Bytes())); err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(fmt.Sprintf("PictureToImage %d bpp", bpp), func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				if _, err := tim3.PictureToImage(tim.Pictures[0]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("ImagePalettedToFile %d bpp", bpp), func(b *testing.B) {
+			img := testutils.Paletted(256, 256, 1<<bpp)
+			b.ReportAllocs()
+			for range b.N {
+				if err := tim3.ImagePalettedToFile(img, bpp, buffer.NewMemory(nil)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package tim3
+
+import (
+	"fmt"
+	"image"
+	"io"
+
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/tim2"
+)
+
+func Bpp(picture *tim2.Picture) (uint, error) {
+	return tim2.Bpp(picture)
+}
+
+// NOTE: convert image with the same bpp, format header, and GS registers as original TIM3
+func ImagePalettedToBytesWithTim(img *image.Paletted, original *Tim3) ([]byte, error) {
+	if len(original.Pictures) != 1 {
+		return nil, fmt.Errorf("Only TIM3 with single picture supported, got %d pictures", len(original.Pictures))
+	}
+
+	picture := original.Pictures[0]
+
+	bpp, err := Bpp(picture)
+	if err != nil {
+		return nil, err
+	}
+
+	memory := buffer.NewMemory(nil)
+	if err := ImagePalettedToFile(img, bpp, memory); err != nil {
+		return nil, err
+	}
+
+	buf := memory.Bytes()
+	if err := tim2.KeepHeader(buf, original.FormatVersion, original.FormatId, picture); err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
+
+func WritePictureToStreamWithOffset(
+	stream io.ReadWriteSeeker,
+	offset uint32,
+	pictures []*tim2.Picture,
+	pictureIndex int,
+	bank int,
+	img *image.Paletted,
+) error {
+	return tim2.WritePictureToStreamWithSignature(stream, offset, Signature, pictures, pictureIndex, bank, img)
+}
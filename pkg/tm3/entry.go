@@ -5,4 +5,5 @@ type Entry struct {
 	Name   string `json:"name"`
 	Size   uint32 `json:"size"`
 	Offset uint32 `json:"offset"`
+	Data   []byte `json:"-"` // NOTE: used instead of source when not nil
 }
@@ -0,0 +1,39 @@
+package tm3_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/anasrar/chihuahua/internal/testutils"
+	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/tm3"
+)
+
+func FuzzFromStream(f *testing.F) {
+	d := tm3.New()
+	d.AddEntryFromBytesWithName([]byte("entry"), "tex0")
+	d.AddEntryFromBytesWithName([]byte("another entry"), "tex1")
+
+	memory := buffer.NewMemory(nil)
+	if err := d.PackToStream(context.Background(), memory, testutils.Noop, testutils.Noop); err != nil {
+		f.Fatal(err)
+	}
+
+	f.Add(memory.Bytes())
+	f.Add([]byte{})
+	f.Add([]byte{'T', 'M', '3', 0x00, 0xFF, 0xFF, 0xFF, 0xFF})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		d := tm3.New()
+		if err := tm3.FromStream(d, bytes.NewReader(data)); err != nil {
+			return
+		}
+
+		for _, entry := range d.Entries {
+			if uint64(entry.Offset)+uint64(entry.Size) > uint64(len(data)) {
+				t.Fatalf("entry 0x%X+%d is outside of %d bytes", entry.Offset, entry.Size, len(data))
+			}
+		}
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 
 	"github.com/anasrar/chihuahua/pkg/buffer"
+	"github.com/anasrar/chihuahua/pkg/iso9660"
 	"github.com/anasrar/chihuahua/pkg/utils"
 )
 
@@ -17,36 +18,56 @@ const (
 )
 
 type Tm3 struct {
-	Offset     uint32   `json:"offset"`
-	Size       uint32   `json:"size"`
-	EntryTotal uint32   `json:"entry_total"`
-	Entries    []*Entry `json:"entries"`
+	Offset     uint32    `json:"offset"`
+	Size       uint32    `json:"size"`
+	EntryTotal uint32    `json:"entry_total"`
+	Unknown    [2]uint32 `json:"unknown"`
+	HeaderSize uint32    `json:"header_size"`
+	Alignment  uint32    `json:"alignment"`
+	Entries    []*Entry  `json:"entries"`
 }
 
-func (self *Tm3) unmarshal(source string, stream io.ReadWriteSeeker) error {
-	if self.Size == 0 {
-		size, _ := buffer.Seek(stream, 0, buffer.SeekEnd)
-		self.Size = uint32(size) - self.Offset
+func (self *Tm3) unmarshal(source string, stream io.ReadSeeker) error {
+	cursor := buffer.NewCursorLE(stream, "TM3")
+
+	size, err := cursor.Size()
+	if err != nil {
+		return err
+	}
+
+	if self.Size == 0 && uint64(self.Offset) <= size {
+		self.Size = uint32(size - uint64(self.Offset))
 	}
 
-	if _, err := buffer.Seek(stream, int64(self.Offset), buffer.SeekStart); err != nil {
+	if err := cursor.Within("Size", uint64(self.Offset), uint64(self.Size)); err != nil {
+		return err
+	}
+
+	if _, err := cursor.Move(int64(self.Offset), buffer.SeekStart); err != nil {
 		return err
 	}
 
 	signature := uint32(0)
-	if _, err := buffer.ReadUint32LE(stream, &signature); err != nil {
+	if _, err := cursor.ReadUint32("signature", &signature); err != nil {
 		return err
 	}
 
 	if signature != Signature {
-		return fmt.Errorf("TM3 signature not match")
+		return cursor.Mismatch("signature", Signature, signature)
 	}
 
-	if _, err := buffer.ReadUint32LE(stream, &self.EntryTotal); err != nil {
+	if _, err := cursor.ReadUint32("EntryTotal", &self.EntryTotal); err != nil {
 		return err
 	}
 
-	if _, err := buffer.Seek(stream, 8, buffer.SeekCurrent); err != nil {
+	for i := range self.Unknown {
+		if _, err := cursor.ReadUint32("Unknown", &self.Unknown[i]); err != nil {
+			return err
+		}
+	}
+
+	// NOTE: offset (uint32) + name (char[8]) for every entry
+	if err := cursor.Require("EntryTotal", uint64(self.EntryTotal)*12); err != nil {
 		return err
 	}
 
@@ -58,23 +79,32 @@ func (self *Tm3) unmarshal(source string, stream io.ReadWriteSeeker) error {
 			Offset: 0,
 		}
 
-		if _, err := buffer.ReadUint32LE(stream, &entry.Offset); err != nil {
+		if _, err := cursor.ReadUint32("entry.Offset", &entry.Offset); err != nil {
 			return err
 		}
 
+		if entry.Offset > self.Size {
+			return cursor.Invalid("entry.Offset", "offset 0x%X is outside of %d bytes container", entry.Offset, self.Size)
+		}
+
+		// NOTE: entry size is distance to the next entry, so offset must be in ascending order
+		if len(self.Entries) > 0 && entry.Offset+self.Offset < self.Entries[len(self.Entries)-1].Offset {
+			return cursor.Invalid("entry.Offset", "offset 0x%X is less than previous entry", entry.Offset)
+		}
+
 		entry.Offset += self.Offset
 
 		self.Entries = append(self.Entries, &entry)
 	}
 
 	if self.EntryTotal&0x1 == 1 {
-		if _, err := buffer.Seek(stream, 4, buffer.SeekCurrent); err != nil {
+		if _, err := cursor.Move(4, buffer.SeekCurrent); err != nil {
 			return err
 		}
 	}
 
 	for _, entry := range self.Entries {
-		if _, err := buffer.ReadString(stream, &entry.Name, EntryNameLength); err != nil {
+		if _, err := cursor.ReadString("entry.Name", &entry.Name, EntryNameLength); err != nil {
 			return err
 		}
 	}
@@ -88,10 +118,21 @@ func (self *Tm3) unmarshal(source string, stream io.ReadWriteSeeker) error {
 		}
 	}
 
+	// NOTE: keep header size and alignment so pack can reproduce original layout
+	if len(self.Entries) > 0 {
+		self.HeaderSize = self.Entries[0].Offset - self.Offset
+	}
+
+	offsets := []uint32{self.Size}
+	for _, entry := range self.Entries {
+		offsets = append(offsets, entry.Offset-self.Offset)
+	}
+	self.Alignment = utils.Alignment(offsets, MaxAlignment)
+
 	return nil
 }
 
-func pad(entryTotal uint32) uint32 {
+func headerSize(entryTotal uint32) uint32 {
 	// NOTE: entry total should even
 	if entryTotal&0x1 == 1 {
 		entryTotal += 1
@@ -101,6 +142,12 @@ func pad(entryTotal uint32) uint32 {
 	result += entryTotal * 4 // NOTE: offset relative to signature (uint32)
 	result += entryTotal * 8 // NOTE: name (char[8])
 
+	return result
+}
+
+func pad(entryTotal uint32) uint32 {
+	result := headerSize(entryTotal)
+
 	if result < 128 {
 		return 128
 	}
@@ -116,14 +163,27 @@ func (self *Tm3) Pack(
 	onStart,
 	onDone func(total uint32, current uint32, name string),
 ) error {
-	p := pad(self.EntryTotal)
-
 	packFile, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	if err != nil {
 		return err
 	}
 	defer packFile.Close()
 
+	return self.PackToStream(ctx, packFile, onStart, onDone)
+}
+
+func (self *Tm3) PackToStream(
+	ctx context.Context,
+	packFile io.WriteSeeker,
+	onStart,
+	onDone func(total uint32, current uint32, name string),
+) error {
+	// NOTE: use original header size when available, otherwise use default padding
+	p := pad(self.EntryTotal)
+	if self.HeaderSize >= headerSize(self.EntryTotal) {
+		p = self.HeaderSize
+	}
+
 	if _, err := buffer.WriteBytes(packFile, make([]byte, p)); err != nil {
 		return err
 	}
@@ -136,19 +196,21 @@ func (self *Tm3) Pack(
 		}
 		entry.Offset = uint32(position)
 
-		entryFile, err := os.Open(entry.Source)
-		if err != nil {
-			return err
+		buf := entry.Data
+		if buf == nil {
+			data, err := iso9660.ReadFile(entry.Source)
+			if err != nil {
+				return err
+			}
+			buf = data
 		}
-		defer entryFile.Close()
 
-		buf := make([]byte, entry.Size)
-
-		if _, err := entryFile.Read(buf); err != nil {
+		size := uint32(len(buf))
+		if _, err := buffer.WriteBytes(packFile, buf); err != nil {
 			return err
 		}
 
-		if _, err := packFile.Write(buf); err != nil {
+		if _, err := buffer.WriteBytes(packFile, make([]byte, utils.AlignUp(size, self.Alignment)-size)); err != nil {
 			return err
 		}
 
@@ -174,12 +236,8 @@ func (self *Tm3) Pack(
 		return err
 	}
 
-	// NOTE: unknown padding
-	{
-		if _, err := buffer.WriteUint32LE(packFile, 4); err != nil {
-			return err
-		}
-		if _, err := buffer.WriteUint32LE(packFile, 0); err != nil {
+	for _, unknown := range self.Unknown {
+		if _, err := buffer.WriteUint32LE(packFile, unknown); err != nil {
 			return err
 		}
 	}
@@ -218,7 +276,7 @@ func (self *Tm3) Unpack(
 
 		onStart(uint32(total), uint32(i+1), filename)
 
-		sourceFile, err := os.Open(entry.Source)
+		sourceFile, err := iso9660.Open(entry.Source)
 		if err != nil {
 			return err
 		}
@@ -269,7 +327,7 @@ func (self *Tm3) AddEntryFromPathWithName(
 		}
 	}
 
-	file, err := os.Open(source)
+	file, err := iso9660.Open(source)
 	if err != nil {
 		return err
 	}
@@ -295,17 +353,57 @@ func (self *Tm3) AddEntryFromPathWithName(
 	return nil
 }
 
+func (self *Tm3) AddEntryFromBytesWithName(
+	data []byte,
+	name string,
+) {
+	if len(name) > 8 {
+		name = name[:8]
+	} else if len(name) != 8 {
+		d := 8 - len(name)
+		for range d {
+			name += "\x00"
+		}
+	}
+
+	self.Entries = append(
+		self.Entries,
+		&Entry{
+			Source: "",
+			Name:   name,
+			Size:   uint32(len(data)),
+			Offset: 0,
+			Data:   data,
+		},
+	)
+
+	self.EntryTotal += 1
+}
+
 func New() *Tm3 {
 	return &Tm3{
 		Offset:     0,
 		Size:       0,
 		EntryTotal: 0,
+		Unknown:    [2]uint32{4, 0},
+		HeaderSize: 0,
+		Alignment:  0,
 		Entries:    []*Entry{},
 	}
 }
 
+func FromStreamWithOffsetSize(tm3 *Tm3, stream io.ReadSeeker, offset uint32, size uint32) error {
+	tm3.Offset = offset
+	tm3.Size = size
+	return tm3.unmarshal("", stream)
+}
+
+func FromStream(tm3 *Tm3, stream io.ReadSeeker) error {
+	return FromStreamWithOffsetSize(tm3, stream, 0, 0)
+}
+
 func FromPathWithOffsetSize(tm3 *Tm3, filePath string, offset uint32, size uint32) error {
-	file, err := os.Open(filePath)
+	file, err := iso9660.Open(filePath)
 	if err != nil {
 		return err
 	}
@@ -1,16 +1,26 @@
 package tm3_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/anasrar/chihuahua/internal/testutils"
+	"github.com/anasrar/chihuahua/pkg/buffer"
 	"github.com/anasrar/chihuahua/pkg/tm3"
+	"github.com/anasrar/chihuahua/pkg/utils"
 	"github.com/stretchr/testify/assert"
 )
 
 func Test(t *testing.T) {
 	t.Run("pl00.dat", func(t *testing.T) {
 		d := tm3.New()
-		if err := tm3.FromPathWithOffsetSize(d, "../../samples/pl00.dat", 4960, 149312); err != nil {
+		if err := tm3.FromPathWithOffsetSize(d, testutils.Sample(t, "pl00.dat"), 4960, 149312); err != nil {
 			t.Fatal(err)
 		}
 
@@ -20,7 +30,7 @@ func Test(t *testing.T) {
 
 	t.Run("ema0.dat", func(t *testing.T) {
 		d := tm3.New()
-		if err := tm3.FromPathWithOffsetSize(d, "../../samples/ema0.dat", 32, 163904); err != nil {
+		if err := tm3.FromPathWithOffsetSize(d, testutils.Sample(t, "ema0.dat"), 32, 163904); err != nil {
 			t.Fatal(err)
 		}
 
@@ -30,7 +40,7 @@ func Test(t *testing.T) {
 
 	t.Run("ema4.dat", func(t *testing.T) {
 		d := tm3.New()
-		if err := tm3.FromPathWithOffsetSize(d, "../../samples/ema4.dat", 800, 60160); err != nil {
+		if err := tm3.FromPathWithOffsetSize(d, testutils.Sample(t, "ema4.dat"), 800, 60160); err != nil {
 			t.Fatal(err)
 		}
 
@@ -40,7 +50,7 @@ func Test(t *testing.T) {
 
 	t.Run("ema6.dat", func(t *testing.T) {
 		d := tm3.New()
-		if err := tm3.FromPathWithOffsetSize(d, "../../samples/ema6.dat", 996672, 154432); err != nil {
+		if err := tm3.FromPathWithOffsetSize(d, testutils.Sample(t, "ema6.dat"), 996672, 154432); err != nil {
 			t.Fatal(err)
 		}
 
@@ -50,7 +60,7 @@ func Test(t *testing.T) {
 
 	t.Run("r100.dat: SCP", func(t *testing.T) {
 		d := tm3.New()
-		if err := tm3.FromPathWithOffsetSize(d, "../../samples/r100.dat", 800, 465152); err != nil {
+		if err := tm3.FromPathWithOffsetSize(d, testutils.Sample(t, "r100.dat"), 800, 465152); err != nil {
 			t.Fatal(err)
 		}
 
@@ -58,3 +68,280 @@ func Test(t *testing.T) {
 		assert.Equal(t, uint32(0x45), uint32(len(d.Entries)))
 	})
 }
+
+func repack(t *testing.T, source string, offset uint32, size uint32) ([]byte, []byte) {
+	d := tm3.New()
+	if err := tm3.FromPathWithOffsetSize(d, source, offset, size); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := d.Unpack(context.Background(), dir, testutils.Noop, testutils.Noop); err != nil {
+		t.Fatal(err)
+	}
+
+	p := tm3.New()
+	p.Unknown = d.Unknown
+	p.HeaderSize = d.HeaderSize
+	p.Alignment = d.Alignment
+	for i, entry := range d.Entries {
+		name := fmt.Sprintf("%s_%03d.tm3", utils.FilterUnprintableString(entry.Name), i)
+		if err := p.AddEntryFromPathWithName(filepath.Join(dir, name), entry.Name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	output := filepath.Join(dir, "OUTPUT.tm3")
+	if err := p.Pack(context.Background(), output, testutils.Noop, testutils.Noop); err != nil {
+		t.Fatal(err)
+	}
+
+	original, err := os.ReadFile(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size == 0 {
+		size = uint32(len(original)) - offset
+	}
+
+	packed, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return original[offset : offset+size], packed
+}
+
+func TestPack(t *testing.T) {
+	t.Run("synthetic", func(t *testing.T) {
+		dir := t.TempDir()
+
+		p := tm3.New()
+		p.Alignment = 16
+		for i, size := range []int{64, 100, 32} {
+			source := filepath.Join(dir, fmt.Sprintf("%d.tm3", i))
+			if err := os.WriteFile(source, bytes.Repeat([]byte{uint8(i + 1)}, size), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.AddEntryFromPathWithName(source, fmt.Sprintf("TEX%d", i)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		output := filepath.Join(dir, "SOURCE.tm3")
+		if err := p.Pack(context.Background(), output, testutils.Noop, testutils.Noop); err != nil {
+			t.Fatal(err)
+		}
+
+		original, packed := repack(t, output, 0, 0)
+		assert.Equal(t, original, packed)
+
+		d := tm3.New()
+		if err := tm3.FromPath(d, output); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, uint32(3), d.EntryTotal)
+		assert.Equal(t, [2]uint32{4, 0}, d.Unknown)
+		assert.Equal(t, uint32(128), d.HeaderSize)
+		assert.Equal(t, uint32(16), d.Alignment)
+		assert.Equal(t, []uint32{128, 192, 304}, []uint32{d.Entries[0].Offset, d.Entries[1].Offset, d.Entries[2].Offset})
+		assert.Equal(t, "TEX1\x00\x00\x00\x00", d.Entries[1].Name)
+
+		buf, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fromReader := tm3.New()
+		if err := tm3.FromStream(fromReader, bytes.NewReader(buf)); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, d.Entries[2].Offset, fromReader.Entries[2].Offset)
+
+		// NOTE: TM3 nested at offset 16 inside other data
+		nested := append(make([]byte, 16), buf...)
+		fromSection := tm3.New()
+		if err := tm3.FromStreamWithOffsetSize(fromSection, io.NewSectionReader(bytes.NewReader(nested), 0, int64(len(nested))), 16, uint32(len(buf))); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, d.Entries[2].Offset+16, fromSection.Entries[2].Offset)
+		assert.Equal(t, d.Entries[2].Size, fromSection.Entries[2].Size)
+	})
+
+	for _, s := range []struct {
+		name   string
+		offset uint32
+		size   uint32
+	}{
+		{"pl00.dat", 4960, 149312},
+		{"ema0.dat", 32, 163904},
+		{"ema4.dat", 800, 60160},
+		{"ema6.dat", 996672, 154432},
+		{"r100.dat", 800, 465152},
+	} {
+		t.Run(s.name, func(t *testing.T) {
+			source := testutils.Sample(t, s.name)
+
+			original, packed := repack(t, source, s.offset, s.size)
+			assert.Equal(t, len(original), len(packed))
+			assert.True(t, bytes.Equal(original, packed), "Repacked TM3 not match original")
+		})
+	}
+}
+
+func TestReplace(t *testing.T) {
+	dir := t.TempDir()
+
+	p := tm3.New()
+	p.Alignment = 16
+	for i, size := range []int{64, 100, 32} {
+		p.AddEntryFromBytesWithName(bytes.Repeat([]byte{uint8(i + 1)}, size), fmt.Sprintf("TEX%d", i))
+	}
+
+	memory := buffer.NewMemory(nil)
+	if err := p.PackToStream(context.Background(), memory, testutils.Noop, testutils.Noop); err != nil {
+		t.Fatal(err)
+	}
+
+	// NOTE: TM3 nested at offset 16 inside other data, result is TM3 only
+	source := filepath.Join(dir, "SOURCE.bin")
+	if err := os.WriteFile(source, append(make([]byte, 16), memory.Bytes()...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	original := tm3.New()
+	if err := tm3.FromPathWithOffsetSize(original, source, 16, uint32(len(memory.Bytes()))); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := original.EntryIndex("TEX1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := original.Replace(index, bytes.Repeat([]byte{9}, 20))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := tm3.New()
+	if err := tm3.FromStream(d, bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, uint32(3), d.EntryTotal)
+	assert.Equal(t, uint32(128), d.HeaderSize)
+	assert.Equal(t, []uint32{128, 192, 224}, []uint32{d.Entries[0].Offset, d.Entries[1].Offset, d.Entries[2].Offset})
+	assert.Equal(t, len(data), int(d.Entries[2].Offset+d.Entries[2].Size))
+
+	for i, expected := range [][]byte{bytes.Repeat([]byte{1}, 64), bytes.Repeat([]byte{9}, 20), bytes.Repeat([]byte{3}, 32)} {
+		entry := d.Entries[i]
+		assert.Equal(t, fmt.Sprintf("TEX%d\x00\x00\x00\x00", i), entry.Name)
+		assert.Equal(t, expected, data[entry.Offset:entry.Offset+uint32(len(expected))], "entry %d", i)
+		assert.Equal(t, uint32(0), entry.Offset%16, "entry %d", i)
+	}
+
+	_, err = original.Replace(3, nil)
+	assert.Error(t, err)
+}
+
+func TestParseError(t *testing.T) {
+	data := []byte{0x00, 0x00, 0x00, 0x00, 'T', 'I', 'M', '3'}
+
+	d := tm3.New()
+	err := tm3.FromStreamWithOffsetSize(d, bytes.NewReader(data), 4, uint32(len(data)-4))
+
+	var parseErr *buffer.ParseError
+	assert.True(t, errors.As(err, &parseErr))
+	assert.Equal(t, "TM3", parseErr.Format)
+	assert.Equal(t, "signature", parseErr.Field)
+	assert.Equal(t, uint64(4), parseErr.Offset)
+	assert.Equal(t, tm3.Signature, parseErr.Expected)
+}
+
+func TestMetadata(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string][]byte{"a.bin": {1, 2, 3}, "b.bin": {4, 5}} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	legacy := `{"entry_total":3,"entries":[` +
+		`{"source":"a.bin","name":"a"},` +
+		`{"source":"b.bin","name":"b"},` +
+		`{"source":"missing.bin","name":"TOOLONGNAME"}]}`
+	metadataPath := filepath.Join(dir, "METADATA.json")
+	if err := os.WriteFile(metadataPath, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var m tm3.Metadata
+	if err := tm3.MetadataFromPath(&m, metadataPath); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, tm3.MetadataVersion, m.Version)
+	assert.Equal(t, uint32(3), m.EntryTotal)
+
+	_, err := m.Validate(dir)
+	assert.ErrorContains(t, err, "Entry 2 name \"TOOLONGNAME\" longer than 8 characters")
+	assert.ErrorContains(t, err, "Entry 2 source missing.bin not found")
+	assert.NotContains(t, err.Error(), "Entry 0")
+
+	m.Entries = m.Entries[:2]
+	m.EntryTotal = 2
+	changes, err := m.Validate(dir)
+	assert.Nil(t, err)
+	assert.Empty(t, changes)
+
+	t.Run("changes", func(t *testing.T) {
+		m.Entries[1].Size = 2
+		m.Entries[1].Checksum = utils.Checksum([]byte{4, 5})
+
+		changes, err := m.Validate(dir)
+		assert.Nil(t, err)
+		assert.Empty(t, changes)
+
+		if err := os.WriteFile(filepath.Join(dir, "b.bin"), []byte{5, 4}, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		changes, err = m.Validate(dir)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{
+			fmt.Sprintf("Entry 1 source b.bin changed, checksum %s to %s", utils.Checksum([]byte{4, 5}), utils.Checksum([]byte{5, 4})),
+		}, changes)
+
+		m.Entries[1].Checksum = ""
+		m.Entries[1].Size = 0
+	})
+
+	t.Run("offset", func(t *testing.T) {
+		// NOTE: 2 entries header is padded to 0x80, a.bin (3 bytes) is aligned to 16
+		m.Alignment = 16
+		m.Entries[0].Offset = 0x80
+		m.Entries[1].Offset = 0x90
+
+		changes, err := m.Validate(dir)
+		assert.Nil(t, err)
+		assert.Empty(t, changes)
+
+		m.Entries[1].Offset = 0xA0
+		changes, err = m.Validate(dir)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"Entry 1 offset moved from 0xA0 to 0x90"}, changes)
+
+		m.Entries[1].Offset = 0x98
+		_, err = m.Validate(dir)
+		assert.EqualError(t, err, "Entry 1 offset 0x98 is not aligned to 16")
+
+		m.Entries[1].Offset = 0x40
+		_, err = m.Validate(dir)
+		assert.EqualError(t, err, "Entry 1 offset 0x40 is inside 128 bytes header")
+	})
+
+	m.Version = tm3.MetadataVersion + 1
+	assert.NotNil(t, m.Migrate())
+}
@@ -1,11 +1,83 @@
 package tm3
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/anasrar/chihuahua/pkg/utils"
+)
+
+const (
+	// NOTE: version 0 is metadata without version field (source, name only)
+	MetadataVersion uint32 = 1
+)
+
 type MetadataEntry struct {
-	Source string `json:"source"`
-	Name   string `json:"name"`
+	Source   string `json:"source"`
+	Name     string `json:"name"`
+	Size     uint32 `json:"size,omitempty"`
+	Checksum string `json:"checksum,omitempty"`
+	Offset   uint32 `json:"offset,omitempty"` // NOTE: offset relative to container when unpacked
 }
 
 type Metadata struct {
+	Version    uint32           `json:"version"`
 	EntryTotal uint32           `json:"entry_total"`
+	Unknown    *[2]uint32       `json:"unknown,omitempty"`
+	HeaderSize uint32           `json:"header_size,omitempty"`
+	Alignment  uint32           `json:"alignment,omitempty"`
 	Entries    []*MetadataEntry `json:"entries"`
 }
+
+func (self *Metadata) Migrate() error {
+	return utils.MigrateMetadata(&self.Version, MetadataVersion, &self.EntryTotal, len(self.Entries))
+}
+
+// NOTE: report every problem found before pack and every source changed since unpack, dir is METADATA.json parent directory
+func (self *Metadata) Validate(dir string) ([]string, error) {
+	errs := []error{}
+	sources := []*utils.MetadataSource{}
+	for i, entry := range self.Entries {
+		if len(entry.Name) > int(EntryNameLength) {
+			errs = append(errs, fmt.Errorf("Entry %d name %q longer than %d characters", i, entry.Name, EntryNameLength))
+		}
+
+		sources = append(sources, &utils.MetadataSource{Source: entry.Source, Size: entry.Size, Checksum: entry.Checksum, Offset: entry.Offset})
+	}
+
+	// NOTE: same header size as pack
+	start := pad(uint32(len(self.Entries)))
+	if self.HeaderSize >= headerSize(uint32(len(self.Entries))) {
+		start = self.HeaderSize
+	}
+
+	changes, err := utils.ValidateMetadata(dir, self.EntryTotal, self.Alignment, start, nil, sources)
+
+	return changes, errors.Join(append(errs, err)...)
+}
+
+func MetadataFromPath(metadata *Metadata, filePath string) error {
+	buf, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(buf, metadata); err != nil {
+		return err
+	}
+
+	return metadata.Migrate()
+}
+
+func MetadataToPath(metadata *Metadata, filePath string) error {
+	metadata.Version = MetadataVersion
+
+	buf, err := json.MarshalIndent(metadata, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filePath, buf, 0644)
+}
@@ -0,0 +1,74 @@
+package tm3
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+
+	"github.com/anasrar/chihuahua/pkg/iso9660"
+	"github.com/anasrar/chihuahua/pkg/utils"
+)
+
+const (
+	MaxAlignment uint32 = 2048
+)
+
+// NOTE: accept entry index, entry name, or unpack filename without extension (NAME_000)
+func (self *Tm3) EntryIndex(query string) (int, error) {
+	if index, err := strconv.Atoi(query); err == nil {
+		if index < 0 || index >= len(self.Entries) {
+			return 0, fmt.Errorf("Entry index %d out of range, total %d", index, len(self.Entries))
+		}
+
+		return index, nil
+	}
+
+	for i, entry := range self.Entries {
+		name := utils.FilterUnprintableString(entry.Name)
+		if name == query || fmt.Sprintf("%s_%03d", name, i) == query {
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("Entry %s not found", query)
+}
+
+// NOTE: rebuild TM3 with entry data replaced, original header and other entries are copied as is
+func (self *Tm3) Replace(index int, data []byte) ([]byte, error) {
+	if index < 0 || index >= len(self.Entries) {
+		return nil, fmt.Errorf("Entry index %d out of range, total %d", index, len(self.Entries))
+	}
+
+	sourceFile, err := iso9660.Open(self.Entries[0].Source)
+	if err != nil {
+		return nil, err
+	}
+	defer sourceFile.Close()
+
+	alignment := self.Alignment
+
+	result := make([]byte, self.Entries[0].Offset-self.Offset)
+	if _, err := sourceFile.ReadAt(result, int64(self.Offset)); err != nil {
+		return nil, err
+	}
+
+	for i, entry := range self.Entries {
+		// NOTE: signature(4) + entry total (4) + unknown (8) + offset (uint32)
+		binary.LittleEndian.PutUint32(result[16+i*4:], uint32(len(result)))
+
+		if i == index {
+			size := uint32(len(data))
+			result = append(result, data...)
+			result = append(result, make([]byte, utils.AlignUp(size, alignment)-size)...)
+			continue
+		}
+
+		buf := make([]byte, entry.Size)
+		if _, err := sourceFile.ReadAt(buf, int64(entry.Offset)); err != nil {
+			return nil, err
+		}
+		result = append(result, buf...)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+func Checksum(b []byte) string {
+	return fmt.Sprintf("%08x", crc32.ChecksumIEEE(b))
+}
+
+func ChecksumFile(p string) (string, error) {
+	file, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := crc32.NewIEEE()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%08x", hash.Sum32()), nil
+}
@@ -38,3 +38,23 @@ func PgHalfFloat32FromUint16(num uint16) float32 {
 
 	return math.Float32frombits(sign | expo | mant)
 }
+
+func AlignUp(value uint32, alignment uint32) uint32 {
+	if alignment == 0 {
+		return value
+	}
+
+	return (value + alignment - 1) / alignment * alignment
+}
+
+// NOTE: largest power of two (up to max) that divide every offset
+func Alignment(offsets []uint32, max uint32) uint32 {
+	alignment := max
+	for _, offset := range offsets {
+		for alignment > 1 && offset%alignment != 0 {
+			alignment >>= 1
+		}
+	}
+
+	return alignment
+}
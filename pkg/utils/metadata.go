@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// NOTE: source of DAT and TM3 METADATA.json entry, nil is null entry
+type MetadataSource struct {
+	Source   string
+	Size     uint32 // NOTE: size when unpacked, 0 when unknown (version 0)
+	Checksum string // NOTE: CRC32 when unpacked, empty when unknown (version 0)
+	Offset   uint32 // NOTE: offset in container when unpacked, 0 when unknown (version 0)
+}
+
+// NOTE: version 0 -> 1, size and checksum unknown (zero value)
+func MigrateMetadata(version *uint32, latest uint32, entryTotal *uint32, entries int) error {
+	if *version > latest {
+		return fmt.Errorf("Metadata version %d not supported, latest %d", *version, latest)
+	}
+
+	if *version == 0 {
+		if *entryTotal == 0 {
+			*entryTotal = uint32(entries)
+		}
+		*version = 1
+	}
+
+	return nil
+}
+
+// NOTE: problem that stop pack is returned as error. Source that is different from unpacked size or checksum
+// (edited file) is not an error, it is returned as changes so user can see what is going to be packed.
+// Offset is placed the same way as pack (header size, then every source in order aligned to alignment, nil order is
+// index order), offset different from unpacked offset is returned as changes because packed layout is not original
+func ValidateMetadata(
+	dir string,
+	entryTotal uint32,
+	alignment uint32,
+	headerSize uint32,
+	order []int,
+	sources []*MetadataSource,
+) ([]string, error) {
+	errs := []error{}
+	changes := []string{}
+
+	if entryTotal != uint32(len(sources)) {
+		errs = append(errs, fmt.Errorf("Entry total %d not match with entries %d", entryTotal, len(sources)))
+	}
+
+	if alignment != 0 && alignment&(alignment-1) != 0 {
+		errs = append(errs, fmt.Errorf("Alignment %d is not power of two", alignment))
+	}
+
+	if order == nil {
+		for i := range sources {
+			order = append(order, i)
+		}
+	}
+
+	align := uint64(max(alignment, 1))
+	total := uint64(headerSize)
+	for _, i := range order {
+		if i < 0 || i >= len(sources) || sources[i] == nil {
+			continue
+		}
+		source := sources[i]
+
+		p := filepath.Join(dir, source.Source)
+		stat, err := os.Stat(p)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Entry %d source %s not found", i, source.Source))
+			continue
+		}
+
+		if stat.IsDir() {
+			errs = append(errs, fmt.Errorf("Entry %d source %s is directory", i, source.Source))
+			continue
+		}
+
+		if stat.Size() > math.MaxUint32 {
+			errs = append(errs, fmt.Errorf("Entry %d source %s oversize, %d bytes", i, source.Source, stat.Size()))
+			continue
+		}
+
+		if source.Offset != 0 {
+			if uint64(source.Offset) < uint64(headerSize) {
+				errs = append(errs, fmt.Errorf("Entry %d offset 0x%X is inside %d bytes header", i, source.Offset, headerSize))
+			} else if uint64(source.Offset)%align != 0 {
+				errs = append(errs, fmt.Errorf("Entry %d offset 0x%X is not aligned to %d", i, source.Offset, align))
+			} else if uint64(source.Offset) != total {
+				changes = append(changes, fmt.Sprintf("Entry %d offset moved from 0x%X to 0x%X", i, source.Offset, total))
+			}
+		}
+
+		total += (uint64(stat.Size()) + align - 1) / align * align
+
+		sizeChanged := source.Size != 0 && uint64(source.Size) != uint64(stat.Size())
+		if source.Checksum == "" {
+			if sizeChanged {
+				changes = append(changes, fmt.Sprintf("Entry %d source %s size changed from %d to %d bytes", i, source.Source, source.Size, stat.Size()))
+			}
+			continue
+		}
+
+		checksum, err := ChecksumFile(p)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Entry %d source %s: %w", i, source.Source, err))
+			continue
+		}
+
+		if checksum == source.Checksum {
+			continue
+		}
+
+		if sizeChanged {
+			changes = append(changes, fmt.Sprintf("Entry %d source %s changed, checksum %s to %s, size %d to %d bytes", i, source.Source, source.Checksum, checksum, source.Size, stat.Size()))
+		} else {
+			changes = append(changes, fmt.Sprintf("Entry %d source %s changed, checksum %s to %s", i, source.Source, source.Checksum, checksum))
+		}
+	}
+
+	if total > math.MaxUint32 {
+		errs = append(errs, fmt.Errorf("Packed size %d exceed 32 bit offset", total))
+	}
+
+	return changes, errors.Join(errs...)
+}
@@ -23,6 +23,21 @@ func BasenameWithoutExt(p string) string {
 	return strings.TrimSuffix(Basename(p), filepath.Ext(p))
 }
 
+// NOTE: file inside ISO is written as game.iso:/DAT/pl00.dat, output is written next to ISO
 func ParentDirectory(p string) string {
+	if isoPath, _, found := SplitIsoPath(p); found {
+		return filepath.Dir(isoPath)
+	}
+
 	return filepath.Dir(p)
 }
+
+// NOTE: split game.iso:/DAT/pl00.dat into ISO path and path inside ISO, found is false when path is not inside ISO
+func SplitIsoPath(p string) (string, string, bool) {
+	i := strings.LastIndex(strings.ToLower(p), ".iso:")
+	if i < 0 {
+		return p, "", false
+	}
+
+	return p[:i+4], p[i+5:], true
+}
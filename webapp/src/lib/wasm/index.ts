@@ -0,0 +1,128 @@
+import { base } from "$app/paths";
+import type { Result } from "@/result";
+
+// NOTE: build with `GOOS=js GOARCH=wasm go build -o webapp/static/chihuahua.wasm ./cmd/wasm`
+// and copy `$(go env GOROOT)/lib/wasm/wasm_exec.js` (Go < 1.24: `misc/wasm`) to `webapp/static`
+
+export type WasmImage = {
+	width: number;
+	height: number;
+	pixels: Uint8Array;
+};
+
+export type WasmPicture = WasmImage & {
+	imageType: string;
+	clutColors: number;
+	clut: Uint8Array;
+};
+
+// NOTE: parsed struct as plain object, field name is the same as Go json tag
+export type WasmJson = { [key: string]: unknown };
+
+export type WasmDatEntry = {
+	isNull: boolean;
+	type: string;
+	offset: number;
+	size: number;
+	data: Uint8Array | null;
+};
+
+export type WasmDat = {
+	headerSize: number;
+	alignment: number;
+	order: number[];
+	entries: WasmDatEntry[];
+};
+
+export type WasmDatPack = {
+	headerSize?: number;
+	alignment?: number;
+	order?: number[];
+	entries: { isNull: boolean; type?: string; data?: Uint8Array }[];
+};
+
+export type WasmTm3Entry = {
+	name: string;
+	offset: number;
+	size: number;
+	data: Uint8Array;
+};
+
+export type WasmTm3 = {
+	unknown: [number, number];
+	headerSize: number;
+	alignment: number;
+	entries: WasmTm3Entry[];
+};
+
+export type WasmTm3Pack = {
+	unknown?: [number, number];
+	headerSize?: number;
+	alignment?: number;
+	entries: { name: string; data: Uint8Array }[];
+};
+
+export type Chihuahua = {
+	version: string;
+	datUnpack: (dat: Uint8Array) => Result<WasmDat>;
+	datPack: (dat: WasmDatPack) => Result<Uint8Array>;
+	tm3Unpack: (tm3: Uint8Array) => Result<WasmTm3>;
+	tm3Pack: (tm3: WasmTm3Pack) => Result<Uint8Array>;
+	tim2ToImages: (tim2: Uint8Array) => Result<WasmPicture[]>;
+	tim3ToImages: (tim3: Uint8Array) => Result<WasmPicture[]>;
+	t32ToImage: (t32: Uint8Array) => Result<WasmImage>;
+	pngToTim2: (png: Uint8Array, bpp?: 4 | 8) => Result<Uint8Array>;
+	pngToTim3: (png: Uint8Array, bpp?: 4 | 8) => Result<Uint8Array>;
+	pngToT32: (t32: Uint8Array, png: Uint8Array) => Result<Uint8Array>;
+	scrToGlb: (scr: Uint8Array, tm3?: Uint8Array | null, textureBase?: number) => Result<Uint8Array>;
+	mdbParse: (mdb: Uint8Array) => Result<WasmJson>;
+	motParse: (mot: Uint8Array) => Result<WasmJson>;
+	emsParse: (ems: Uint8Array) => Result<WasmJson>;
+	omsParse: (oms: Uint8Array) => Result<WasmJson>;
+	akgParse: (akg: Uint8Array) => Result<WasmJson>;
+};
+
+declare global {
+	// eslint-disable-next-line no-var
+	var chihuahua: Chihuahua | undefined;
+	// eslint-disable-next-line @typescript-eslint/no-explicit-any
+	var Go: any;
+}
+
+let loading: Promise<Chihuahua> | null = null;
+
+const loadScript = (src: string): Promise<void> =>
+	new Promise((resolve, reject) => {
+		const script = document.createElement("script");
+		script.src = src;
+		script.onload = () => resolve();
+		script.onerror = () => reject(new Error(`Failed to load ${src}`));
+		document.head.appendChild(script);
+	});
+
+export const loadChihuahua = (): Promise<Chihuahua> => {
+	if (loading) {
+		return loading;
+	}
+
+	loading = (async () => {
+		if (!globalThis.Go) {
+			await loadScript(`${base}/wasm_exec.js`);
+		}
+
+		const go = new globalThis.Go();
+		const { instance } = await WebAssembly.instantiateStreaming(
+			fetch(`${base}/chihuahua.wasm`),
+			go.importObject,
+		);
+		go.run(instance);
+
+		if (!globalThis.chihuahua) {
+			throw new Error("Chihuahua WebAssembly not initialized");
+		}
+
+		return globalThis.chihuahua;
+	})();
+
+	return loading;
+};